@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"dot-be-go/config"
+	"dot-be-go/internal/app/api/validation"
+	"dot-be-go/internal/domain/repository"
+	"dot-be-go/internal/service"
+	"dot-be-go/pkg/booklookup"
+	"dot-be-go/pkg/breach"
+	"dot-be-go/pkg/hash"
+	"dot-be-go/pkg/jwt"
+	"dot-be-go/pkg/mailer"
+	"dot-be-go/pkg/oidc"
+	"dot-be-go/pkg/storage"
+	"dot-be-go/pkg/throttle"
+
+	"gorm.io/gorm"
+)
+
+// app holds the repositories and services shared by the server and the CLI
+type app struct {
+	userRepo          repository.UserRepository
+	categoryRepo      repository.CategoryRepository
+	bookRepo          repository.BookRepository
+	refreshTokenRepo  repository.RefreshTokenRepository
+	bookSearchRepo    repository.BookSearchRepository
+	roleRepo          repository.RoleRepository
+	passwordResetRepo repository.PasswordResetTokenRepository
+	recoveryCodeRepo  repository.RecoveryCodeRepository
+	apiKeyRepo        repository.APIKeyRepository
+	identityRepo      repository.IdentityRepository
+	auditLogRepo      repository.AuditLogRepository
+	bookImportRepo    repository.BookImportRepository
+
+	authThrottle *service.AuthThrottle
+	keys         *jwt.KeyRing
+	passwords    *service.Passwords
+	auditor      *service.Auditor
+	mailQueue    *mailer.Queue
+
+	authService       service.AuthService
+	userService       service.UserService
+	categoryService   service.CategoryService
+	bookService       service.BookService
+	roleService       service.RoleService
+	auditService      service.AuditService
+	bookImportService service.BookImportService
+}
+
+// cliActor is recorded in the audit log for changes made from the command line
+var cliActor = service.Actor{UserAgent: "cli"}
+
+// newApp wires repositories and services on top of an open database
+func newApp(cfg *config.Config, db *gorm.DB) *app {
+	a := &app{}
+
+	// Initialize repositories
+	a.userRepo = repository.NewUserRepository(db)
+	a.categoryRepo = repository.NewCategoryRepository(db)
+	a.bookRepo = repository.NewBookRepository(db)
+	a.refreshTokenRepo = repository.NewRefreshTokenRepository(db)
+	a.bookSearchRepo = repository.NewBookSearchRepository(db, cfg.DBDriver)
+	a.roleRepo = repository.NewRoleRepository(db)
+	a.passwordResetRepo = repository.NewPasswordResetTokenRepository(db)
+	a.recoveryCodeRepo = repository.NewRecoveryCodeRepository(db)
+	a.apiKeyRepo = repository.NewAPIKeyRepository(db)
+	a.identityRepo = repository.NewIdentityRepository(db)
+	a.auditLogRepo = repository.NewAuditLogRepository(db)
+	a.bookImportRepo = repository.NewBookImportRepository(db)
+
+	// Initialize services
+	keys, err := newKeyRing(cfg)
+	if err != nil {
+		panic("Failed to load JWT keys: " + err.Error())
+	}
+	a.keys = keys
+	passwords, err := newPasswords(cfg)
+	if err != nil {
+		panic("Failed to configure password hashing: " + err.Error())
+	}
+	a.passwords = passwords
+	a.authThrottle = newAuthThrottle(cfg, db)
+	a.auditor = service.NewAuditor(a.auditLogRepo)
+	mailQueue, err := newMailQueue(cfg)
+	if err != nil {
+		panic("Failed to configure mail: " + err.Error())
+	}
+	a.mailQueue = mailQueue
+	a.userService = service.NewUserService(a.userRepo, a.roleRepo, a.refreshTokenRepo, a.authThrottle, a.passwords, a.auditor)
+	a.categoryService = service.NewCategoryService(a.categoryRepo, a.auditor)
+	bookLookup, err := newBookLookup(cfg)
+	if err != nil {
+		panic("Failed to configure book lookup: " + err.Error())
+	}
+	covers, err := newStorage(cfg)
+	if err != nil {
+		panic("Failed to configure storage: " + err.Error())
+	}
+	a.bookService = service.NewBookService(a.bookRepo, a.categoryRepo, a.bookSearchRepo, a.auditor, bookLookup, covers, service.BookCoverConfig{
+		MaxSize:   int64(cfg.CoverMaxSize),
+		MaxPixels: cfg.CoverMaxPixels,
+	})
+	a.authService = service.NewAuthService(service.AuthServiceDeps{
+		UserRepo:      a.userRepo,
+		TokenRepo:     a.refreshTokenRepo,
+		RoleRepo:      a.roleRepo,
+		ResetRepo:     a.passwordResetRepo,
+		RecoveryRepo:  a.recoveryCodeRepo,
+		APIKeyRepo:    a.apiKeyRepo,
+		IdentityRepo:  a.identityRepo,
+		Books:         a.bookService,
+		Mailer:        a.mailQueue,
+		Passwords:     a.passwords,
+		Throttle:      a.authThrottle,
+		Audit:         a.auditor,
+		Issuer:        cfg.AppName,
+		Keys:          a.keys,
+		JWTSecret:     cfg.JWTSecretKey,
+		JWTExpiry:     cfg.JWTExpiry,
+		RefreshExpiry: cfg.JWTRefreshExpiry,
+		Verification:  emailVerificationConfig(cfg),
+		OIDCProviders: newOIDCProviders(cfg),
+	})
+	a.roleService = service.NewRoleService(a.roleRepo, a.userRepo, a.refreshTokenRepo, a.auditor)
+	a.auditService = service.NewAuditService(a.auditLogRepo)
+	subjectRules, err := loadSubjectRules(cfg.MARCSubjectRulesFile)
+	if err != nil {
+		panic("Failed to load MARC subject rules: " + err.Error())
+	}
+	a.bookImportService = service.NewBookImportService(a.bookRepo, a.categoryRepo, a.bookImportRepo, validation.New(), a.auditor, service.BookImportConfig{
+		MaxRows:      cfg.BookImportMaxRows,
+		SyncRows:     cfg.BookImportSyncRows,
+		SubjectRules: subjectRules,
+	})
+
+	return a
+}
+
+// emailVerificationConfig collects the email verification settings
+func emailVerificationConfig(cfg *config.Config) service.EmailVerificationConfig {
+	return service.EmailVerificationConfig{
+		Required:              cfg.EmailVerificationRequired,
+		UnverifiedPermissions: cfg.UnverifiedPermissions,
+		Expiry:                cfg.EmailVerificationExpiry,
+		URL:                   cfg.EmailVerificationURL,
+	}
+}
+
+// newKeyRing loads the access token keys: the PEM key in
+// JWT_SIGNING_KEY_FILE plus the rotated out keys in
+// JWT_VERIFICATION_KEY_FILES, or HS256 with JWT_SECRET when no key file is set
+func newKeyRing(cfg *config.Config) (*jwt.KeyRing, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		return jwt.NewHMACKeyRing(cfg.JWTSecretKey), nil
+	}
+	return jwt.LoadKeyRing(cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles)
+}
+
+// newPasswords creates the password hashing selected by PASSWORD_HASHER,
+// argon2id or bcrypt, and the password policy. PASSWORD_BREACHED_LIST
+// enables the breached password check.
+func newPasswords(cfg *config.Config) (*service.Passwords, error) {
+	var algorithm hash.Algorithm
+	var err error
+	switch cfg.PasswordHasher {
+	case "argon2id":
+		if cfg.Argon2Memory < 0 || cfg.Argon2Time < 0 || cfg.Argon2Parallelism < 0 || cfg.Argon2Parallelism > 255 {
+			return nil, fmt.Errorf("argon2id costs out of range")
+		}
+		algorithm, err = hash.NewArgon2id(uint32(cfg.Argon2Memory), uint32(cfg.Argon2Time), uint8(cfg.Argon2Parallelism))
+	case "bcrypt":
+		algorithm, err = hash.NewBcrypt(cfg.BcryptCost)
+	default:
+		err = fmt.Errorf("unknown PASSWORD_HASHER %q", cfg.PasswordHasher)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passwords := &service.Passwords{
+		Hasher:    hash.NewHasher(algorithm),
+		MinLength: cfg.PasswordMinLength,
+	}
+	if cfg.PasswordBreachedList != "" {
+		if passwords.Breached, err = breach.Open(cfg.PasswordBreachedList); err != nil {
+			return nil, err
+		}
+	}
+	return passwords, nil
+}
+
+// loadSubjectRules reads the JSON array of MARC subject rules in path, if any
+func loadSubjectRules(path string) ([]service.SubjectRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []service.SubjectRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// newBookLookup chains the metadata providers named in BOOK_LOOKUP_PROVIDERS
+// behind a cache. Lookups are disabled when none is named.
+func newBookLookup(cfg *config.Config) (booklookup.Provider, error) {
+	client := &http.Client{Timeout: cfg.BookLookupTimeout}
+
+	var chain booklookup.Chain
+	for _, name := range cfg.BookLookupProviders {
+		switch name {
+		case "openlibrary":
+			chain = append(chain, booklookup.NewOpenLibrary(cfg.OpenLibraryURL, client))
+		case "googlebooks":
+			chain = append(chain, booklookup.NewGoogleBooks(cfg.GoogleBooksURL, cfg.GoogleBooksAPIKey, client))
+		case "fixture":
+			fixtures, err := booklookup.LoadFixtures(cfg.BookLookupFixtures)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, fixtures)
+		default:
+			return nil, fmt.Errorf("unknown book lookup provider %q", name)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return booklookup.NewCache(chain, cfg.BookLookupCacheTTL, cfg.BookLookupCacheSize), nil
+}
+
+// newStorage creates the blob storage selected by STORAGE_DRIVER: files
+// under STORAGE_DIR served at STORAGE_URL, or an S3 compatible bucket
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "local":
+		return storage.NewLocal(cfg.StorageDir, cfg.StorageURL), nil
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.S3PublicURL,
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// newOIDCProviders creates the OpenID Connect providers listed in OIDC_PROVIDERS
+func newOIDCProviders(cfg *config.Config) []*oidc.Provider {
+	providers := make([]*oidc.Provider, len(cfg.OIDCProviders))
+	for i, p := range cfg.OIDCProviders {
+		providers[i] = oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+			RedirectURL:  p.RedirectURL,
+		}, nil)
+	}
+	return providers
+}
+
+// newAuthThrottle creates the login throttling selected by THROTTLE_STORE:
+// database for deployments with several instances, or memory
+func newAuthThrottle(cfg *config.Config, db *gorm.DB) *service.AuthThrottle {
+	var store throttle.Store
+	if cfg.ThrottleStore == "database" {
+		store = repository.NewThrottleStore(db)
+	} else {
+		store = throttle.NewMemoryStore()
+	}
+
+	policy := throttle.Policy{
+		MaxAttempts: cfg.LoginMaxAttempts,
+		BaseDelay:   cfg.LockoutBaseDelay,
+		MaxDelay:    cfg.LockoutMaxDelay,
+		Window:      cfg.ThrottleWindow,
+	}
+	ipPolicy := policy
+	ipPolicy.MaxAttempts = cfg.LoginIPMaxAttempts
+
+	return &service.AuthThrottle{
+		Account: throttle.NewLimiter(store, policy),
+		IP:      throttle.NewLimiter(store, ipPolicy),
+	}
+}
+
+// newMailQueue selects the mailer from MAIL_DRIVER and sends through it in
+// the background. Requests never wait on the mail server, so endpoints such
+// as password reset answer equally fast whether or not an email is sent.
+func newMailQueue(cfg *config.Config) (*mailer.Queue, error) {
+	if cfg.MailQueueSize < 1 {
+		return nil, fmt.Errorf("MAIL_QUEUE_SIZE must be at least 1")
+	}
+
+	var next mailer.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		next = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	case "file":
+		next = mailer.NewFileMailer(cfg.MailFile, cfg.MailFrom)
+	default:
+		next = mailer.NewLogMailer(log.New(os.Stdout, "", log.LstdFlags))
+	}
+
+	return mailer.NewQueue(next, cfg.MailQueueSize, log.New(os.Stderr, "", log.LstdFlags)), nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dot-be-go/config"
+	"dot-be-go/internal/app/api/validation"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/service"
+)
+
+const bookUsage = `usage: book <command> [flags]
+
+commands:
+  export            export books as CSV, JSON, NDJSON, MARCXML, BibTeX or RIS
+  import-marc       import binary MARC 21 or MARCXML records
+  normalize-isbn    rewrite stored ISBNs to canonical ISBN-13 and report invalid ones`
+
+// runBookCommand implements the book subcommands
+func runBookCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, bookUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "export":
+		runBookExport(cfg, args[1:])
+	case "import-marc":
+		runBookImportMARC(cfg, args[1:])
+	case "normalize-isbn":
+		a := newApp(cfg, setupDatabase(cfg))
+		result, err := a.bookService.NormalizeISBNs(cliActor)
+		if err != nil {
+			fail("Failed to normalize ISBNs: " + err.Error())
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(result)
+
+		if len(result.Failed) > 0 {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, bookUsage)
+		os.Exit(2)
+	}
+}
+
+func runBookExport(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("book export", flag.ExitOnError)
+	userEmail := fs.String("user", "", "only export the books of the user with this email")
+	format := fs.String("format", "csv", "output format: csv, json, ndjson, marcxml, bibtex or ris")
+	output := fs.String("output", "", "output file (default stdout)")
+	_ = fs.Parse(args)
+
+	export, err := service.NewBookExport(*format)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	a := newApp(cfg, setupDatabase(cfg))
+
+	var userID uint
+	if *userEmail != "" {
+		user, err := a.userRepo.FindByEmail(*userEmail)
+		if err != nil {
+			fail("Failed to find user: " + err.Error())
+		}
+		userID = user.ID
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail("Failed to create output file: " + err.Error())
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err = export.Write(w, func(fn func(book *entity.Book) error) error {
+		return a.bookService.Each(userID, fn)
+	})
+	if err != nil {
+		fail("Failed to export books: " + err.Error())
+	}
+}
+
+func runBookImportMARC(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("book import-marc", flag.ExitOnError)
+	file := fs.String("file", "", "MARC 21 (.mrc) or MARCXML (.xml) file")
+	userEmail := fs.String("user", "", "email of the user owning the imported books")
+	format := fs.String("format", "", "input format: marc or marcxml (default from the file extension)")
+	mode := fs.String("mode", entity.BookImportBestEffort, "best_effort or atomic")
+	dryRun := fs.Bool("dry-run", false, "validate the records without saving them")
+	upsert := fs.Bool("upsert", false, "update books with a matching ISBN")
+	unknownSubjects := fs.String("unknown-subjects", service.UnknownSubjectsSkip, "subjects without a category: skip, create or reject")
+	rulesFile := fs.String("subject-rules", "", "JSON file of subject rules applied before MARC_SUBJECT_RULES")
+	_ = fs.Parse(args)
+	requireFlags(fs, map[string]string{"file": *file, "user": *userEmail})
+
+	if *format == "" {
+		*format = service.BookImportMARC
+		if strings.EqualFold(filepath.Ext(*file), ".xml") {
+			*format = service.BookImportMARCXML
+		}
+	}
+	if *format != service.BookImportMARC && *format != service.BookImportMARCXML {
+		fail("format must be one of: marc, marcxml")
+	}
+
+	req := &service.BookImportRequest{
+		Format:          *format,
+		Mode:            *mode,
+		DryRun:          *dryRun,
+		Upsert:          *upsert,
+		UnknownSubjects: *unknownSubjects,
+	}
+	if *rulesFile != "" {
+		rules, err := os.ReadFile(*rulesFile)
+		if err != nil {
+			fail("Failed to read subject rules: " + err.Error())
+		}
+		req.SubjectRules = string(rules)
+	}
+	if err := validation.New().Validate(req); err != nil {
+		fail(err.Error())
+	}
+
+	a := newApp(cfg, setupDatabase(cfg))
+	user, err := a.userRepo.FindByEmail(*userEmail)
+	if err != nil {
+		fail("Failed to find user: " + err.Error())
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fail("Failed to open import file: " + err.Error())
+	}
+	defer f.Close()
+
+	actor := cliActor
+	actor.UserID = user.ID
+	job, err := a.bookImportService.Start(actor, user.ID, req, f)
+	if err != nil {
+		fail("Failed to import books: " + err.Error())
+	}
+
+	// Large files are imported in the background
+	for !job.IsFinished() {
+		time.Sleep(time.Second)
+		if job, err = a.bookImportService.GetByID(job.ID, user.ID); err != nil {
+			fail("Failed to check the import: " + err.Error())
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(job)
+
+	err = a.bookImportService.EachError(job, func(rowError *entity.BookImportError) error {
+		_, err := fmt.Fprintf(os.Stderr, "record %d %s: %s (%s)\n", rowError.Line, rowError.ISBN, rowError.Message, rowError.Field)
+		return err
+	})
+	if err != nil {
+		fail("Failed to list import errors: " + err.Error())
+	}
+
+	if job.Status != entity.BookImportCompleted || job.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dot-be-go/config"
+	"dot-be-go/internal/service"
+)
+
+const categoryUsage = `usage: category <command> [flags]
+
+commands:
+  import    import categories from a CSV file with a name,description header or a JSON array`
+
+// runCategoryCommand implements the category subcommands
+func runCategoryCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(os.Stderr, categoryUsage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("category import", flag.ExitOnError)
+	file := fs.String("file", "", "path to a .csv or .json file (required)")
+	update := fs.Bool("update", false, "replace the description of categories that already exist")
+	_ = fs.Parse(args[1:])
+	requireFlags(fs, map[string]string{"file": *file})
+
+	reqs, err := readCategoryFile(*file)
+	if err != nil {
+		fail("Failed to read categories: " + err.Error())
+	}
+
+	a := newApp(cfg, setupDatabase(cfg))
+	result := a.categoryService.Import(cliActor, reqs, *update)
+
+	fmt.Printf("Created %d, updated %d, skipped %d, failed %d\n", result.Created, result.Updated, result.Skipped, len(result.Failed))
+	for _, issue := range result.Failed {
+		fmt.Fprintf(os.Stderr, "  %q: %s\n", issue.Name, issue.Reason)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readCategoryFile parses categories from a CSV or JSON file
+func readCategoryFile(path string) ([]service.CategoryRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var reqs []service.CategoryRequest
+		if err := json.NewDecoder(f).Decode(&reqs); err != nil {
+			return nil, err
+		}
+		return reqs, nil
+	case ".csv":
+		return readCategoryCSV(f)
+	default:
+		return nil, errors.New("file must have a .csv or .json extension")
+	}
+}
+
+func readCategoryCSV(r io.Reader) ([]service.CategoryRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	nameCol, descriptionCol := -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name":
+			nameCol = i
+		case "description":
+			descriptionCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, errors.New("CSV header must contain a name column")
+	}
+
+	var reqs []service.CategoryRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		req := service.CategoryRequest{}
+		if nameCol < len(record) {
+			req.Name = record[nameCol]
+		}
+		if descriptionCol >= 0 && descriptionCol < len(record) {
+			req.Description = record[descriptionCol]
+		}
+		reqs = append(reqs, req)
+	}
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"dot-be-go/config"
 	"dot-be-go/internal/app/api/handlers"
 	"dot-be-go/internal/app/api/routes"
 	"dot-be-go/internal/domain/entity"
-	"dot-be-go/internal/domain/repository"
-	"dot-be-go/internal/service"
-	"dot-be-go/pkg/hash"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/driver/mysql"
@@ -18,47 +23,126 @@ import (
 	"gorm.io/gorm"
 )
 
+const usage = `usage: dot-be-go <command> [arguments]
+
+commands:
+  serve                  start the HTTP API (default)
+  migrate                manage database migrations
+  user create            create a user, e.g. the first admin with --role admin
+  user set-role          change the role of a user
+  user reset-password    set a new password and revoke all sessions
+  user unlock            clear the failed logins of a locked out user
+  category import        import categories from a CSV or JSON file
+  book export            export books as CSV or JSON
+  book normalize-isbn    rewrite stored ISBNs to canonical ISBN-13
+
+Run "dot-be-go <command> -h" for the flags of a command.`
+
+// shutdownTimeout bounds how long the server waits for requests in flight
+// and queued emails when it is stopped
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.New()
 
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+
+	switch command {
+	case "serve":
+		runServe(cfg)
+	case "migrate":
+		runMigrateCommand(cfg, args)
+	case "user":
+		runUserCommand(cfg, args)
+	case "category":
+		runCategoryCommand(cfg, args)
+	case "book":
+		runBookCommand(cfg, args)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// runServe starts the HTTP API
+func runServe(cfg *config.Config) {
+	// JWT_SECRET also signs verification and MFA tokens, so the public
+	// default must never reach production
+	if !cfg.IsDevelopment() && (cfg.JWTSecretKey == "" || cfg.JWTSecretKey == config.DefaultJWTSecret) {
+		fmt.Fprintln(os.Stderr, "JWT_SECRET must be set to a private value unless APP_ENV=development")
+		os.Exit(1)
+	}
+
 	// Setup database
 	db := setupDatabase(cfg)
 
-	// Auto migrate database models
-	err := db.AutoMigrate(
-		&entity.User{},
-		&entity.Category{},
-		&entity.Book{},
-	)
-	if err != nil {
-		panic("Failed to migrate database: " + err.Error())
+	// Apply pending migrations. The migrator holds an advisory lock, so
+	// replicas starting together migrate one at a time.
+	if cfg.DBAutoMigrate {
+		migrator, err := newMigrator(db, cfg)
+		if err != nil {
+			panic("Failed to load migrations: " + err.Error())
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			panic("Failed to migrate database: " + err.Error())
+		}
 	}
 
-	// Create admin user if not exists
-	createAdminUser(db)
-
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	categoryRepo := repository.NewCategoryRepository(db)
-	bookRepo := repository.NewBookRepository(db)
+	a := newApp(cfg, db)
 
-	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg.JWTSecretKey, cfg.JWTExpiry)
-	categoryService := service.NewCategoryService(categoryRepo)
-	bookService := service.NewBookService(bookRepo, categoryRepo)
+	// No default admin is created; point operators at the bootstrap command
+	if count, err := a.userRepo.CountByRole(entity.RoleAdmin); err == nil && count == 0 {
+		fmt.Println("No admin user exists. Create one with: dot-be-go user create --role admin --email <email> --name <name>")
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(authService, bookService, categoryService)
+	handler := handlers.NewHandler(a.authService, a.bookService, a.categoryService, a.roleService, a.userService, a.auditService, a.bookImportService)
 
-	// Setup Echo
+	// Setup Echo. X-Forwarded-For is only trusted behind a proxy, otherwise
+	// clients could dodge the per-IP login throttling.
 	e := echo.New()
+	if cfg.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	// Setup routes
-	routes.SetupRoutes(e, handler, cfg.JWTSecretKey)
+	routes.SetupRoutes(e, handler, a.keys)
+
+	// Serve locally stored covers unless STORAGE_URL points elsewhere, e.g.
+	// to a web server or CDN in front of STORAGE_DIR
+	if cfg.StorageDriver == "local" && strings.HasPrefix(cfg.StorageURL, "/") {
+		e.Static(cfg.StorageURL, cfg.StorageDir)
+	}
 
 	// Start server
-	e.Logger.Fatal(e.Start(":" + strconv.Itoa(cfg.AppPort)))
+	go func() {
+		if err := e.Start(":" + strconv.Itoa(cfg.AppPort)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM finish the requests in flight, then send the
+	// emails they queued, which were already promised to the clients
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		e.Logger.Error(err)
+	}
+	if err := a.mailQueue.Close(ctx); err != nil {
+		e.Logger.Error("mail queue not drained: ", err)
+	}
 }
 
 func setupDatabase(cfg *config.Config) *gorm.DB {
@@ -77,24 +161,3 @@ func setupDatabase(cfg *config.Config) *gorm.DB {
 
 	return db
 }
-
-func createAdminUser(db *gorm.DB) {
-	// Check if admin user exists
-	var count int64
-	db.Model(&entity.User{}).Where("role = ?", "admin").Count(&count)
-	if count > 0 {
-		return
-	}
-
-	// Create admin user if not exists
-	hashedPassword, _ := hash.GenerateHash("admin123")
-	adminUser := entity.User{
-		Name:     "Admin",
-		Email:    "admin@example.com",
-		Password: hashedPassword,
-		Role:     "admin",
-	}
-
-	db.Create(&adminUser)
-	fmt.Println("Admin user created with email: admin@example.com and password: admin123")
-}
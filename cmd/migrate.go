@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"dot-be-go/config"
+	"dot-be-go/migrations"
+	"dot-be-go/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [steps]    roll back the last applied migration, or the given number of migrations
+  status          list migrations and whether they are applied
+  to <version>    migrate up or down to the given version (0 rolls back everything)`
+
+// newMigrator creates a migrator for the configured database driver
+func newMigrator(db *gorm.DB, cfg *config.Config) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := migrate.Load(migrations.FS, cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(sqlDB, cfg.DBDriver, list)
+}
+
+// runMigrateCommand implements the migrate subcommand
+func runMigrateCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	db := setupDatabase(cfg)
+	migrator, err := newMigrator(db, cfg)
+	if err != nil {
+		fail("Failed to load migrations: " + err.Error())
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		printMigrations("Applied", done)
+		if err != nil {
+			fail(err.Error())
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fail("steps must be a positive number")
+			}
+		}
+		done, err := migrator.Down(ctx, steps)
+		printMigrations("Rolled back", done)
+		if err != nil {
+			fail(err.Error())
+		}
+	case "to":
+		if len(args) < 2 {
+			fail("missing target version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			fail("version must be a non-negative number")
+		}
+		done, err := migrator.To(ctx, version)
+		printMigrations("Migrated", done)
+		if err != nil {
+			fail(err.Error())
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fail(err.Error())
+		}
+		printStatus(statuses)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
+
+func printMigrations(verb string, done []migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, m := range done {
+		fmt.Printf("%s %06d_%s\n", verb, m.Version, m.Name)
+	}
+}
+
+func printStatus(statuses []migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.ChecksumMismatch {
+			state = "modified"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"dot-be-go/config"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/service"
+	"dot-be-go/pkg/token"
+)
+
+const userUsage = `usage: user <command> [flags]
+
+commands:
+  create            create a user
+  set-role          replace the roles of a user
+  reset-password    set a new password and revoke all sessions of a user
+  unlock            clear the failed logins of a locked out user`
+
+// runUserCommand implements the user subcommands
+func runUserCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ExitOnError)
+		email := fs.String("email", "", "email address (required)")
+		name := fs.String("name", "", "display name (required)")
+		roles := fs.String("role", entity.RoleUser, "comma separated roles, e.g. admin or user,editor")
+		password, passwordStdin := passwordFlags(fs)
+		_ = fs.Parse(args[1:])
+		requireFlags(fs, map[string]string{"email": *email, "name": *name})
+
+		pw, generated := resolvePassword(*password, *passwordStdin)
+		a := newApp(cfg, setupDatabase(cfg))
+		user, err := a.userService.Create(cliActor, &service.CreateUserRequest{
+			Email:    *email,
+			Name:     *name,
+			Password: pw,
+			Roles:    splitList(*roles),
+		})
+		if err != nil {
+			fail("Failed to create user: " + err.Error())
+		}
+
+		fmt.Printf("Created user %s (id %d) with roles %s\n", user.Email, user.ID, strings.Join(user.RoleNames(), ", "))
+		if generated {
+			fmt.Printf("Generated password: %s\n", pw)
+		}
+	case "set-role":
+		fs := flag.NewFlagSet("user set-role", flag.ExitOnError)
+		email := fs.String("email", "", "email address (required)")
+		roles := fs.String("role", "", "comma separated roles (required)")
+		_ = fs.Parse(args[1:])
+		requireFlags(fs, map[string]string{"email": *email, "role": *roles})
+
+		a := newApp(cfg, setupDatabase(cfg))
+		user, err := a.userRepo.FindByEmail(*email)
+		if err != nil {
+			fail("Failed to find user: " + err.Error())
+		}
+		if user, err = a.roleService.AssignRoles(cliActor, user.ID, splitList(*roles)); err != nil {
+			fail("Failed to set roles: " + err.Error())
+		}
+
+		fmt.Printf("User %s now has roles %s\n", user.Email, strings.Join(user.RoleNames(), ", "))
+	case "reset-password":
+		fs := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+		email := fs.String("email", "", "email address (required)")
+		password, passwordStdin := passwordFlags(fs)
+		_ = fs.Parse(args[1:])
+		requireFlags(fs, map[string]string{"email": *email})
+
+		pw, generated := resolvePassword(*password, *passwordStdin)
+		a := newApp(cfg, setupDatabase(cfg))
+		user, err := a.userService.ResetPassword(cliActor, *email, pw)
+		if err != nil {
+			fail("Failed to reset password: " + err.Error())
+		}
+
+		fmt.Printf("Password of %s reset and all sessions revoked\n", user.Email)
+		if generated {
+			fmt.Printf("Generated password: %s\n", pw)
+		}
+	case "unlock":
+		fs := flag.NewFlagSet("user unlock", flag.ExitOnError)
+		email := fs.String("email", "", "email address (required)")
+		_ = fs.Parse(args[1:])
+		requireFlags(fs, map[string]string{"email": *email})
+
+		a := newApp(cfg, setupDatabase(cfg))
+		user, err := a.userRepo.FindByEmail(*email)
+		if err != nil {
+			fail("Failed to find user: " + err.Error())
+		}
+		if _, err := a.userService.Unlock(cliActor, user.ID); err != nil {
+			fail("Failed to unlock user: " + err.Error())
+		}
+
+		fmt.Printf("Failed logins of %s cleared\n", user.Email)
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+}
+
+func passwordFlags(fs *flag.FlagSet) (*string, *bool) {
+	password := fs.String("password", "", "password; a random one is generated and printed once when omitted")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+	return password, passwordStdin
+}
+
+// resolvePassword returns the password to use and whether it was generated
+func resolvePassword(password string, fromStdin bool) (string, bool) {
+	if fromStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fail("Failed to read password from stdin")
+		}
+		return strings.TrimRight(line, "\r\n"), false
+	}
+	if password != "" {
+		return password, false
+	}
+
+	generated, err := token.Generate(12)
+	if err != nil {
+		fail("Failed to generate password: " + err.Error())
+	}
+	return generated, true
+}
+
+func requireFlags(fs *flag.FlagSet, values map[string]string) {
+	for name, value := range values {
+		if value == "" {
+			fmt.Fprintf(os.Stderr, "-%s is required\n", name)
+			fs.Usage()
+			os.Exit(2)
+		}
+	}
+}
+
+// splitList splits a comma separated flag value and drops empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,19 +7,98 @@ import (
 	"time"
 )
 
+// DefaultJWTSecret is the development fallback for JWT_SECRET. The server
+// refuses to start with it outside development.
+const DefaultJWTSecret = "mySecretKey"
+
+// OIDCProvider configures an OpenID Connect login provider
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+}
+
 // Config represents application configuration
 type Config struct {
-	AppName      string
-	AppPort      int
-	DBDriver     string
-	DBHost       string
-	DBPort       int
-	DBUser       string
-	DBPassword   string
-	DBName       string
-	DBUrl        string
-	JWTSecretKey string
-	JWTExpiry    time.Duration
+	AppName          string
+	AppEnv           string
+	AppPort          int
+	DBDriver         string
+	DBHost           string
+	DBPort           int
+	DBUser           string
+	DBPassword       string
+	DBName           string
+	DBUrl            string
+	DBAutoMigrate    bool
+	JWTSecretKey     string
+	JWTExpiry        time.Duration
+	JWTRefreshExpiry time.Duration
+	MailDriver       string
+	MailFrom         string
+	MailFile         string
+	MailQueueSize    int
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	// Email verification
+	EmailVerificationRequired bool
+	EmailVerificationExpiry   time.Duration
+	EmailVerificationURL      string
+	UnverifiedPermissions     []string
+	// Brute-force protection
+	ThrottleStore      string
+	LoginMaxAttempts   int
+	LoginIPMaxAttempts int
+	LockoutBaseDelay   time.Duration
+	LockoutMaxDelay    time.Duration
+	ThrottleWindow     time.Duration
+	TrustProxy         bool
+	// Asymmetric access token signing; HS256 with JWTSecretKey when unset
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+	// OpenID Connect login
+	OIDCProviders []OIDCProvider
+	// Password hashing and policy
+	PasswordHasher       string
+	Argon2Memory         int
+	Argon2Time           int
+	Argon2Parallelism    int
+	BcryptCost           int
+	PasswordMinLength    int
+	PasswordBreachedList string
+	// Bulk book import
+	BookImportMaxRows  int
+	BookImportSyncRows int
+	// JSON file of the subject rules of MARC imports
+	MARCSubjectRulesFile string
+	// Book metadata lookup by ISBN
+	BookLookupProviders []string
+	BookLookupTimeout   time.Duration
+	BookLookupCacheTTL  time.Duration
+	BookLookupCacheSize int
+	BookLookupFixtures  string
+	OpenLibraryURL      string
+	GoogleBooksURL      string
+	GoogleBooksAPIKey   string
+	// Blob storage of cover images: local or s3
+	StorageDriver string
+	StorageDir    string
+	StorageURL    string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3PublicURL   string
+	S3PathStyle   bool
+	// Cover upload limits
+	CoverMaxSize   int
+	CoverMaxPixels int
 }
 
 // New returns application configuration
@@ -29,18 +108,105 @@ func New() *Config {
 	dbDriver, dbHost, dbPort, dbUser, dbPassword, dbName := parseDbUrl(dbUrl)
 
 	return &Config{
-		AppName:      getEnv("APP_NAME", "dot-be-go"),
-		AppPort:      getEnvAsInt("APP_PORT", 8080),
-		DBDriver:     dbDriver,
-		DBHost:       dbHost,
-		DBPort:       dbPort,
-		DBUser:       dbUser,
-		DBPassword:   dbPassword,
-		DBName:       dbName,
-		DBUrl:        dbUrl,
-		JWTSecretKey: getEnv("JWT_SECRET", "mySecretKey"),
-		JWTExpiry:    time.Duration(getEnvAsInt("JWT_EXPIRY", 24)) * time.Hour,
+		AppName:          getEnv("APP_NAME", "dot-be-go"),
+		AppEnv:           getEnv("APP_ENV", "production"),
+		AppPort:          getEnvAsInt("APP_PORT", 8080),
+		DBDriver:         dbDriver,
+		DBHost:           dbHost,
+		DBPort:           dbPort,
+		DBUser:           dbUser,
+		DBPassword:       dbPassword,
+		DBName:           dbName,
+		DBUrl:            dbUrl,
+		DBAutoMigrate:    getEnvAsBool("DB_AUTO_MIGRATE", true),
+		JWTSecretKey:     getEnv("JWT_SECRET", DefaultJWTSecret),
+		JWTExpiry:        getEnvAsDuration("JWT_EXPIRY", 15*time.Minute),
+		JWTRefreshExpiry: getEnvAsDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour),
+		MailDriver:       getEnv("MAIL_DRIVER", "log"),
+		MailFrom:         getEnv("MAIL_FROM", "no-reply@dot-be-go.local"),
+		MailFile:         getEnv("MAIL_FILE", "mail.log"),
+		MailQueueSize:    getEnvAsInt("MAIL_QUEUE_SIZE", 1000),
+		SMTPHost:         getEnv("SMTP_HOST", "127.0.0.1"),
+		SMTPPort:         getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+
+		EmailVerificationRequired: getEnvAsBool("EMAIL_VERIFICATION_REQUIRED", true),
+		EmailVerificationExpiry:   getEnvAsDuration("EMAIL_VERIFICATION_EXPIRY", 48*time.Hour),
+		EmailVerificationURL:      getEnv("EMAIL_VERIFICATION_URL", "http://localhost:8080/verify-email"),
+		UnverifiedPermissions:     getEnvAsList("UNVERIFIED_PERMISSIONS", []string{"book:read"}),
+
+		ThrottleStore:      getEnv("THROTTLE_STORE", "memory"),
+		LoginMaxAttempts:   getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginIPMaxAttempts: getEnvAsInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		LockoutBaseDelay:   getEnvAsDuration("LOCKOUT_BASE_DELAY", 30*time.Second),
+		LockoutMaxDelay:    getEnvAsDuration("LOCKOUT_MAX_DELAY", 15*time.Minute),
+		ThrottleWindow:     getEnvAsDuration("THROTTLE_WINDOW", time.Hour),
+		TrustProxy:         getEnvAsBool("TRUST_PROXY", false),
+
+		JWTSigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles: getEnvAsList("JWT_VERIFICATION_KEY_FILES", nil),
+
+		OIDCProviders: oidcProviders(),
+
+		PasswordHasher:       getEnv("PASSWORD_HASHER", "argon2id"),
+		Argon2Memory:         getEnvAsInt("ARGON2_MEMORY", 64*1024),
+		Argon2Time:           getEnvAsInt("ARGON2_TIME", 3),
+		Argon2Parallelism:    getEnvAsInt("ARGON2_PARALLELISM", 2),
+		BcryptCost:           getEnvAsInt("BCRYPT_COST", 10),
+		PasswordMinLength:    getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordBreachedList: getEnv("PASSWORD_BREACHED_LIST", ""),
+
+		BookImportMaxRows:    getEnvAsInt("BOOK_IMPORT_MAX_ROWS", 50000),
+		BookImportSyncRows:   getEnvAsInt("BOOK_IMPORT_SYNC_ROWS", 100),
+		MARCSubjectRulesFile: getEnv("MARC_SUBJECT_RULES", ""),
+
+		BookLookupProviders: getEnvAsList("BOOK_LOOKUP_PROVIDERS", []string{"openlibrary", "googlebooks"}),
+		BookLookupTimeout:   getEnvAsDuration("BOOK_LOOKUP_TIMEOUT", 5*time.Second),
+		BookLookupCacheTTL:  getEnvAsDuration("BOOK_LOOKUP_CACHE_TTL", 24*time.Hour),
+		BookLookupCacheSize: getEnvAsInt("BOOK_LOOKUP_CACHE_SIZE", 10000),
+		BookLookupFixtures:  getEnv("BOOK_LOOKUP_FIXTURES", ""),
+		OpenLibraryURL:      getEnv("OPEN_LIBRARY_URL", "https://openlibrary.org"),
+		GoogleBooksURL:      getEnv("GOOGLE_BOOKS_URL", "https://www.googleapis.com"),
+		GoogleBooksAPIKey:   getEnv("GOOGLE_BOOKS_API_KEY", ""),
+
+		StorageDriver:  getEnv("STORAGE_DRIVER", "local"),
+		StorageDir:     getEnv("STORAGE_DIR", "storage"),
+		StorageURL:     getEnv("STORAGE_URL", "/media"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		S3PublicURL:    getEnv("S3_PUBLIC_URL", ""),
+		S3PathStyle:    getEnvAsBool("S3_PATH_STYLE", false),
+		CoverMaxSize:   getEnvAsInt("COVER_MAX_SIZE", 5<<20),
+		CoverMaxPixels: getEnvAsInt("COVER_MAX_PIXELS", 25000000),
+	}
+}
+
+// oidcProviders reads the providers named in OIDC_PROVIDERS. Each provider
+// is configured by OIDC_<NAME>_* variables, e.g. OIDC_GOOGLE_ISSUER.
+func oidcProviders() []OIDCProvider {
+	names := getEnvAsList("OIDC_PROVIDERS", nil)
+	providers := make([]OIDCProvider, 0, len(names))
+	for _, name := range names {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnvAsList(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", "http://localhost:8080/auth/callback/"+name),
+		})
 	}
+	return providers
+}
+
+// IsDevelopment reports whether APP_ENV selects development mode
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development"
 }
 
 // DBConnectionString returns database connection string
@@ -131,3 +297,42 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList reads a comma separated list, dropping empty items
+func getEnvAsList(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsDuration reads a duration such as "15m". Plain integers are
+// treated as hours to stay compatible with the original JWT_EXPIRY format.
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if i, err := strconv.Atoi(value); err == nil {
+			return time.Duration(i) * time.Hour
+		}
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
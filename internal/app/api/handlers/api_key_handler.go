@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAPIKeys lists the API keys of the current user
+func (h *Handler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.AuthService.ListAPIKeys(c.Get("user_id").(uint))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey creates an API key for the current user. The key itself is
+// only part of this response.
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	req := new(service.APIKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	key, err := h.AuthService.CreateAPIKey(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey revokes an API key of the current user
+func (h *Handler) RevokeAPIKey(c echo.Context) error {
+	id, err := parseID(c, "id", "API key")
+	if err != nil {
+		return err
+	}
+
+	if err := h.AuthService.RevokeAPIKey(auditActor(c), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
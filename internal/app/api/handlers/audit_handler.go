@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAuditLogs returns a page of audit log entries, filtered by actor_id,
+// action, target_type, target_id, from and to
+func (h *Handler) GetAuditLogs(c echo.Context) error {
+	params, err := parseListParams(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.AuditService.GetAll(filter, params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(c, page))
+}
+
+// ExportAuditLogs streams the matching audit log entries as JSON lines,
+// oldest first
+func (h *Handler) ExportAuditLogs(c echo.Context) error {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit-logs.jsonl"`)
+	res.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(res)
+	return h.AuditService.Each(filter, func(entry *entity.AuditLog) error {
+		return encoder.Encode(entry)
+	})
+}
+
+// parseAuditLogFilter reads the audit log filters from the query string
+func parseAuditLogFilter(c echo.Context) (repository.AuditLogFilter, error) {
+	filter := repository.AuditLogFilter{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+	}
+
+	var err error
+	if filter.ActorID, err = queryUint(c, "actor_id"); err != nil {
+		return filter, err
+	}
+	if filter.TargetID, err = queryUint(c, "target_id"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = queryTime(c, "from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to", true); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
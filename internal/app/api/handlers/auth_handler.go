@@ -10,18 +10,19 @@ import (
 
 // Register registers a new user
 func (h *Handler) Register(c echo.Context) error {
-	req := new(service.AuthRequest)
+	req := new(service.RegisterRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
+	req.Actor = auditActor(c)
 
-	if req.Name == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
 	resp, err := h.AuthService.Register(req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, resp)
@@ -31,25 +32,181 @@ func (h *Handler) Register(c echo.Context) error {
 func (h *Handler) Login(c echo.Context) error {
 	req := new(service.AuthRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, challenge, err := h.AuthService.Login(req)
+	if err != nil {
+		return err
+	}
+	if challenge != nil {
+		return c.JSON(http.StatusOK, challenge)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CompleteMFALogin finishes a two-factor login with a TOTP or recovery code
+func (h *Handler) CompleteMFALogin(c echo.Context) error {
+	req := new(service.MFALoginRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
-	resp, err := h.AuthService.Login(req)
+	resp, err := h.AuthService.CompleteMFALogin(req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, resp)
 }
 
+// RefreshToken rotates a refresh token and returns a new token pair
+func (h *Handler) RefreshToken(c echo.Context) error {
+	req := new(service.RefreshRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := h.AuthService.Refresh(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the session of a refresh token
+func (h *Handler) Logout(c echo.Context) error {
+	req := new(service.RefreshRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.Logout(req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Messages returned whether or not the email belongs to an account
+const (
+	forgotPasswordMessage     = "if an account with that email exists, a password reset token has been sent"
+	resendVerificationMessage = "if an unverified account with that email exists, a verification link has been sent"
+)
+
+// ForgotPassword mails a password reset token
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	req := new(service.ForgotPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.ForgotPassword(req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": forgotPasswordMessage})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *Handler) ResetPassword(c echo.Context) error {
+	req := new(service.ResetPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.ResetPassword(req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// VerifyEmail marks an email address as verified
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	req := new(service.VerifyEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	user, err := h.AuthService.VerifyEmail(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification mails a new email verification link
+func (h *Handler) ResendVerification(c echo.Context) error {
+	req := new(service.ResendVerificationRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.ResendVerification(req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": resendVerificationMessage})
+}
+
 // GetProfile returns the user profile
 func (h *Handler) GetProfile(c echo.Context) error {
 	userID := c.Get("user_id").(uint)
 
 	user, err := h.AuthService.GetUserByID(userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
+
+// JWKS publishes the public keys access tokens are signed with. Clients may
+// cache the set briefly; rotated keys stay listed during the overlap.
+func (h *Handler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.AuthService.JWKS())
+}
@@ -2,112 +2,291 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
+	"strings"
 
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
 	"dot-be-go/internal/service"
+	"dot-be-go/pkg/isbn"
 
 	"github.com/labstack/echo/v4"
 )
 
 // CreateBook creates a new book
 func (h *Handler) CreateBook(c echo.Context) error {
-	userID := c.Get("user_id").(uint)
+	req, err := h.bindBookRequest(c)
+	if err != nil {
+		return err
+	}
 
-	req := new(service.BookRequest)
+	book, err := h.BookService.Create(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, book)
+}
+
+// LookupBook finds the metadata of a book by ISBN, e.g. to prefill a new book
+func (h *Handler) LookupBook(c echo.Context) error {
+	req := new(service.BookLookupRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	book, err := h.BookService.Create(userID, req)
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	result, err := h.BookService.Lookup(req.ISBN)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, book)
+	return c.JSON(http.StatusOK, result)
 }
 
-// GetAllBooks returns all books for a user
+// GetAllBooks returns a page of books for a user
 func (h *Handler) GetAllBooks(c echo.Context) error {
 	userID := c.Get("user_id").(uint)
 
-	books, err := h.BookService.GetAll(userID)
+	params, err := parseListParams(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, books)
+	filter, err := parseBookFilter(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.BookService.GetAll(userID, filter, params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(c, page))
 }
 
-// GetBookByID returns a book by ID for a user
-func (h *Handler) GetBookByID(c echo.Context) error {
+// SearchBooks runs a full-text search over the user's books
+func (h *Handler) SearchBooks(c echo.Context) error {
 	userID := c.Get("user_id").(uint)
 
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return apperror.Invalid("invalid_query_parameter", "q is required")
+	}
+
+	params, err := parseListParams(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID")
+		return err
 	}
 
-	book, err := h.BookService.GetByID(uint(id), userID)
+	page, err := h.BookService.Search(userID, query, params)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(c, page))
+}
+
+// ExportBooks streams the user's books in the format given by the format
+// query parameter: csv (default), json, ndjson, marcxml, bibtex or ris
+func (h *Handler) ExportBooks(c echo.Context) error {
+	return h.exportBooks(c, c.Get("user_id").(uint))
+}
+
+// ExportAllBooks streams the books of every user, like ExportBooks
+func (h *Handler) ExportAllBooks(c echo.Context) error {
+	return h.exportBooks(c, 0)
+}
+
+// exportBooks streams the books of a user, or of every user when userID is zero
+func (h *Handler) exportBooks(c echo.Context, userID uint) error {
+	export, err := service.NewBookExport(c.QueryParam("format"))
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, export.ContentType)
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="books`+export.Extension+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	return export.Write(res, func(fn func(book *entity.Book) error) error {
+		return h.BookService.Each(userID, fn)
+	})
+}
+
+// GetBookByID returns a book by ID for a user, or of any user with book:read:any
+func (h *Handler) GetBookByID(c echo.Context) error {
+	userID := bookOwnerScope(c, entity.PermissionBookReadAny)
+
+	id, err := parseID(c, "id", "book")
+	if err != nil {
+		return err
+	}
+
+	book, err := h.BookService.GetByID(id, userID)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, book)
 }
 
-// UpdateBook updates a book
+// UpdateBook updates a book of the user, or of any user with book:write:any
 func (h *Handler) UpdateBook(c echo.Context) error {
-	userID := c.Get("user_id").(uint)
+	userID := bookOwnerScope(c, entity.PermissionBookWriteAny)
 
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := parseID(c, "id", "book")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID")
+		return err
 	}
 
-	req := new(service.BookRequest)
-	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	req, err := h.bindBookRequest(c)
+	if err != nil {
+		return err
 	}
 
-	book, err := h.BookService.Update(uint(id), userID, req)
+	book, err := h.BookService.Update(auditActor(c), id, userID, req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, book)
 }
 
-// DeleteBook deletes a book
+// DeleteBook deletes a book of the user, or of any user with book:write:any
 func (h *Handler) DeleteBook(c echo.Context) error {
-	userID := c.Get("user_id").(uint)
+	userID := bookOwnerScope(c, entity.PermissionBookWriteAny)
 
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := parseID(c, "id", "book")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID")
+		return err
 	}
 
-	if err := h.BookService.Delete(uint(id), userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := h.BookService.Delete(auditActor(c), id, userID); err != nil {
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// SetBookCover uploads the cover image of a book of the user, or of any
+// user with book:write:any, from the multipart field file
+func (h *Handler) SetBookCover(c echo.Context) error {
+	userID := bookOwnerScope(c, entity.PermissionBookWriteAny)
+
+	id, err := parseID(c, "id", "book")
+	if err != nil {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperror.ValidationField("file", "required", "file is required")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	book, err := h.BookService.SetCover(auditActor(c), id, userID, file)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, book)
+}
+
+// DeleteBookCover removes the cover image of a book like SetBookCover
+func (h *Handler) DeleteBookCover(c echo.Context) error {
+	userID := bookOwnerScope(c, entity.PermissionBookWriteAny)
+
+	id, err := parseID(c, "id", "book")
+	if err != nil {
+		return err
+	}
+
+	book, err := h.BookService.DeleteCover(auditActor(c), id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, book)
+}
+
 // GetBooksByCategory returns books by category
 func (h *Handler) GetBooksByCategory(c echo.Context) error {
-	categoryIDParam := c.Param("categoryId")
-	categoryID, err := strconv.ParseUint(categoryIDParam, 10, 64)
+	categoryID, err := parseID(c, "categoryId", "category")
+	if err != nil {
+		return err
+	}
+
+	params, err := parseListParams(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseBookFilter(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid category ID")
+		return err
 	}
 
-	books, err := h.BookService.GetByCategory(uint(categoryID))
+	page, err := h.BookService.GetByCategory(categoryID, filter, params)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(c, page))
+}
+
+// bindBookRequest binds and validates a book request. With autofill the
+// empty fields are filled from the metadata of the ISBN before validation.
+func (h *Handler) bindBookRequest(c echo.Context) (*service.BookRequest, error) {
+	req := new(service.BookRequest)
+	if err := c.Bind(req); err != nil {
+		return nil, err
+	}
+
+	if req.Autofill {
+		if err := h.BookService.Autofill(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Validate(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// parseBookFilter reads the book listing filters from the query string
+func parseBookFilter(c echo.Context) (repository.BookFilter, error) {
+	filter := repository.BookFilter{
+		Author:     c.QueryParam("author"),
+		ISBNPrefix: isbn.Clean(c.QueryParam("isbn_prefix")),
+	}
+
+	var err error
+	if filter.PublishYearFrom, err = queryInt(c, "publish_year_from"); err != nil {
+		return filter, err
+	}
+	if filter.PublishYearTo, err = queryInt(c, "publish_year_to"); err != nil {
+		return filter, err
+	}
+	if filter.CategoryIDs, err = queryUintList(c, "category_ids"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, err = queryTime(c, "created_from", false); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = queryTime(c, "created_to", true); err != nil {
+		return filter, err
 	}
 
-	return c.JSON(http.StatusOK, books)
+	return filter, nil
 }
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportBooks imports the books of an uploaded CSV or NDJSON file. Small
+// files are imported before responding with 201; larger ones are accepted
+// with 202 and imported in the background.
+func (h *Handler) ImportBooks(c echo.Context) error {
+	return h.importBooks(c, service.BookImportCSV, service.BookImportNDJSON)
+}
+
+// ImportMARCBooks imports the records of an uploaded binary MARC 21 or
+// MARCXML file like ImportBooks. Creating categories for unknown subject
+// headings requires category:write.
+func (h *Handler) ImportMARCBooks(c echo.Context) error {
+	return h.importBooks(c, service.BookImportMARC, service.BookImportMARCXML)
+}
+
+// importBooks starts an import of an uploaded file in one of formats
+func (h *Handler) importBooks(c echo.Context, formats ...string) error {
+	req := new(service.BookImportRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if req.UnknownSubjects == service.UnknownSubjectsCreate && !hasPermission(c, entity.PermissionCategoryWrite) {
+		return apperror.Forbidden("permission_denied", "missing permission "+entity.PermissionCategoryWrite)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperror.ValidationField("file", "required", "file is required")
+	}
+	if req.Format == "" {
+		if req.Format = importFormat(header.Filename); req.Format == "" {
+			return apperror.ValidationField("format", "required", "format is required when the file extension is not recognized")
+		}
+	}
+	if !slices.Contains(formats, req.Format) {
+		return apperror.ValidationField("format", "oneof", "format must be one of: "+strings.Join(formats, ", "))
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	job, err := h.BookImportService.Start(auditActor(c), bookOwnerScope(c, entity.PermissionBookWriteAny), req, file)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/books/imports/%d", job.ID))
+	if !job.IsFinished() {
+		return c.JSON(http.StatusAccepted, job)
+	}
+	return c.JSON(http.StatusCreated, job)
+}
+
+// GetBookImport returns the progress of an import started by the user, or
+// by any user with book:write:any
+func (h *Handler) GetBookImport(c echo.Context) error {
+	job, err := h.findBookImport(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// GetBookImportErrors downloads the rows an import rejected as CSV
+func (h *Handler) GetBookImportErrors(c echo.Context) error {
+	job, err := h.findBookImport(c)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="book-import-%d-errors.csv"`, job.ID))
+	res.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(res)
+	if err := writer.Write([]string{"line", "isbn", "field", "rule", "message"}); err != nil {
+		return err
+	}
+	err = h.BookImportService.EachError(job, func(rowError *entity.BookImportError) error {
+		return writer.Write([]string{strconv.Itoa(rowError.Line), rowError.ISBN, rowError.Field, rowError.Rule, rowError.Message})
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (h *Handler) findBookImport(c echo.Context) (*entity.BookImport, error) {
+	userID := bookOwnerScope(c, entity.PermissionBookWriteAny)
+
+	id, err := parseID(c, "id", "book import")
+	if err != nil {
+		return nil, err
+	}
+
+	return h.BookImportService.GetByID(id, userID)
+}
+
+// importFormat infers the format of an import file from its extension
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return service.BookImportCSV
+	case ".ndjson", ".jsonl":
+		return service.BookImportNDJSON
+	case ".mrc", ".marc":
+		return service.BookImportMARC
+	case ".xml":
+		return service.BookImportMARCXML
+	}
+	return ""
+}
@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
+	"dot-be-go/internal/domain/repository"
 	"dot-be-go/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -13,38 +13,48 @@ import (
 func (h *Handler) CreateCategory(c echo.Context) error {
 	req := new(service.CategoryRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	category, err := h.CategoryService.Create(req)
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	category, err := h.CategoryService.Create(auditActor(c), req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, category)
 }
 
-// GetAllCategories returns all categories
+// GetAllCategories returns a page of categories
 func (h *Handler) GetAllCategories(c echo.Context) error {
-	categories, err := h.CategoryService.GetAll()
+	params, err := parseListParams(c)
+	if err != nil {
+		return err
+	}
+
+	filter := repository.CategoryFilter{Name: c.QueryParam("name")}
+
+	page, err := h.CategoryService.GetAll(filter, params)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, categories)
+	return c.JSON(http.StatusOK, newListResponse(c, page))
 }
 
 // GetCategoryByID returns a category by ID
 func (h *Handler) GetCategoryByID(c echo.Context) error {
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := parseID(c, "id", "category")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid category ID")
+		return err
 	}
 
-	category, err := h.CategoryService.GetByID(uint(id))
+	category, err := h.CategoryService.GetByID(id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, category)
@@ -52,20 +62,23 @@ func (h *Handler) GetCategoryByID(c echo.Context) error {
 
 // UpdateCategory updates a category
 func (h *Handler) UpdateCategory(c echo.Context) error {
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := parseID(c, "id", "category")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid category ID")
+		return err
 	}
 
 	req := new(service.CategoryRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
 	}
 
-	category, err := h.CategoryService.Update(uint(id), req)
+	category, err := h.CategoryService.Update(auditActor(c), id, req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, category)
@@ -73,14 +86,13 @@ func (h *Handler) UpdateCategory(c echo.Context) error {
 
 // DeleteCategory deletes a category
 func (h *Handler) DeleteCategory(c echo.Context) error {
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 64)
+	id, err := parseID(c, "id", "category")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid category ID")
+		return err
 	}
 
-	if err := h.CategoryService.Delete(uint(id)); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	if err := h.CategoryService.Delete(auditActor(c), id); err != nil {
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 error responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypePrefix namespaces the problem type URIs by error code
+const problemTypePrefix = "urn:dot-be-go:problem:"
+
+// Problem represents an RFC 7807 problem details response
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"request_id,omitempty"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// kindStatus maps domain error kinds to HTTP statuses
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindInvalid:      http.StatusBadRequest,
+	apperror.KindValidation:   http.StatusUnprocessableEntity,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindRateLimited:  http.StatusTooManyRequests,
+	apperror.KindUnavailable:  http.StatusServiceUnavailable,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+// ErrorHandler renders every error returned by a handler or middleware as an
+// application/problem+json response. Unexpected errors are logged and
+// reported without their details.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	problem := NewProblem(err)
+	problem.Instance = c.Request().URL.Path
+	problem.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if problem.Status >= http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+	if appErr, ok := apperror.As(err); ok && appErr.RetryAfter > 0 {
+		seconds := int(math.Ceil(appErr.RetryAfter.Seconds()))
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		var body []byte
+		body, err = json.Marshal(problem)
+		if err == nil {
+			err = c.Blob(problem.Status, MIMEApplicationProblemJSON, body)
+		}
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// NewProblem converts an error into problem details
+func NewProblem(err error) *Problem {
+	if appErr, ok := apperror.As(err); ok {
+		status, ok := kindStatus[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+
+		problem := newProblem(status, appErr.Code, appErr.Message)
+		problem.Errors = appErr.Fields
+		if status >= http.StatusInternalServerError {
+			problem.Detail = ""
+		}
+		return problem
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail := http.StatusText(httpErr.Code)
+		if message, ok := httpErr.Message.(string); ok {
+			detail = message
+		}
+		if httpErr.Code >= http.StatusInternalServerError {
+			detail = ""
+		}
+		return newProblem(httpErr.Code, statusCode(httpErr.Code), detail)
+	}
+
+	return newProblem(http.StatusInternalServerError, statusCode(http.StatusInternalServerError), "")
+}
+
+func newProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// statusCode derives a stable error code from an HTTP status, e.g. "method_not_allowed"
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
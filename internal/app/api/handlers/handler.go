@@ -1,14 +1,24 @@
 package handlers
 
 import (
+	"slices"
+	"strconv"
+
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
 )
 
 // Handler contains all handlers for API endpoints
 type Handler struct {
-	AuthService     service.AuthService
-	BookService     service.BookService
-	CategoryService service.CategoryService
+	AuthService       service.AuthService
+	BookService       service.BookService
+	CategoryService   service.CategoryService
+	RoleService       service.RoleService
+	UserService       service.UserService
+	AuditService      service.AuditService
+	BookImportService service.BookImportService
 }
 
 // NewHandler creates a new handler instance
@@ -16,10 +26,48 @@ func NewHandler(
 	authService service.AuthService,
 	bookService service.BookService,
 	categoryService service.CategoryService,
+	roleService service.RoleService,
+	userService service.UserService,
+	auditService service.AuditService,
+	bookImportService service.BookImportService,
 ) *Handler {
 	return &Handler{
-		AuthService:     authService,
-		BookService:     bookService,
-		CategoryService: categoryService,
+		AuthService:       authService,
+		BookService:       bookService,
+		CategoryService:   categoryService,
+		RoleService:       roleService,
+		UserService:       userService,
+		AuditService:      auditService,
+		BookImportService: bookImportService,
+	}
+}
+
+// parseID reads a numeric path parameter identifying a resource
+func parseID(c echo.Context, param, resource string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return 0, apperror.Invalid("invalid_id", "invalid "+resource+" ID")
+	}
+	return uint(id), nil
+}
+
+// auditActor identifies the caller for the audit log
+func auditActor(c echo.Context) service.Actor {
+	userID, _ := c.Get("user_id").(uint)
+	return service.Actor{UserID: userID, IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+}
+
+// hasPermission reports whether the authenticated caller's token grants the permission
+func hasPermission(c echo.Context, permission string) bool {
+	permissions, _ := c.Get("permissions").([]string)
+	return slices.Contains(permissions, permission)
+}
+
+// bookOwnerScope returns the owner books are scoped to: the caller, or any
+// user (zero) when the caller holds the given cross-user permission
+func bookOwnerScope(c echo.Context, anyPermission string) uint {
+	if hasPermission(c, anyPermission) {
+		return 0
 	}
+	return c.Get("user_id").(uint)
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetOIDCProviders lists the OpenID Connect providers users can sign in with
+func (h *Handler) GetOIDCProviders(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string][]string{"providers": h.AuthService.OIDCProviders()})
+}
+
+// StartOIDCLogin returns the provider URL to send the user to
+func (h *Handler) StartOIDCLogin(c echo.Context) error {
+	authorization, err := h.AuthService.StartOIDCLogin(c.Param("provider"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, authorization)
+}
+
+// CompleteOIDCLogin signs a user in with the code and state the provider
+// redirected back with
+func (h *Handler) CompleteOIDCLogin(c echo.Context) error {
+	req := new(service.OIDCCallbackRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	req.Actor = auditActor(c)
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, challenge, err := h.AuthService.CompleteOIDCLogin(c.Param("provider"), req)
+	if err != nil {
+		return err
+	}
+	if challenge != nil {
+		return c.JSON(http.StatusOK, challenge)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetIdentities lists the external identities linked to the current user
+func (h *Handler) GetIdentities(c echo.Context) error {
+	identities, err := h.AuthService.ListIdentities(c.Get("user_id").(uint))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentity removes an external identity of the current user
+func (h *Handler) UnlinkIdentity(c echo.Context) error {
+	id, err := parseID(c, "id", "identity")
+	if err != nil {
+		return err
+	}
+
+	if err := h.AuthService.UnlinkIdentity(auditActor(c), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
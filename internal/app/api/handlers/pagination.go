@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListResponse represents the envelope returned by list endpoints
+type ListResponse struct {
+	Data  interface{} `json:"data"`
+	Meta  ListMeta    `json:"meta"`
+	Links ListLinks   `json:"links"`
+}
+
+// ListMeta represents pagination metadata of a list response
+type ListMeta struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ListLinks represents navigation links of a list response
+type ListLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// parseListParams reads the page, limit, cursor and sort query parameters
+func parseListParams(c echo.Context) (repository.ListParams, error) {
+	params := repository.ListParams{
+		Cursor: c.QueryParam("cursor"),
+		Sort:   c.QueryParam("sort"),
+	}
+
+	var err error
+	if params.Page, err = queryInt(c, "page"); err != nil {
+		return params, err
+	}
+	if params.Limit, err = queryInt(c, "limit"); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// newListResponse wraps a page into the list envelope with navigation links
+func newListResponse[T any](c echo.Context, page *repository.Page[T]) ListResponse {
+	items := page.Items
+	if items == nil {
+		items = []T{}
+	}
+
+	totalPages := 0
+	if page.Limit > 0 {
+		totalPages = int((page.Total + int64(page.Limit) - 1) / int64(page.Limit))
+	}
+
+	resp := ListResponse{
+		Data: items,
+		Meta: ListMeta{
+			Total:      page.Total,
+			Page:       page.Page,
+			Limit:      page.Limit,
+			TotalPages: totalPages,
+			NextCursor: page.NextCursor,
+			PrevCursor: page.PrevCursor,
+		},
+		Links: ListLinks{Self: c.Request().URL.RequestURI()},
+	}
+
+	if page.Page > 0 {
+		// Offset pagination
+		if page.HasNext {
+			resp.Links.Next = pageLink(c, "page", strconv.Itoa(page.Page+1))
+		}
+		if page.HasPrev {
+			resp.Links.Prev = pageLink(c, "page", strconv.Itoa(page.Page-1))
+		}
+		return resp
+	}
+
+	// Cursor pagination
+	if page.NextCursor != "" {
+		resp.Links.Next = pageLink(c, "cursor", page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		resp.Links.Prev = pageLink(c, "cursor", page.PrevCursor)
+	}
+	return resp
+}
+
+// pageLink returns the current request URL with a single query parameter replaced
+func pageLink(c echo.Context, key, value string) string {
+	u := *c.Request().URL
+	query := u.Query()
+	query.Del("page")
+	query.Del("cursor")
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidQueryParam(name)
+	}
+	return i, nil
+}
+
+// queryUint parses an optional unsigned integer query parameter
+func queryUint(c echo.Context, name string) (*uint, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	i, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, invalidQueryParam(name)
+	}
+	u := uint(i)
+	return &u, nil
+}
+
+// queryTime parses an optional RFC 3339 or YYYY-MM-DD query parameter. A
+// plain date is expanded to the end of that day when endOfDay is set.
+func queryTime(c echo.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return &t, nil
+	}
+	return nil, invalidQueryParam(name)
+}
+
+// queryUintList parses an optional comma separated list of IDs
+func queryUintList(c echo.Context, name string) ([]uint, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, invalidQueryParam(name)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// invalidQueryParam reports a query parameter that could not be parsed
+func invalidQueryParam(name string) error {
+	return apperror.Invalid("invalid_query_parameter", "invalid "+name+" parameter")
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UpdateProfile updates the profile of the current user
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	req := new(service.UpdateProfileRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	user, err := h.AuthService.UpdateProfile(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword changes the password of the current user and signs out
+// their other sessions
+func (h *Handler) ChangePassword(c echo.Context) error {
+	req := new(service.ChangePasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	sessionID, _ := c.Get("session_id").(string)
+	if err := h.AuthService.ChangePassword(auditActor(c), sessionID, req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RequestEmailChange sends a confirmation token to a new email address
+func (h *Handler) RequestEmailChange(c echo.Context) error {
+	req := new(service.ChangeEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.RequestEmailChange(auditActor(c), req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ConfirmEmailChange switches the current user to their pending email address
+func (h *Handler) ConfirmEmailChange(c echo.Context) error {
+	req := new(service.ConfirmEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	user, err := h.AuthService.ConfirmEmailChange(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// DeleteAccount deletes the account of the current user
+func (h *Handler) DeleteAccount(c echo.Context) error {
+	req := new(service.DeleteAccountRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.DeleteAccount(auditActor(c), req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateRole creates a new role
+func (h *Handler) CreateRole(c echo.Context) error {
+	req := new(service.RoleRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.Create(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, role)
+}
+
+// GetAllRoles returns every role with its permissions
+func (h *Handler) GetAllRoles(c echo.Context) error {
+	roles, err := h.RoleService.GetAll()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// GetRoleByID returns a role by ID
+func (h *Handler) GetRoleByID(c echo.Context) error {
+	id, err := parseID(c, "id", "role")
+	if err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// UpdateRole updates a role and replaces its permissions
+func (h *Handler) UpdateRole(c echo.Context) error {
+	id, err := parseID(c, "id", "role")
+	if err != nil {
+		return err
+	}
+
+	req := new(service.RoleRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	role, err := h.RoleService.Update(auditActor(c), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes a role
+func (h *Handler) DeleteRole(c echo.Context) error {
+	id, err := parseID(c, "id", "role")
+	if err != nil {
+		return err
+	}
+
+	if err := h.RoleService.Delete(auditActor(c), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetAllPermissions returns every known permission
+func (h *Handler) GetAllPermissions(c echo.Context) error {
+	permissions, err := h.RoleService.GetPermissions()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, permissions)
+}
+
+// GetUserRoles returns the roles assigned to a user
+func (h *Handler) GetUserRoles(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	roles, err := h.RoleService.GetUserRoles(id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRoles replaces the roles assigned to a user
+func (h *Handler) SetUserRoles(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	req := new(service.AssignRolesRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	user, err := h.RoleService.AssignRoles(auditActor(c), id, req.Roles)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user.Roles)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetupTwoFactor starts TOTP enrolment and returns the otpauth URI and QR code
+func (h *Handler) SetupTwoFactor(c echo.Context) error {
+	setup, err := h.AuthService.SetupTwoFactor(c.Get("user_id").(uint))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmTwoFactor enables two-factor authentication and returns recovery codes
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	req := new(service.TwoFactorCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	codes, err := h.AuthService.ConfirmTwoFactor(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	req := new(service.TwoFactorCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	codes, err := h.AuthService.RegenerateRecoveryCodes(auditActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
+
+// DisableTwoFactor turns two-factor authentication off for the current user
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	req := new(service.DisableTwoFactorRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.AuthService.DisableTwoFactor(auditActor(c), req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
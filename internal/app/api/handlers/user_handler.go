@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dot-be-go/internal/domain/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAllUsers returns a page of users, filtered by q (email or name), role and status
+func (h *Handler) GetAllUsers(c echo.Context) error {
+	params, err := parseListParams(c)
+	if err != nil {
+		return err
+	}
+
+	filter := repository.UserFilter{
+		Query:  c.QueryParam("q"),
+		Role:   c.QueryParam("role"),
+		Status: c.QueryParam("status"),
+	}
+
+	page, err := h.UserService.GetAll(filter, params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newListResponse(c, page))
+}
+
+// GetUserByID returns a user by ID, including deleted users
+func (h *Handler) GetUserByID(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserService.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser suspends a user and revokes their sessions
+func (h *Handler) SuspendUser(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserService.Suspend(auditActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UnsuspendUser lifts the suspension of a user
+func (h *Handler) UnsuspendUser(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserService.Unsuspend(auditActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser soft deletes a user
+func (h *Handler) DeleteUser(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	if err := h.UserService.Delete(auditActor(c), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreUser restores a soft deleted user
+func (h *Handler) RestoreUser(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserService.Restore(auditActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UnlockUser clears the failed logins of a user
+func (h *Handler) UnlockUser(c echo.Context) error {
+	id, err := parseID(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.UserService.Unlock(auditActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
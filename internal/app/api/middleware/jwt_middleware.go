@@ -1,54 +1,112 @@
 package middleware
 
 import (
-	"net/http"
+	"slices"
 	"strings"
 
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/service"
 	"dot-be-go/pkg/jwt"
 
 	"github.com/labstack/echo/v4"
 )
 
-// JWTMiddleware creates a JWT middleware
-func JWTMiddleware(secretKey string) echo.MiddlewareFunc {
+// JWTMiddleware creates a JWT middleware. Tokens whose session has been
+// revoked through logout, refresh token reuse, suspension or deletion of the
+// user are rejected. Personal API keys are accepted as well, either as
+// "Authorization: ApiKey <key>" or in the X-API-Key header.
+func JWTMiddleware(keys *jwt.KeyRing, authService service.AuthService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if apiKey := apiKeyFrom(c); apiKey != "" {
+				principal, err := authService.AuthenticateAPIKey(apiKey)
+				if err != nil {
+					return err
+				}
+
+				c.Set("user_id", principal.User.ID)
+				c.Set("email", principal.User.Email)
+				c.Set("roles", principal.User.RoleNames())
+				c.Set("permissions", principal.Permissions)
+				c.Set("api_key_id", principal.Key.ID)
+
+				return next(c)
+			}
+
 			authHeader := c.Request().Header.Get("Authorization")
 
 			if authHeader == "" {
-				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
+				return apperror.Unauthorized("missing_token", "missing authorization header")
 			}
 
 			// Bearer token format
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token format")
+				return apperror.Unauthorized("invalid_token", "invalid token format")
 			}
 
-			claims, err := jwt.ValidateToken(parts[1], secretKey)
+			claims, err := jwt.ValidateToken(parts[1], keys)
 			if err != nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired token")
+				return apperror.Unauthorized("invalid_token", "invalid or expired token")
+			}
+
+			if err := authService.ValidateSession(claims.SessionID, claims.UserID); err != nil {
+				return err
 			}
 
 			// Set claims context
 			c.Set("user_id", claims.UserID)
 			c.Set("email", claims.Email)
-			c.Set("role", claims.Role)
+			c.Set("roles", claims.Roles)
+			c.Set("permissions", claims.Permissions)
+			c.Set("session_id", claims.SessionID)
+			c.Set("claims", claims)
 
 			return next(c)
 		}
 	}
 }
 
-// AdminMiddleware creates an admin middleware
-func AdminMiddleware() echo.MiddlewareFunc {
+// RequirePermission creates a middleware that rejects callers whose token
+// lacks any of the given permissions. It must run after JWTMiddleware.
+func RequirePermission(permissions ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			role, ok := c.Get("role").(string)
-			if !ok || role != "admin" {
-				return echo.NewHTTPError(http.StatusForbidden, "admin privileges required")
+			granted, ok := c.Get("permissions").([]string)
+			if !ok {
+				return apperror.Unauthorized("missing_token", "missing authorization header")
+			}
+			for _, permission := range permissions {
+				if !slices.Contains(granted, permission) {
+					return apperror.Forbidden("permission_denied", "missing permission "+permission)
+				}
 			}
 			return next(c)
 		}
 	}
 }
+
+// RequireSession creates a middleware that rejects requests authenticated
+// with an API key, for account security operations that need a login. It
+// must run after JWTMiddleware.
+func RequireSession() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Get("api_key_id") != nil {
+				return apperror.Forbidden("session_required", "this endpoint cannot be used with an API key")
+			}
+			return next(c)
+		}
+	}
+}
+
+// apiKeyFrom returns the API key of a request, if any
+func apiKeyFrom(c echo.Context) string {
+	if key := c.Request().Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if key, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "ApiKey "); ok {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
@@ -3,14 +3,22 @@ package routes
 import (
 	"dot-be-go/internal/app/api/handlers"
 	customMiddleware "dot-be-go/internal/app/api/middleware"
+	"dot-be-go/internal/app/api/validation"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/jwt"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 // SetupRoutes sets up API routes
-func SetupRoutes(e *echo.Echo, handler *handlers.Handler, jwtSecret string) {
+func SetupRoutes(e *echo.Echo, handler *handlers.Handler, keys *jwt.KeyRing) {
+	// Request validation and problem+json error responses
+	e.Validator = validation.New()
+	e.HTTPErrorHandler = handlers.ErrorHandler
+
 	// Middleware
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
@@ -20,9 +28,22 @@ func SetupRoutes(e *echo.Echo, handler *handlers.Handler, jwtSecret string) {
 		return c.String(200, "OK")
 	})
 
+	// Public keys for services verifying our access tokens
+	e.GET("/.well-known/jwks.json", handler.JWKS)
+
 	// Public routes
 	e.POST("/api/auth/register", handler.Register)
 	e.POST("/api/auth/login", handler.Login)
+	e.POST("/api/auth/login/mfa", handler.CompleteMFALogin)
+	e.POST("/api/auth/refresh", handler.RefreshToken)
+	e.POST("/api/auth/logout", handler.Logout)
+	e.POST("/api/auth/forgot-password", handler.ForgotPassword)
+	e.POST("/api/auth/reset-password", handler.ResetPassword)
+	e.POST("/api/auth/verify-email", handler.VerifyEmail)
+	e.POST("/api/auth/resend-verification", handler.ResendVerification)
+	e.GET("/api/auth/oidc/providers", handler.GetOIDCProviders)
+	e.GET("/api/auth/oidc/:provider/authorize", handler.StartOIDCLogin)
+	e.POST("/api/auth/oidc/:provider/callback", handler.CompleteOIDCLogin)
 
 	// Public category routes
 	e.GET("/api/categories", handler.GetAllCategories)
@@ -31,24 +52,79 @@ func SetupRoutes(e *echo.Echo, handler *handlers.Handler, jwtSecret string) {
 
 	// Protected routes
 	protected := e.Group("/api")
-	protected.Use(customMiddleware.JWTMiddleware(jwtSecret))
+	protected.Use(customMiddleware.JWTMiddleware(keys, handler.AuthService))
 
-	// User routes
+	// User routes. Account security operations need a login, not an API key.
+	session := customMiddleware.RequireSession()
 	protected.GET("/profile", handler.GetProfile)
+	protected.PATCH("/profile", handler.UpdateProfile)
+	protected.DELETE("/profile", handler.DeleteAccount, session)
+	protected.POST("/profile/password", handler.ChangePassword, session)
+	protected.POST("/profile/email", handler.RequestEmailChange, session)
+	protected.POST("/profile/email/confirm", handler.ConfirmEmailChange, session)
+	protected.POST("/profile/2fa/setup", handler.SetupTwoFactor, session)
+	protected.POST("/profile/2fa/confirm", handler.ConfirmTwoFactor, session)
+	protected.POST("/profile/2fa/recovery-codes", handler.RegenerateRecoveryCodes, session)
+	protected.DELETE("/profile/2fa", handler.DisableTwoFactor, session)
+	protected.GET("/profile/api-keys", handler.GetAPIKeys, session)
+	protected.POST("/profile/api-keys", handler.CreateAPIKey, session)
+	protected.DELETE("/profile/api-keys/:id", handler.RevokeAPIKey, session)
+	protected.GET("/profile/identities", handler.GetIdentities)
+	protected.DELETE("/profile/identities/:id", handler.UnlinkIdentity, session)
 
 	// Book routes
-	protected.POST("/books", handler.CreateBook)
-	protected.GET("/books", handler.GetAllBooks)
-	protected.GET("/books/:id", handler.GetBookByID)
-	protected.PUT("/books/:id", handler.UpdateBook)
-	protected.DELETE("/books/:id", handler.DeleteBook)
+	bookRead := customMiddleware.RequirePermission(entity.PermissionBookRead)
+	bookWrite := customMiddleware.RequirePermission(entity.PermissionBookWrite)
+	protected.POST("/books", handler.CreateBook, bookWrite)
+	protected.POST("/books/lookup", handler.LookupBook, bookWrite)
+	protected.GET("/books", handler.GetAllBooks, bookRead)
+	protected.GET("/books/search", handler.SearchBooks, bookRead)
+	protected.GET("/books/export", handler.ExportBooks, bookRead)
+	protected.POST("/books/import", handler.ImportBooks, bookWrite)
+	protected.GET("/books/imports/:id", handler.GetBookImport, bookWrite)
+	protected.GET("/books/imports/:id/errors", handler.GetBookImportErrors, bookWrite)
+	protected.GET("/books/:id", handler.GetBookByID, bookRead)
+	protected.PUT("/books/:id", handler.UpdateBook, bookWrite)
+	protected.DELETE("/books/:id", handler.DeleteBook, bookWrite)
+	protected.PUT("/books/:id/cover", handler.SetBookCover, bookWrite)
+	protected.DELETE("/books/:id/cover", handler.DeleteBookCover, bookWrite)
 
 	// Admin routes
 	admin := protected.Group("/admin")
-	admin.Use(customMiddleware.AdminMiddleware())
 
 	// Admin category management
-	admin.POST("/categories", handler.CreateCategory)
-	admin.PUT("/categories/:id", handler.UpdateCategory)
-	admin.DELETE("/categories/:id", handler.DeleteCategory)
+	categoryWrite := customMiddleware.RequirePermission(entity.PermissionCategoryWrite)
+	admin.POST("/categories", handler.CreateCategory, categoryWrite)
+	admin.PUT("/categories/:id", handler.UpdateCategory, categoryWrite)
+	admin.DELETE("/categories/:id", handler.DeleteCategory, categoryWrite)
+
+	// Admin catalog export and MARC record import
+	admin.GET("/books/export", handler.ExportAllBooks, customMiddleware.RequirePermission(entity.PermissionBookReadAny))
+	admin.POST("/books/import/marc", handler.ImportMARCBooks, customMiddleware.RequirePermission(entity.PermissionBookWriteAny))
+
+	// Admin role and permission management
+	roleManage := customMiddleware.RequirePermission(entity.PermissionRoleManage)
+	admin.GET("/permissions", handler.GetAllPermissions, roleManage)
+	admin.GET("/roles", handler.GetAllRoles, roleManage)
+	admin.POST("/roles", handler.CreateRole, roleManage)
+	admin.GET("/roles/:id", handler.GetRoleByID, roleManage)
+	admin.PUT("/roles/:id", handler.UpdateRole, roleManage)
+	admin.DELETE("/roles/:id", handler.DeleteRole, roleManage)
+
+	// Admin user management
+	userManage := customMiddleware.RequirePermission(entity.PermissionUserManage)
+	admin.GET("/users", handler.GetAllUsers, userManage)
+	admin.GET("/users/:id", handler.GetUserByID, userManage)
+	admin.DELETE("/users/:id", handler.DeleteUser, userManage)
+	admin.POST("/users/:id/restore", handler.RestoreUser, userManage)
+	admin.POST("/users/:id/suspend", handler.SuspendUser, userManage)
+	admin.POST("/users/:id/unsuspend", handler.UnsuspendUser, userManage)
+	admin.POST("/users/:id/unlock", handler.UnlockUser, userManage)
+	admin.GET("/users/:id/roles", handler.GetUserRoles, userManage)
+	admin.PUT("/users/:id/roles", handler.SetUserRoles, userManage)
+
+	// Admin audit log
+	auditRead := customMiddleware.RequirePermission(entity.PermissionAuditRead)
+	admin.GET("/audit-logs", handler.GetAuditLogs, auditRead)
+	admin.GET("/audit-logs/export", handler.ExportAuditLogs, auditRead)
 }
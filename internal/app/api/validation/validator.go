@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CustomValidator implements echo.Validator using the validate struct tags
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+// New creates a validator that reports fields by their JSON names
+func New() *CustomValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	return &CustomValidator{validator: v}
+}
+
+// Validate validates a bound request and returns a validation error listing every failing field
+func (cv *CustomValidator) Validate(i interface{}) error {
+	err := cv.validator.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]apperror.FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		field := fieldPath(fe)
+		fields = append(fields, apperror.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: message(field, fe),
+		})
+	}
+
+	return apperror.Validation(fields...)
+}
+
+// fieldPath strips the struct name from the namespace, e.g. "category_ids[0]"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// message returns a human readable message for a failed rule
+func message(field string, fe validator.FieldError) string {
+	kind := fe.Kind()
+
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "email":
+		return field + " must be a valid email address"
+	case "min", "gte":
+		return field + " must be " + bound("at least", fe.Param(), kind)
+	case "max", "lte":
+		return field + " must be " + bound("at most", fe.Param(), kind)
+	case "len":
+		return field + " must be " + bound("exactly", fe.Param(), kind)
+	case "oneof":
+		return field + " must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+
+	return fmt.Sprintf("%s failed the %q rule", field, fe.Tag())
+}
+
+// bound phrases a size constraint depending on the kind of value checked
+func bound(qualifier, param string, kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return qualifier + " " + param + " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return qualifier + " " + param + " items"
+	default:
+		return qualifier + " " + param
+	}
+}
@@ -0,0 +1,127 @@
+// Package apperror defines the typed errors returned by the service and
+// repository layers. Each error carries a stable machine readable code that
+// the API renders in its problem responses.
+package apperror
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Kind classifies an error independently of the transport
+type Kind string
+
+const (
+	KindInvalid      Kind = "invalid"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindRateLimited  Kind = "rate_limited"
+	KindUnavailable  Kind = "unavailable"
+	KindInternal     Kind = "internal"
+)
+
+// FieldError describes a single invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a kind, a stable code and an optional cause
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+	// RetryAfter tells rate limited callers how long to wait
+	RetryAfter time.Duration
+}
+
+// Error returns the human readable message followed by any field messages
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return e.Message + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap sets the underlying cause of the error and returns it
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// Invalid creates an error for a malformed request
+func Invalid(code, message string) *Error {
+	return &Error{Kind: KindInvalid, Code: code, Message: message}
+}
+
+// Validation creates an error listing invalid fields
+func Validation(fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: "validation failed", Fields: fields}
+}
+
+// ValidationField creates a validation error for a single field
+func ValidationField(field, rule, message string) *Error {
+	return Validation(FieldError{Field: field, Rule: rule, Message: message})
+}
+
+// Unauthorized creates an error for missing or invalid credentials
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+// Forbidden creates an error for an authenticated caller lacking permission
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// Conflict creates an error for a request that clashes with existing state
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// RateLimited creates an error for a caller that must wait before retrying
+func RateLimited(code, message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+// Unavailable creates an error for an external service that did not answer
+func Unavailable(code, message string) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message}
+}
+
+// As returns the *Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// IsKind reports whether err is a domain error of the given kind
+func IsKind(err error, kind Kind) bool {
+	appErr, ok := As(err)
+	return ok && appErr.Kind == kind
+}
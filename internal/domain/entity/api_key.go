@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+)
+
+// APIKey represents a personal API key of a user. Only the hash of the key
+// is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	UserID     uint         `json:"user_id" gorm:"not null;index"`
+	Name       string       `json:"name" gorm:"size:100;not null"`
+	Prefix     string       `json:"prefix" gorm:"size:16;not null"`
+	KeyHash    string       `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes     []Permission `json:"scopes" gorm:"many2many:api_key_permissions;"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// TableName specifies the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive reports whether the key is neither revoked nor expired
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && time.Now().Before(k.ExpiresAt)
+}
+
+// ScopeNames returns the names of the permissions the key is limited to
+func (k *APIKey) ScopeNames() []string {
+	names := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		names[i] = scope.Name
+	}
+	return names
+}
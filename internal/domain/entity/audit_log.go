@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditLog is an entry of the append-only audit log. ActorID is nil for
+// anonymous requests such as failed logins and for the CLI.
+type AuditLog struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	ActorID    *uint        `json:"actor_id"`
+	IP         string       `json:"ip" gorm:"size:45"`
+	UserAgent  string       `json:"user_agent" gorm:"size:255"`
+	Action     string       `json:"action" gorm:"size:64;not null"`
+	TargetType string       `json:"target_type,omitempty" gorm:"size:32"`
+	TargetID   *uint        `json:"target_id,omitempty"`
+	Changes    AuditChanges `json:"changes,omitempty" gorm:"type:text"`
+	Details    AuditDetails `json:"details,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// TableName specifies the table name for AuditLog
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditChange is the value of a field before and after an action. Before
+// is nil for created records, After for deleted ones.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps the changed fields of a record to their values. It is
+// stored as JSON.
+type AuditChanges map[string]AuditChange
+
+// Value implements driver.Valuer
+func (c AuditChanges) Value() (driver.Value, error) {
+	return jsonValue(c, len(c))
+}
+
+// Scan implements sql.Scanner
+func (c *AuditChanges) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+// AuditDetails holds additional context of an action, such as the email of
+// a failed login. It is stored as JSON.
+type AuditDetails map[string]string
+
+// Value implements driver.Valuer
+func (d AuditDetails) Value() (driver.Value, error) {
+	return jsonValue(d, len(d))
+}
+
+// Scan implements sql.Scanner
+func (d *AuditDetails) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
+
+// jsonValue encodes a map column, storing NULL for empty maps
+func jsonValue(v interface{}, length int) (driver.Value, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// scanJSON decodes a JSON column. NULL leaves the destination empty.
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON column", value)
+	}
+}
@@ -1,8 +1,11 @@
 package entity
 
 import (
+	"database/sql/driver"
 	"time"
 
+	"dot-be-go/pkg/isbn"
+
 	"gorm.io/gorm"
 )
 
@@ -12,8 +15,11 @@ type Book struct {
 	Title       string         `json:"title" gorm:"size:255;not null"`
 	Author      string         `json:"author" gorm:"size:100;not null"`
 	ISBN        string         `json:"isbn" gorm:"size:20;uniqueIndex"`
+	ISBNDisplay string         `json:"isbn_display" gorm:"-"`
 	PublishYear int            `json:"publish_year"`
 	Description string         `json:"description" gorm:"type:text"`
+	Cover       BookCover      `json:"cover,omitempty" gorm:"type:text"`
+	CoverKeys   BookCover      `json:"-" gorm:"type:text"`
 	Categories  []Category     `json:"categories,omitempty" gorm:"many2many:book_categories;"`
 	UserID      uint           `json:"user_id" gorm:"not null"`
 	User        User           `json:"-" gorm:"foreignKey:UserID"`
@@ -22,7 +28,33 @@ type Book struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// BookCover maps the sizes of a cover image, "original" and the thumbnail
+// sizes, to their public URLs or storage keys. It is stored as JSON.
+type BookCover map[string]string
+
+// Value implements driver.Valuer
+func (c BookCover) Value() (driver.Value, error) {
+	return jsonValue(c, len(c))
+}
+
+// Scan implements sql.Scanner
+func (c *BookCover) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
 // TableName specifies the table name for Book
 func (Book) TableName() string {
 	return "books"
 }
+
+// AfterFind fills the hyphenated ISBN after loading a book
+func (b *Book) AfterFind(tx *gorm.DB) error {
+	b.ISBNDisplay = isbn.Hyphenate(b.ISBN)
+	return nil
+}
+
+// AfterSave fills the hyphenated ISBN after creating or updating a book
+func (b *Book) AfterSave(tx *gorm.DB) error {
+	b.ISBNDisplay = isbn.Hyphenate(b.ISBN)
+	return nil
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"time"
+)
+
+// Book import statuses
+const (
+	BookImportPending   = "pending"
+	BookImportRunning   = "running"
+	BookImportCompleted = "completed"
+	BookImportFailed    = "failed"
+)
+
+// Book import modes. An atomic import writes every row or none of them; a
+// best effort import writes the valid rows and reports the others.
+const (
+	BookImportBestEffort = "best_effort"
+	BookImportAtomic     = "atomic"
+)
+
+// BookImport is a bulk import of books and its progress. Created, Updated
+// and CategoriesCreated count what was written, or what would have been for
+// a dry run.
+type BookImport struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Format    string `json:"format" gorm:"size:16;not null"`
+	Mode      string `json:"mode" gorm:"size:16;not null"`
+	DryRun    bool   `json:"dry_run" gorm:"not null;default:false"`
+	Upsert    bool   `json:"upsert" gorm:"not null;default:false"`
+	Status    string `json:"status" gorm:"size:16;not null"`
+	TotalRows int    `json:"total_rows" gorm:"not null;default:0"`
+	Processed int    `json:"processed_rows" gorm:"column:processed_rows;not null;default:0"`
+	Created   int    `json:"created" gorm:"column:created_count;not null;default:0"`
+	Updated   int    `json:"updated" gorm:"column:updated_count;not null;default:0"`
+	Failed    int    `json:"failed" gorm:"column:failed_count;not null;default:0"`
+	// CategoriesCreated counts the categories created for subject headings
+	CategoriesCreated int        `json:"categories_created" gorm:"not null;default:0"`
+	Error             string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt         *time.Time `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for BookImport
+func (BookImport) TableName() string {
+	return "book_imports"
+}
+
+// IsFinished reports whether the import has stopped, successfully or not
+func (i *BookImport) IsFinished() bool {
+	return i.Status == BookImportCompleted || i.Status == BookImportFailed
+}
+
+// BookImportError describes a row of an import that could not be imported.
+// Line is the line of the row in the uploaded file.
+type BookImportError struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	ImportID uint   `json:"-" gorm:"not null;index"`
+	Line     int    `json:"line" gorm:"not null"`
+	ISBN     string `json:"isbn" gorm:"size:32"`
+	Field    string `json:"field" gorm:"size:64"`
+	Rule     string `json:"rule" gorm:"size:32"`
+	Message  string `json:"message" gorm:"type:text;not null"`
+}
+
+// TableName specifies the table name for BookImportError
+func (BookImportError) TableName() string {
+	return "book_import_errors"
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+)
+
+// RefreshToken represents a persisted refresh token. Tokens issued from the
+// same login share a FamilyID, which also identifies the session.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	FamilyID  string     `json:"family_id" gorm:"size:64;not null;index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for RefreshToken
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	// PermissionBookRead allows reading the caller's own books
+	PermissionBookRead = "book:read"
+	// PermissionBookWrite allows creating, updating and deleting the caller's own books
+	PermissionBookWrite = "book:write"
+	// PermissionBookReadAny allows reading books of any user
+	PermissionBookReadAny = "book:read:any"
+	// PermissionBookWriteAny allows updating and deleting books of any user
+	PermissionBookWriteAny = "book:write:any"
+	// PermissionCategoryWrite allows managing categories
+	PermissionCategoryWrite = "category:write"
+	// PermissionUserManage allows managing users and their role assignments
+	PermissionUserManage = "user:manage"
+	// PermissionRoleManage allows managing roles and their permissions
+	PermissionRoleManage = "role:manage"
+	// PermissionAuditRead allows reading and exporting the audit log
+	PermissionAuditRead = "audit:read"
+)
+
+// Permission represents a named capability that can be granted to roles
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Permission
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role represents a named set of permissions assigned to users
+type Role struct {
+	ID               uint         `json:"id" gorm:"primaryKey"`
+	Name             string       `json:"name" gorm:"size:50;not null;uniqueIndex"`
+	Description      string       `json:"description" gorm:"size:255"`
+	Permissions      []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+	RequireTwoFactor bool         `json:"require_two_factor" gorm:"not null;default:false"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name for Role
+func (Role) TableName() string {
+	return "roles"
+}
+
+// IsSystem reports whether the role is built in and cannot be renamed or deleted
+func (r *Role) IsSystem() bool {
+	return r.Name == RoleAdmin || r.Name == RoleUser
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+)
+
+// ThrottleAttempt records repeated attempts for a throttled key, such as
+// failed logins of an account or from an IP address
+type ThrottleAttempt struct {
+	Key           string    `gorm:"column:throttle_key;primaryKey;size:191"`
+	Attempts      int       `gorm:"not null"`
+	LastAttemptAt time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null;index"`
+}
+
+// TableName specifies the table name for ThrottleAttempt
+func (ThrottleAttempt) TableName() string {
+	return "throttle_attempts"
+}
@@ -1,24 +1,101 @@
 package entity
 
 import (
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// RoleUser is the default role of registered users
+	RoleUser = "user"
+	// RoleAdmin is the role granted every permission
+	RoleAdmin = "admin"
+)
+
 // User represents the user model
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"size:100;not null"`
-	Email     string         `json:"email" gorm:"size:100;not null;uniqueIndex"`
-	Password  string         `json:"-" gorm:"size:100;not null"`
-	Role      string         `json:"role" gorm:"size:20;default:'user'"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	Name                  string         `json:"name" gorm:"size:100;not null"`
+	Email                 string         `json:"email" gorm:"size:100;not null;uniqueIndex"`
+	Password              string         `json:"-" gorm:"size:100;not null"`
+	Roles                 []Role         `json:"roles" gorm:"many2many:user_roles;"`
+	SuspendedAt           *time.Time     `json:"suspended_at"`
+	EmailVerifiedAt       *time.Time     `json:"email_verified_at"`
+	PendingEmail          string         `json:"pending_email,omitempty" gorm:"size:100"`
+	PendingEmailTokenHash string         `json:"-" gorm:"size:64"`
+	PendingEmailExpiresAt *time.Time     `json:"-"`
+	TOTPSecret            string         `json:"-" gorm:"column:totp_secret;size:64"`
+	TOTPEnabledAt         *time.Time     `json:"two_factor_enabled_at" gorm:"column:totp_enabled_at"`
+	TOTPLastCounter       int64          `json:"-" gorm:"column:totp_last_counter;not null;default:0"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // TableName specifies the table name for User
 func (User) TableName() string {
 	return "users"
 }
+
+// IsSuspended reports whether the account has been suspended by an admin
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// IsEmailVerified reports whether the user has confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HasTwoFactor reports whether the user has confirmed TOTP enrolment
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RequiresTwoFactor reports whether one of the user's roles requires 2FA
+func (u *User) RequiresTwoFactor() bool {
+	for _, role := range u.Roles {
+		if role.RequireTwoFactor {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleNames returns the names of the user's roles
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasRole reports whether the user has the named role
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionNames returns the distinct permissions granted by the user's roles
+func (u *User) PermissionNames() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				names = append(names, permission.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect
+// provider, identified by the provider's subject
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject     string     `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for UserIdentity
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCAuthRequest is a pending OpenID Connect login. It keeps the PKCE
+// verifier and nonce server side until the provider redirects back with the
+// state whose hash is stored.
+type OIDCAuthRequest struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"size:64;not null;uniqueIndex"`
+	Provider     string    `gorm:"size:50;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// TableName specifies the table name for OIDCAuthRequest
+func (OIDCAuthRequest) TableName() string {
+	return "oidc_auth_requests"
+}
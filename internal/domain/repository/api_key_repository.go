@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository interface for API key operations
+type APIKeyRepository interface {
+	Create(key *entity.APIKey) error
+	FindByHash(keyHash string) (*entity.APIKey, error)
+	FindByUser(userID uint) ([]entity.APIKey, error)
+	Revoke(id uint, userID uint) error
+	Touch(id uint, usedAt time.Time) error
+}
+
+// apiKeyRepository implements APIKeyRepository
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+// Create creates a new API key with its scopes
+func (r *apiKeyRepository) Create(key *entity.APIKey) error {
+	return translateError(r.db.Create(key).Error)
+}
+
+// FindByHash finds an API key and its scopes by the hash of the key
+func (r *apiKeyRepository) FindByHash(keyHash string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.Preload("Scopes").Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errAPIKeyNotFound()
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindByUser returns every API key of a user, newest first
+func (r *apiKeyRepository) FindByUser(userID uint) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	err := r.db.Preload("Scopes").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revokes an API key of a user. Revoking a key twice is a no-op.
+func (r *apiKeyRepository) Revoke(id uint, userID uint) error {
+	var key entity.APIKey
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errAPIKeyNotFound()
+		}
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	return r.db.Model(&key).Update("revoked_at", time.Now()).Error
+}
+
+// Touch records the last time an API key was used
+func (r *apiKeyRepository) Touch(id uint, usedAt time.Time) error {
+	return r.db.Model(&entity.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+func errAPIKeyNotFound() error {
+	return apperror.NotFound("api_key_not_found", "API key not found")
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter represents optional filters for audit log listings
+type AuditLogFilter struct {
+	ActorID *uint
+	// Action matches an action exactly, or every action with a prefix when
+	// it ends in ".*", e.g. "auth.*"
+	Action     string
+	TargetType string
+	TargetID   *uint
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditLogRepository interface for the audit log. Entries can only be
+// appended.
+type AuditLogRepository interface {
+	Create(entry *entity.AuditLog) error
+	FindAll(filter AuditLogFilter, params ListParams) (*Page[entity.AuditLog], error)
+	FindInBatches(filter AuditLogFilter, batchSize int, fn func(entries []entity.AuditLog) error) error
+}
+
+// auditLogSortFields lists the fields audit log listings can be sorted by
+var auditLogSortFields = map[string]sortField[entity.AuditLog]{
+	"id":         {column: "audit_logs.id", kind: kindInt, value: func(e *entity.AuditLog) any { return e.ID }},
+	"created_at": {column: "audit_logs.created_at", kind: kindTime, value: func(e *entity.AuditLog) any { return e.CreatedAt }},
+}
+
+// auditLogRepository implements AuditLogRepository
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db}
+}
+
+// Create appends an entry
+func (r *auditLogRepository) Create(entry *entity.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// FindAll returns a page of entries, newest first by default
+func (r *auditLogRepository) FindAll(filter AuditLogFilter, params ListParams) (*Page[entity.AuditLog], error) {
+	return paginate(r.filtered(filter), params, auditLogSortFields, "-id")
+}
+
+// FindInBatches calls fn with consecutive batches of entries, oldest first
+func (r *auditLogRepository) FindInBatches(filter AuditLogFilter, batchSize int, fn func(entries []entity.AuditLog) error) error {
+	var entries []entity.AuditLog
+	return r.filtered(filter).FindInBatches(&entries, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(entries)
+	}).Error
+}
+
+func (r *auditLogRepository) filtered(filter AuditLogFilter) *gorm.DB {
+	query := r.db.Model(&entity.AuditLog{})
+
+	if filter.ActorID != nil {
+		query = query.Where("audit_logs.actor_id = ?", *filter.ActorID)
+	}
+	if prefix, ok := strings.CutSuffix(filter.Action, ".*"); ok {
+		query = query.Where("audit_logs.action LIKE ? ESCAPE '!'", prefixPattern(prefix+"."))
+	} else if filter.Action != "" {
+		query = query.Where("audit_logs.action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("audit_logs.target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("audit_logs.target_id = ?", *filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("audit_logs.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("audit_logs.created_at <= ?", *filter.To)
+	}
+	return query
+}
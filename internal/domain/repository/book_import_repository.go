@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// BookImportRepository interface for book import jobs and their row errors
+type BookImportRepository interface {
+	Create(job *entity.BookImport) error
+	Update(job *entity.BookImport) error
+	FindByID(id uint, userID uint) (*entity.BookImport, error)
+	AddErrors(rowErrors []entity.BookImportError) error
+	FindErrorsInBatches(importID uint, batchSize int, fn func(rowErrors []entity.BookImportError) error) error
+}
+
+// bookImportRepository implements BookImportRepository
+type bookImportRepository struct {
+	db *gorm.DB
+}
+
+// NewBookImportRepository creates a new book import repository
+func NewBookImportRepository(db *gorm.DB) BookImportRepository {
+	return &bookImportRepository{db}
+}
+
+// Create creates a new import job
+func (r *bookImportRepository) Create(job *entity.BookImport) error {
+	return r.db.Create(job).Error
+}
+
+// Update saves the status and progress of an import job
+func (r *bookImportRepository) Update(job *entity.BookImport) error {
+	return r.db.Save(job).Error
+}
+
+// FindByID finds an import job by ID for the user who started it. A zero
+// userID matches any user.
+func (r *bookImportRepository) FindByID(id uint, userID uint) (*entity.BookImport, error) {
+	var job entity.BookImport
+	err := r.db.Scopes(ownedBy(userID)).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("book_import_not_found", "book import not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// AddErrors stores row errors of an import
+func (r *bookImportRepository) AddErrors(rowErrors []entity.BookImportError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+	return r.db.Create(&rowErrors).Error
+}
+
+// FindErrorsInBatches calls fn with consecutive batches of the row errors of
+// an import in file order
+func (r *bookImportRepository) FindErrorsInBatches(importID uint, batchSize int, fn func(rowErrors []entity.BookImportError) error) error {
+	var rowErrors []entity.BookImportError
+	return r.db.Where("import_id = ?", importID).FindInBatches(&rowErrors, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(rowErrors)
+	}).Error
+}
@@ -2,20 +2,52 @@ package repository
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/domain/entity"
 
 	"gorm.io/gorm"
 )
 
+// BookFilter represents optional filters for book listings
+type BookFilter struct {
+	Author          string
+	PublishYearFrom int
+	PublishYearTo   int
+	CategoryIDs     []uint
+	ISBNPrefix      string
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+}
+
 // BookRepository interface for book operations
 type BookRepository interface {
 	Create(book *entity.Book) error
-	FindAll(userID uint) ([]entity.Book, error)
+	FindAll(userID uint, filter BookFilter, params ListParams) (*Page[entity.Book], error)
 	FindByID(id uint, userID uint) (*entity.Book, error)
+	FindByISBN(isbn string) (*entity.Book, error)
 	Update(book *entity.Book) error
 	Delete(id uint, userID uint) error
-	FindByCategory(categoryID uint) ([]entity.Book, error)
+	DeleteByUser(userID uint) error
+	FindByCategory(categoryID uint, filter BookFilter, params ListParams) (*Page[entity.Book], error)
+	FindBatch(afterID uint, limit int) ([]entity.Book, error)
+	FindInBatches(userID uint, batchSize int, fn func(books []entity.Book) error) error
+	UpdateISBN(id uint, isbn string) error
+	UpdateCover(id uint, cover, keys entity.BookCover) error
+	Transaction(fn func(repo BookRepository) error) error
+}
+
+// bookSortFields lists the fields book listings can be sorted by
+var bookSortFields = map[string]sortField[entity.Book]{
+	"id":           {column: "books.id", kind: kindInt, value: func(b *entity.Book) any { return b.ID }},
+	"title":        {column: "books.title", kind: kindString, value: func(b *entity.Book) any { return b.Title }},
+	"author":       {column: "books.author", kind: kindString, value: func(b *entity.Book) any { return b.Author }},
+	"isbn":         {column: "books.isbn", kind: kindString, value: func(b *entity.Book) any { return b.ISBN }},
+	"publish_year": {column: "books.publish_year", kind: kindInt, value: func(b *entity.Book) any { return b.PublishYear }},
+	"created_at":   {column: "books.created_at", kind: kindTime, value: func(b *entity.Book) any { return b.CreatedAt }},
+	"updated_at":   {column: "books.updated_at", kind: kindTime, value: func(b *entity.Book) any { return b.UpdatedAt }},
 }
 
 // bookRepository implements BookRepository
@@ -30,27 +62,38 @@ func NewBookRepository(db *gorm.DB) BookRepository {
 
 // Create creates a new book
 func (r *bookRepository) Create(book *entity.Book) error {
-	return r.db.Create(book).Error
+	return translateError(r.db.Create(book).Error)
 }
 
-// FindAll returns all books for a user
-func (r *bookRepository) FindAll(userID uint) ([]entity.Book, error) {
-	var books []entity.Book
-	err := r.db.Where("user_id = ?", userID).
-		Preload("Categories").
-		Find(&books).Error
-	return books, err
+// FindAll returns a page of books for a user
+func (r *bookRepository) FindAll(userID uint, filter BookFilter, params ListParams) (*Page[entity.Book], error) {
+	query := r.db.Model(&entity.Book{}).Where("books.user_id = ?", userID)
+	return paginate(applyBookFilter(query, filter), params, bookSortFields, "id", preloadCategories)
 }
 
-// FindByID finds a book by ID for a specific user
+// FindByID finds a book by ID for a specific user. A zero userID matches any owner.
 func (r *bookRepository) FindByID(id uint, userID uint) (*entity.Book, error) {
 	var book entity.Book
-	err := r.db.Where("id = ? AND user_id = ?", id, userID).
+	err := r.db.Scopes(ownedBy(userID)).
+		Where("id = ?", id).
 		Preload("Categories").
 		First(&book).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("book not found")
+			return nil, apperror.NotFound("book_not_found", "book not found")
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+// FindByISBN finds a book of any owner by its canonical ISBN
+func (r *bookRepository) FindByISBN(isbn string) (*entity.Book, error) {
+	var book entity.Book
+	err := r.db.Where("isbn = ?", isbn).Preload("Categories").First(&book).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("book_not_found", "book not found")
 		}
 		return nil, err
 	}
@@ -65,28 +108,121 @@ func (r *bookRepository) Update(book *entity.Book) error {
 		return err
 	}
 
-	// Then save the book with new associations
-	return r.db.Session(&gorm.Session{FullSaveAssociations: true}).Save(book).Error
+	// Then save the book with new associations. The cover is only written by
+	// UpdateCover, so a concurrent upload is not overwritten.
+	return translateError(r.db.Session(&gorm.Session{FullSaveAssociations: true}).Omit("cover", "cover_keys").Save(book).Error)
 }
 
-// Delete deletes a book
+// Delete deletes a book of a specific user. A zero userID matches any owner.
 func (r *bookRepository) Delete(id uint, userID uint) error {
-	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Book{})
+	result := r.db.Scopes(ownedBy(userID)).Where("id = ?", id).Delete(&entity.Book{})
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("book not found or you don't have permission")
+		return apperror.NotFound("book_not_found", "book not found")
 	}
 	return nil
 }
 
-// FindByCategory finds books by category ID
-func (r *bookRepository) FindByCategory(categoryID uint) ([]entity.Book, error) {
+// DeleteByUser soft deletes every book of a user
+func (r *bookRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&entity.Book{}).Error
+}
+
+// FindByCategory finds a page of books by category ID
+func (r *bookRepository) FindByCategory(categoryID uint, filter BookFilter, params ListParams) (*Page[entity.Book], error) {
+	query := r.db.Model(&entity.Book{}).
+		Where("books.id IN (SELECT book_id FROM book_categories WHERE category_id = ?)", categoryID)
+	return paginate(applyBookFilter(query, filter), params, bookSortFields, "id", preloadCategories)
+}
+
+// FindBatch returns up to limit books with an ID greater than afterID,
+// including soft deleted ones, ordered by ID
+func (r *bookRepository) FindBatch(afterID uint, limit int) ([]entity.Book, error) {
 	var books []entity.Book
-	err := r.db.Joins("JOIN book_categories ON books.id = book_categories.book_id").
-		Where("book_categories.category_id = ?", categoryID).
-		Preload("Categories").
+	err := r.db.Unscoped().
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
 		Find(&books).Error
 	return books, err
 }
+
+// FindInBatches calls fn with consecutive batches of books and their
+// categories. A zero userID walks the books of every user.
+func (r *bookRepository) FindInBatches(userID uint, batchSize int, fn func(books []entity.Book) error) error {
+	query := r.db.Preload("Categories").Scopes(ownedBy(userID))
+
+	var books []entity.Book
+	return query.FindInBatches(&books, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(books)
+	}).Error
+}
+
+// UpdateISBN updates only the ISBN of a book, including soft deleted ones
+func (r *bookRepository) UpdateISBN(id uint, isbn string) error {
+	err := r.db.Unscoped().Model(&entity.Book{}).Where("id = ?", id).Update("isbn", isbn).Error
+	return translateError(err)
+}
+
+// UpdateCover replaces the cover URLs and storage keys of a book. Empty maps
+// remove the cover.
+func (r *bookRepository) UpdateCover(id uint, cover, keys entity.BookCover) error {
+	result := r.db.Model(&entity.Book{ID: id}).Updates(map[string]interface{}{"cover": cover, "cover_keys": keys})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("book_not_found", "book not found")
+	}
+	return nil
+}
+
+// Transaction runs fn with a repository whose writes are committed together,
+// or rolled back when fn returns an error
+func (r *bookRepository) Transaction(fn func(repo BookRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&bookRepository{tx})
+	})
+}
+
+// ownedBy restricts a query to the books of a user unless userID is zero
+func ownedBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if userID == 0 {
+			return db
+		}
+		return db.Where("user_id = ?", userID)
+	}
+}
+
+// applyBookFilter adds the conditions of a BookFilter to a query
+func applyBookFilter(query *gorm.DB, filter BookFilter) *gorm.DB {
+	if filter.Author != "" {
+		query = query.Where("LOWER(books.author) LIKE ? ESCAPE '!'", containsPattern(strings.ToLower(filter.Author)))
+	}
+	if filter.PublishYearFrom > 0 {
+		query = query.Where("books.publish_year >= ?", filter.PublishYearFrom)
+	}
+	if filter.PublishYearTo > 0 {
+		query = query.Where("books.publish_year <= ?", filter.PublishYearTo)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("books.id IN (SELECT book_id FROM book_categories WHERE category_id IN ?)", filter.CategoryIDs)
+	}
+	if filter.ISBNPrefix != "" {
+		query = query.Where("books.isbn LIKE ? ESCAPE '!'", prefixPattern(filter.ISBNPrefix))
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("books.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("books.created_at <= ?", *filter.CreatedTo)
+	}
+	return query
+}
+
+func preloadCategories(db *gorm.DB) *gorm.DB {
+	return db.Preload("Categories")
+}
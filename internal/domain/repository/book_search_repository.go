@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// postgresSearchVector is the weighted document searched on Postgres. The
+// expression index created by the books_fulltext migration must match it exactly.
+const postgresSearchVector = "(setweight(to_tsvector('simple', coalesce(title, '')), 'A') || " +
+	"setweight(to_tsvector('simple', coalesce(author, '')), 'B') || " +
+	"setweight(to_tsvector('simple', coalesce(description, '')), 'C'))"
+
+// mysqlSearchMatch is the MATCH expression backed by the FULLTEXT index on MySQL
+const mysqlSearchMatch = "MATCH(title, author, description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+// mysqlMinTokenSize is the default innodb_ft_min_token_size. Shorter words
+// are left out of the FULLTEXT index, so MATCH never finds them.
+const mysqlMinTokenSize = 3
+
+// mysqlShortWordMatch matches a short word as a whole word in any searched
+// column. The POSIX classes work on both MySQL 5.7 and 8.
+const mysqlShortWordMatch = "LOWER(title) REGEXP ? OR LOWER(author) REGEXP ? OR LOWER(description) REGEXP ?"
+
+// BookSearchHit represents a book matched by a full-text search
+type BookSearchHit struct {
+	Book  entity.Book
+	Score float64
+}
+
+// BookSearchRepository interface for full-text book search
+type BookSearchRepository interface {
+	Search(userID uint, query string, params ListParams) (*Page[BookSearchHit], error)
+}
+
+// bookSearchRepository implements BookSearchRepository for Postgres and MySQL
+type bookSearchRepository struct {
+	db     *gorm.DB
+	driver string
+}
+
+// searchRow is a single ranked row returned by the search query
+type searchRow struct {
+	ID    uint
+	Score float64
+}
+
+// NewBookSearchRepository creates a new book search repository for the given database driver
+func NewBookSearchRepository(db *gorm.DB, driver string) BookSearchRepository {
+	return &bookSearchRepository{db: db, driver: driver}
+}
+
+// Search returns a page of the user's books ranked by relevance. Only offset
+// pagination is supported because relevance scores are not stable cursors.
+func (r *bookSearchRepository) Search(userID uint, query string, params ListParams) (*Page[BookSearchHit], error) {
+	if params.Cursor != "" {
+		return nil, invalidListParams("cursor pagination is not supported for search")
+	}
+	if params.Sort != "" && params.Sort != "relevance" {
+		return nil, invalidListParams("search results can only be sorted by relevance")
+	}
+	params = params.normalized()
+
+	var match, score string
+	matchArgs := []interface{}{query}
+	if r.driver == "postgres" {
+		match = postgresSearchVector + " @@ websearch_to_tsquery('simple', ?)"
+		score = "ts_rank(" + postgresSearchVector + ", websearch_to_tsquery('simple', ?)) AS score"
+	} else {
+		match, matchArgs = mysqlSearch(query)
+		score = mysqlSearchMatch + " AS score"
+	}
+
+	base := r.db.Model(&entity.Book{}).
+		Where("books.user_id = ?", userID).
+		Where(match, matchArgs...)
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []searchRow
+	err := base.Session(&gorm.Session{}).
+		Select("books.id AS id, "+score, query).
+		Order("score DESC").
+		Order("books.id ASC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := r.loadHits(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page[BookSearchHit]{
+		Items:   hits,
+		Total:   total,
+		Page:    params.Page,
+		Limit:   params.Limit,
+		HasPrev: params.Page > 1,
+		HasNext: int64(params.Page*params.Limit) < total,
+	}, nil
+}
+
+// mysqlSearch builds the MySQL match expression with its arguments. Words
+// shorter than mysqlMinTokenSize are missing from the FULLTEXT index, so
+// they are matched as whole words with REGEXP instead, like the Postgres
+// search does; like natural language mode, a book matching any word is
+// found. Such matches do not add to the relevance score.
+func mysqlSearch(query string) (string, []interface{}) {
+	match, args := mysqlSearchMatch, []interface{}{query}
+
+	seen := map[string]bool{}
+	for _, word := range searchWords(query) {
+		if utf8.RuneCountInString(word) >= mysqlMinTokenSize || seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		pattern := mysqlWordPattern(word)
+		match += " OR " + mysqlShortWordMatch
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	return "(" + match + ")", args
+}
+
+// searchWords splits a search query into lower case words of letters and
+// digits, which need no escaping in a pattern
+func searchWords(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// mysqlWordPattern returns a regular expression matching word only between
+// non-alphanumeric characters
+func mysqlWordPattern(word string) string {
+	return "(^|[^[:alnum:]])" + word + "([^[:alnum:]]|$)"
+}
+
+// loadHits loads the books of ranked rows with their categories, keeping the ranking order
+func (r *bookSearchRepository) loadHits(rows []searchRow) ([]BookSearchHit, error) {
+	if len(rows) == 0 {
+		return []BookSearchHit{}, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	var books []entity.Book
+	if err := r.db.Where("id IN ?", ids).Preload("Categories").Find(&books).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]entity.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	hits := make([]BookSearchHit, 0, len(rows))
+	for _, row := range rows {
+		if book, ok := byID[row.ID]; ok {
+			hits = append(hits, BookSearchHit{Book: book, Score: row.Score})
+		}
+	}
+	return hits, nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQLSearch_LongWordsUseFullText(t *testing.T) {
+	match, args := mysqlSearch("clean code")
+	assert.Equal(t, "("+mysqlSearchMatch+")", match)
+	assert.Equal(t, []interface{}{"clean code"}, args)
+}
+
+func TestMySQLSearch_ShortWordsMatchWholeWords(t *testing.T) {
+	match, args := mysqlSearch("Go, go in Action")
+
+	// "go" once and "in", each against three columns
+	assert.Equal(t, 6, strings.Count(match, "REGEXP ?"))
+	assert.NotContains(t, match, "LIKE")
+	assert.Equal(t, strings.Count(match, "?"), len(args))
+	assert.Equal(t, "Go, go in Action", args[0])
+
+	in := regexp.MustCompile(mysqlWordPattern("in"))
+	assert.True(t, in.MatchString("go in action"))
+	assert.True(t, in.MatchString("in"))
+	assert.True(t, in.MatchString("all-in"))
+	assert.False(t, in.MatchString("thinking"))
+	assert.False(t, in.MatchString("main"))
+
+	goWord := regexp.MustCompile(mysqlWordPattern("go"))
+	assert.True(t, goWord.MatchString("the go programming language"))
+	assert.False(t, goWord.MatchString("google"))
+	assert.False(t, goWord.MatchString("long ago"))
+}
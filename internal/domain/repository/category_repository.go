@@ -2,21 +2,37 @@ package repository
 
 import (
 	"errors"
+	"strings"
 
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/domain/entity"
 
 	"gorm.io/gorm"
 )
 
+// CategoryFilter represents optional filters for category listings
+type CategoryFilter struct {
+	Name string
+}
+
 // CategoryRepository interface for category operations
 type CategoryRepository interface {
 	Create(category *entity.Category) error
-	FindAll() ([]entity.Category, error)
+	FindAll(filter CategoryFilter, params ListParams) (*Page[entity.Category], error)
 	FindByID(id uint) (*entity.Category, error)
+	FindByName(name string) (*entity.Category, error)
 	Update(category *entity.Category) error
 	Delete(id uint) error
 }
 
+// categorySortFields lists the fields category listings can be sorted by
+var categorySortFields = map[string]sortField[entity.Category]{
+	"id":         {column: "categories.id", kind: kindInt, value: func(c *entity.Category) any { return c.ID }},
+	"name":       {column: "categories.name", kind: kindString, value: func(c *entity.Category) any { return c.Name }},
+	"created_at": {column: "categories.created_at", kind: kindTime, value: func(c *entity.Category) any { return c.CreatedAt }},
+	"updated_at": {column: "categories.updated_at", kind: kindTime, value: func(c *entity.Category) any { return c.UpdatedAt }},
+}
+
 // categoryRepository implements CategoryRepository
 type categoryRepository struct {
 	db *gorm.DB
@@ -29,14 +45,16 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 
 // Create creates a new category
 func (r *categoryRepository) Create(category *entity.Category) error {
-	return r.db.Create(category).Error
+	return translateError(r.db.Create(category).Error)
 }
 
-// FindAll returns all categories
-func (r *categoryRepository) FindAll() ([]entity.Category, error) {
-	var categories []entity.Category
-	err := r.db.Find(&categories).Error
-	return categories, err
+// FindAll returns a page of categories
+func (r *categoryRepository) FindAll(filter CategoryFilter, params ListParams) (*Page[entity.Category], error) {
+	query := r.db.Model(&entity.Category{})
+	if filter.Name != "" {
+		query = query.Where("LOWER(categories.name) LIKE ? ESCAPE '!'", containsPattern(strings.ToLower(filter.Name)))
+	}
+	return paginate(query, params, categorySortFields, "id")
 }
 
 // FindByID finds a category by ID
@@ -45,7 +63,20 @@ func (r *categoryRepository) FindByID(id uint) (*entity.Category, error) {
 	err := r.db.First(&category, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("category not found")
+			return nil, apperror.NotFound("category_not_found", "category not found")
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindByName finds a category by its exact name
+func (r *categoryRepository) FindByName(name string) (*entity.Category, error) {
+	var category entity.Category
+	err := r.db.Where("name = ?", name).First(&category).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("category_not_found", "category not found")
 		}
 		return nil, err
 	}
@@ -54,7 +85,7 @@ func (r *categoryRepository) FindByID(id uint) (*entity.Category, error) {
 
 // Update updates a category
 func (r *categoryRepository) Update(category *entity.Category) error {
-	return r.db.Save(category).Error
+	return translateError(r.db.Save(category).Error)
 }
 
 // Delete deletes a category
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+
+	"dot-be-go/internal/domain/apperror"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolations maps unique indexes to the conflict reported when an insert or update violates them
+var uniqueViolations = map[string]func() *apperror.Error{
+	"idx_users_email": func() *apperror.Error {
+		return apperror.Conflict("email_taken", "email already registered")
+	},
+	"idx_categories_name": func() *apperror.Error {
+		return apperror.Conflict("category_name_taken", "a category with this name already exists")
+	},
+	"idx_books_isbn": func() *apperror.Error {
+		return apperror.Conflict("isbn_taken", "a book with this ISBN already exists")
+	},
+	"idx_roles_name": func() *apperror.Error {
+		return apperror.Conflict("role_name_taken", "a role with this name already exists")
+	},
+	"idx_user_identities_provider_subject": func() *apperror.Error {
+		return apperror.Conflict("identity_taken", "this external account is already linked to a user")
+	},
+}
+
+// mysqlDuplicateKey extracts the index name from a MySQL 1062 error message,
+// e.g. "Duplicate entry 'x' for key 'books.idx_books_isbn'"
+var mysqlDuplicateKey = regexp.MustCompile(`for key '(?:[^.']+\.)?([^']+)'`)
+
+// translateError turns duplicate key errors of known unique indexes into
+// conflict errors and returns any other error unchanged
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var index string
+
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		index = pgErr.ConstraintName
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == 1062:
+		if match := mysqlDuplicateKey.FindStringSubmatch(mysqlErr.Message); match != nil {
+			index = match[1]
+		}
+	default:
+		return err
+	}
+
+	if conflict, ok := uniqueViolations[index]; ok {
+		return conflict().Wrap(err)
+	}
+	return apperror.Conflict("duplicate", "resource already exists").Wrap(err)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdentityRepository interface for linked external identities and pending
+// OpenID Connect logins
+type IdentityRepository interface {
+	Create(identity *entity.UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*entity.UserIdentity, error)
+	FindByUser(userID uint) ([]entity.UserIdentity, error)
+	TouchLogin(id uint, at time.Time) error
+	Delete(id uint, userID uint) error
+	CreateAuthRequest(request *entity.OIDCAuthRequest) error
+	ConsumeAuthRequest(stateHash string) (*entity.OIDCAuthRequest, error)
+}
+
+// identityRepository implements IdentityRepository
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db}
+}
+
+// Create links an external identity to a user
+func (r *identityRepository) Create(identity *entity.UserIdentity) error {
+	return translateError(r.db.Create(identity).Error)
+}
+
+// FindByProviderSubject finds the identity a provider knows by subject
+func (r *identityRepository) FindByProviderSubject(provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errIdentityNotFound()
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByUser returns the identities linked to a user
+func (r *identityRepository) FindByUser(userID uint) ([]entity.UserIdentity, error) {
+	var identities []entity.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&identities).Error
+	return identities, err
+}
+
+// TouchLogin records the last login through an identity
+func (r *identityRepository) TouchLogin(id uint, at time.Time) error {
+	return r.db.Model(&entity.UserIdentity{}).Where("id = ?", id).Update("last_login_at", at).Error
+}
+
+// Delete unlinks an identity of a user
+func (r *identityRepository) Delete(id uint, userID uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errIdentityNotFound()
+	}
+	return nil
+}
+
+// CreateAuthRequest stores a pending login and drops expired ones
+func (r *identityRepository) CreateAuthRequest(request *entity.OIDCAuthRequest) error {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&entity.OIDCAuthRequest{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(request).Error
+}
+
+// ConsumeAuthRequest removes and returns a pending login, so each state can
+// complete a login only once
+func (r *identityRepository) ConsumeAuthRequest(stateHash string) (*entity.OIDCAuthRequest, error) {
+	var request entity.OIDCAuthRequest
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state_hash = ?", stateHash).
+			First(&request).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&request).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("oidc_auth_request_not_found", "login request not found")
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func errIdentityNotFound() error {
+	return apperror.NotFound("identity_not_found", "linked identity not found")
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "strings"
+
+// likeEscape is the escape character of LIKE patterns. A backslash would
+// need different quoting in MySQL and Postgres string literals.
+const likeEscape = "!"
+
+// likeEscaper escapes the wildcards of LIKE in user input
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// containsPattern returns a LIKE pattern matching values that contain s.
+// Use it with ESCAPE '!'.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// prefixPattern returns a LIKE pattern matching values that start with s.
+// Use it with ESCAPE '!'.
+func prefixPattern(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
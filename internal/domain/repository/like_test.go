@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsPattern_EscapesWildcards(t *testing.T) {
+	assert.Equal(t, "%tolkien%", containsPattern("tolkien"))
+	assert.Equal(t, "%!_%", containsPattern("_"))
+	assert.Equal(t, "%100!%!!%", containsPattern("100%!"))
+}
+
+func TestPrefixPattern_EscapesWildcards(t *testing.T) {
+	assert.Equal(t, "auth.%", prefixPattern("auth."))
+	assert.Equal(t, "auth!_x.%", prefixPattern("auth_x."))
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPageLimit is the page size used when none is requested
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page size a client may request
+	MaxPageLimit = 100
+)
+
+// ErrInvalidListParams is returned when paging, sorting or filter parameters are malformed
+var ErrInvalidListParams = errors.New("invalid list parameters")
+
+// invalidListParams returns a domain error wrapping ErrInvalidListParams
+func invalidListParams(format string, args ...any) error {
+	message := ErrInvalidListParams.Error() + ": " + fmt.Sprintf(format, args...)
+	return apperror.Invalid("invalid_list_params", message).Wrap(ErrInvalidListParams)
+}
+
+// ListParams represents pagination and sorting options for list queries.
+// When Cursor is set, keyset pagination is used and Page is ignored.
+type ListParams struct {
+	Page   int
+	Limit  int
+	Cursor string
+	// Sort is a comma separated list of fields, prefixed with "-" for descending order
+	Sort string
+}
+
+// Page represents one page of a list query
+type Page[T any] struct {
+	Items      []T
+	Total      int64
+	Page       int
+	Limit      int
+	HasNext    bool
+	HasPrev    bool
+	NextCursor string
+	PrevCursor string
+}
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindTime
+)
+
+// sortField describes a column clients are allowed to sort by
+type sortField[T any] struct {
+	column string
+	kind   valueKind
+	value  func(item *T) any
+}
+
+type sortKey[T any] struct {
+	field sortField[T]
+	desc  bool
+}
+
+type cursorDirection string
+
+const (
+	cursorNext cursorDirection = "next"
+	cursorPrev cursorDirection = "prev"
+)
+
+// cursor is the decoded form of an opaque pagination cursor
+type cursor struct {
+	Sort      string          `json:"s"`
+	Values    []string        `json:"v"`
+	Direction cursorDirection `json:"d"`
+}
+
+func (p ListParams) normalized() ListParams {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	return p
+}
+
+// paginate counts and fetches one page of query. Scopes are only applied to
+// the fetch, which keeps preloads out of the count query.
+func paginate[T any](
+	query *gorm.DB,
+	params ListParams,
+	fields map[string]sortField[T],
+	defaultSort string,
+	scopes ...func(*gorm.DB) *gorm.DB,
+) (*Page[T], error) {
+	params = params.normalized()
+	if params.Sort == "" {
+		params.Sort = defaultSort
+	}
+
+	keys, err := parseSort(params.Sort, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Total: total, Limit: params.Limit}
+	find := query.Session(&gorm.Session{}).Scopes(scopes...)
+
+	if params.Cursor == "" {
+		page.Page = params.Page
+
+		var items []T
+		err := orderBy(find, keys, false).
+			Offset((params.Page - 1) * params.Limit).
+			Limit(params.Limit).
+			Find(&items).Error
+		if err != nil {
+			return nil, err
+		}
+
+		page.Items = items
+		page.HasPrev = params.Page > 1
+		page.HasNext = int64(params.Page*params.Limit) < total
+		if page.HasNext && len(items) > 0 {
+			page.NextCursor = encodeCursor(params.Sort, keys, &items[len(items)-1], cursorNext)
+		}
+		return page, nil
+	}
+
+	c, err := decodeCursor(params.Cursor, params.Sort, keys)
+	if err != nil {
+		return nil, err
+	}
+	backward := c.Direction == cursorPrev
+
+	condition, args := keysetCondition(keys, c.Values, backward)
+
+	var items []T
+	err = orderBy(find.Where(condition, args...), keys, backward).
+		Limit(params.Limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(items) > params.Limit
+	if more {
+		items = items[:params.Limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+		page.HasPrev = more
+		page.HasNext = true
+	} else {
+		page.HasNext = more
+		page.HasPrev = true
+	}
+
+	page.Items = items
+	if len(items) > 0 {
+		if page.HasNext {
+			page.NextCursor = encodeCursor(params.Sort, keys, &items[len(items)-1], cursorNext)
+		}
+		if page.HasPrev {
+			page.PrevCursor = encodeCursor(params.Sort, keys, &items[0], cursorPrev)
+		}
+	}
+
+	return page, nil
+}
+
+// parseSort resolves a sort expression and appends the id column as tiebreaker
+func parseSort[T any](sort string, fields map[string]sortField[T]) ([]sortKey[T], error) {
+	var keys []sortKey[T]
+	hasID := false
+
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+		field, ok := fields[name]
+		if !ok {
+			return nil, invalidListParams("unknown sort field %q", name)
+		}
+		if name == "id" {
+			hasID = true
+		}
+		keys = append(keys, sortKey[T]{field: field, desc: desc})
+	}
+
+	if !hasID {
+		keys = append(keys, sortKey[T]{field: fields["id"]})
+	}
+
+	return keys, nil
+}
+
+func orderBy[T any](query *gorm.DB, keys []sortKey[T], reverse bool) *gorm.DB {
+	for _, key := range keys {
+		desc := key.desc != reverse
+		if desc {
+			query = query.Order(key.field.column + " DESC")
+		} else {
+			query = query.Order(key.field.column + " ASC")
+		}
+	}
+	return query
+}
+
+// keysetCondition builds "(a > ?) OR (a = ? AND b > ?) ..." for the sort keys
+func keysetCondition[T any](keys []sortKey[T], values []any, backward bool) (string, []any) {
+	var clauses []string
+	var args []any
+
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].field.column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if key.desc != backward {
+			op = "<"
+		}
+		parts = append(parts, key.field.column+" "+op+" ?")
+		args = append(args, values[i])
+
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+func encodeCursor[T any](sort string, keys []sortKey[T], item *T, direction cursorDirection) string {
+	c := cursor{Sort: sort, Direction: direction}
+	for _, key := range keys {
+		switch v := key.field.value(item).(type) {
+		case time.Time:
+			c.Values = append(c.Values, v.UTC().Format(time.RFC3339Nano))
+		default:
+			c.Values = append(c.Values, fmt.Sprint(v))
+		}
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodedCursor holds cursor values converted to their column types
+type decodedCursor struct {
+	Values    []any
+	Direction cursorDirection
+}
+
+func decodeCursor[T any](encoded, sort string, keys []sortKey[T]) (*decodedCursor, error) {
+	invalid := invalidListParams("malformed cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != sort {
+		return nil, invalidListParams("cursor does not match sort order")
+	}
+	if len(c.Values) != len(keys) || (c.Direction != cursorNext && c.Direction != cursorPrev) {
+		return nil, invalid
+	}
+
+	decoded := &decodedCursor{Direction: c.Direction}
+	for i, key := range keys {
+		switch key.field.kind {
+		case kindInt:
+			v, err := strconv.ParseInt(c.Values[i], 10, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			decoded.Values = append(decoded.Values, v)
+		case kindTime:
+			v, err := time.Parse(time.RFC3339Nano, c.Values[i])
+			if err != nil {
+				return nil, invalid
+			}
+			decoded.Values = append(decoded.Values, v)
+		default:
+			decoded.Values = append(decoded.Values, c.Values[i])
+		}
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// ErrPasswordResetTokenUsed is returned when a reset token has already been consumed
+var ErrPasswordResetTokenUsed = errors.New("password reset token already used")
+
+// PasswordResetTokenRepository interface for password reset token operations
+type PasswordResetTokenRepository interface {
+	Create(token *entity.PasswordResetToken) error
+	FindByHash(tokenHash string) (*entity.PasswordResetToken, error)
+	MarkUsed(id uint) error
+	InvalidateForUser(userID uint) error
+}
+
+// passwordResetTokenRepository implements PasswordResetTokenRepository
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db}
+}
+
+// Create creates a new password reset token
+func (r *passwordResetTokenRepository) Create(token *entity.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash finds a password reset token by its hash
+func (r *passwordResetTokenRepository) FindByHash(tokenHash string) (*entity.PasswordResetToken, error) {
+	var token entity.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("password_reset_token_not_found", "password reset token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes a reset token. It fails with ErrPasswordResetTokenUsed
+// when another request already consumed the same token.
+func (r *passwordResetTokenRepository) MarkUsed(id uint) error {
+	result := r.db.Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPasswordResetTokenUsed
+	}
+	return nil
+}
+
+// InvalidateForUser consumes every outstanding reset token of a user
+func (r *passwordResetTokenRepository) InvalidateForUser(userID uint) error {
+	return r.db.Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
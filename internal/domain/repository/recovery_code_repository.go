@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// ErrRecoveryCodeInvalid is returned when a recovery code is unknown or already used
+var ErrRecoveryCodeInvalid = errors.New("recovery code invalid or already used")
+
+// RecoveryCodeRepository interface for 2FA recovery code operations
+type RecoveryCodeRepository interface {
+	Replace(userID uint, codeHashes []string) error
+	Use(userID uint, codeHash string) error
+	DeleteForUser(userID uint) error
+}
+
+// recoveryCodeRepository implements RecoveryCodeRepository
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db}
+}
+
+// Replace discards the recovery codes of a user and stores new ones
+func (r *recoveryCodeRepository) Replace(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]entity.RecoveryCode, len(codeHashes))
+		for i, codeHash := range codeHashes {
+			codes[i] = entity.RecoveryCode{UserID: userID, CodeHash: codeHash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use consumes an unused recovery code of a user. It fails with
+// ErrRecoveryCodeInvalid when no such code is left.
+func (r *recoveryCodeRepository) Use(userID uint, codeHash string) error {
+	result := r.db.Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// DeleteForUser removes every recovery code of a user
+func (r *recoveryCodeRepository) DeleteForUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error
+}
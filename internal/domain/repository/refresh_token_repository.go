@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenUsed is returned when a refresh token has already been rotated
+var ErrRefreshTokenUsed = errors.New("refresh token already used")
+
+// RefreshTokenRepository interface for refresh token operations
+type RefreshTokenRepository interface {
+	Create(token *entity.RefreshToken) error
+	FindByHash(tokenHash string) (*entity.RefreshToken, error)
+	MarkUsed(id uint) error
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID uint) error
+	RevokeAllForRole(roleID uint) error
+	RevokeOthersForUser(userID uint, keepFamilyID string) error
+	IsFamilyActive(familyID string) (bool, error)
+}
+
+// refreshTokenRepository implements RefreshTokenRepository
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db}
+}
+
+// Create creates a new refresh token
+func (r *refreshTokenRepository) Create(token *entity.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash finds a refresh token by its hash
+func (r *refreshTokenRepository) FindByHash(tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("refresh_token_not_found", "refresh token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks a refresh token as rotated. It fails with ErrRefreshTokenUsed
+// when another request already rotated the same token.
+func (r *refreshTokenRepository) MarkUsed(id uint) error {
+	result := r.db.Model(&entity.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefreshTokenUsed
+	}
+	return nil
+}
+
+// RevokeFamily revokes every refresh token in a family
+func (r *refreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every refresh token belonging to a user
+func (r *refreshTokenRepository) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForRole revokes every refresh token of the users holding a role
+func (r *refreshTokenRepository) RevokeAllForRole(roleID uint) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("user_id IN (SELECT user_id FROM user_roles WHERE role_id = ?) AND revoked_at IS NULL", roleID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOthersForUser revokes every refresh token of a user outside the kept family
+func (r *refreshTokenRepository) RevokeOthersForUser(userID uint, keepFamilyID string) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsFamilyActive reports whether a family still has a usable refresh token
+func (r *refreshTokenRepository) IsFamilyActive(familyID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
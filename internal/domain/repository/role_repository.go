@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// RoleRepository interface for role and permission operations
+type RoleRepository interface {
+	Create(role *entity.Role) error
+	FindAll() ([]entity.Role, error)
+	FindByID(id uint) (*entity.Role, error)
+	FindByNames(names []string) ([]entity.Role, error)
+	Update(role *entity.Role) error
+	Delete(id uint) error
+	FindAllPermissions() ([]entity.Permission, error)
+	FindPermissionsByNames(names []string) ([]entity.Permission, error)
+	ReplaceUserRoles(user *entity.User, roles []entity.Role) error
+}
+
+// roleRepository implements RoleRepository
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db}
+}
+
+// Create creates a new role with its permissions
+func (r *roleRepository) Create(role *entity.Role) error {
+	return translateError(r.db.Create(role).Error)
+}
+
+// FindAll returns every role with its permissions, ordered by name
+func (r *roleRepository) FindAll() ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.db.Preload("Permissions", orderByName).Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// FindByID finds a role by ID
+func (r *roleRepository) FindByID(id uint) (*entity.Role, error) {
+	var role entity.Role
+	err := r.db.Preload("Permissions", orderByName).First(&role, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("role_not_found", "role not found")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// FindByNames finds the roles with the given names. Unknown names are ignored.
+func (r *roleRepository) FindByNames(names []string) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.db.Preload("Permissions", orderByName).Where("name IN ?", names).Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// Update updates a role and replaces its permissions
+func (r *roleRepository) Update(role *entity.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return translateError(err)
+		}
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+// Delete deletes a role. Its grants and assignments are removed by cascade.
+func (r *roleRepository) Delete(id uint) error {
+	result := r.db.Delete(&entity.Role{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("role_not_found", "role not found")
+	}
+	return nil
+}
+
+// FindAllPermissions returns every known permission, ordered by name
+func (r *roleRepository) FindAllPermissions() ([]entity.Permission, error) {
+	var permissions []entity.Permission
+	err := r.db.Order("name ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// FindPermissionsByNames finds the permissions with the given names. Unknown names are ignored.
+func (r *roleRepository) FindPermissionsByNames(names []string) ([]entity.Permission, error) {
+	var permissions []entity.Permission
+	err := r.db.Where("name IN ?", names).Order("name ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// ReplaceUserRoles replaces the roles assigned to a user
+func (r *roleRepository) ReplaceUserRoles(user *entity.User, roles []entity.Role) error {
+	if err := r.db.Model(user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+	user.Roles = roles
+	return nil
+}
+
+// orderByName orders preloaded associations by name
+func orderByName(db *gorm.DB) *gorm.DB {
+	return db.Order("name ASC")
+}
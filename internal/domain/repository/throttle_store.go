@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/throttle"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// throttleStore implements throttle.Store on the throttle_attempts table so
+// every instance shares the same attempt counts
+type throttleStore struct {
+	db *gorm.DB
+}
+
+// NewThrottleStore creates a database-backed throttle store
+func NewThrottleStore(db *gorm.DB) throttle.Store {
+	return &throttleStore{db}
+}
+
+// Get returns the entry of a key
+func (s *throttleStore) Get(key string) (throttle.Entry, error) {
+	var attempt entity.ThrottleAttempt
+	err := s.db.Where("throttle_key = ?", key).First(&attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return throttle.Entry{}, nil
+		}
+		return throttle.Entry{}, err
+	}
+	return throttle.Entry{Attempts: attempt.Attempts, LastAttempt: attempt.LastAttemptAt}, nil
+}
+
+// Increment records an attempt under a row lock and drops expired rows
+func (s *throttleStore) Increment(key string, now time.Time, window time.Duration) (throttle.Entry, error) {
+	if err := s.db.Where("expires_at < ?", now).Delete(&entity.ThrottleAttempt{}).Error; err != nil {
+		return throttle.Entry{}, err
+	}
+
+	attempt, err := s.increment(key, now, window)
+	// Two instances may insert the first attempt of a key at the same time
+	if apperror.IsKind(err, apperror.KindConflict) {
+		attempt, err = s.increment(key, now, window)
+	}
+	if err != nil {
+		return throttle.Entry{}, err
+	}
+	return throttle.Entry{Attempts: attempt.Attempts, LastAttempt: attempt.LastAttemptAt}, nil
+}
+
+func (s *throttleStore) increment(key string, now time.Time, window time.Duration) (*entity.ThrottleAttempt, error) {
+	var attempt entity.ThrottleAttempt
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("throttle_key = ?", key).
+			First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			attempt = entity.ThrottleAttempt{Key: key, Attempts: 1, LastAttemptAt: now, ExpiresAt: now.Add(window)}
+			return translateError(tx.Create(&attempt).Error)
+		}
+		if err != nil {
+			return err
+		}
+
+		if now.After(attempt.ExpiresAt) {
+			attempt.Attempts = 0
+		}
+		attempt.Attempts++
+		attempt.LastAttemptAt = now
+		attempt.ExpiresAt = now.Add(window)
+		return tx.Save(&attempt).Error
+	})
+	return &attempt, err
+}
+
+// Reset forgets a key
+func (s *throttleStore) Reset(key string) error {
+	return s.db.Where("throttle_key = ?", key).Delete(&entity.ThrottleAttempt{}).Error
+}
@@ -2,19 +2,54 @@ package repository
 
 import (
 	"errors"
+	"strings"
 
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/domain/entity"
 
 	"gorm.io/gorm"
 )
 
+// ErrTOTPCodeReused is returned when a TOTP code's time step was already used
+var ErrTOTPCodeReused = errors.New("totp code already used")
+
+// User statuses accepted by UserFilter.Status
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
+// UserFilter represents optional filters for user listings
+type UserFilter struct {
+	// Query matches a substring of the email or name
+	Query string
+	Role  string
+	// Status is one of the UserStatus constants. Deleted users are only
+	// listed when it is UserStatusDeleted.
+	Status string
+}
+
 // UserRepository interface for user operations
 type UserRepository interface {
 	Create(user *entity.User) error
+	FindAll(filter UserFilter, params ListParams) (*Page[entity.User], error)
 	FindByID(id uint) (*entity.User, error)
+	FindByIDWithDeleted(id uint) (*entity.User, error)
 	FindByEmail(email string) (*entity.User, error)
+	CountByRole(role string) (int64, error)
 	Update(user *entity.User) error
 	Delete(id uint) error
+	Restore(id uint) error
+	AdvanceTOTPCounter(id uint, counter int64) error
+}
+
+// userSortFields lists the fields user listings can be sorted by
+var userSortFields = map[string]sortField[entity.User]{
+	"id":         {column: "users.id", kind: kindInt, value: func(u *entity.User) any { return u.ID }},
+	"email":      {column: "users.email", kind: kindString, value: func(u *entity.User) any { return u.Email }},
+	"name":       {column: "users.name", kind: kindString, value: func(u *entity.User) any { return u.Name }},
+	"created_at": {column: "users.created_at", kind: kindTime, value: func(u *entity.User) any { return u.CreatedAt }},
 }
 
 // userRepository implements UserRepository
@@ -29,16 +64,56 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 
 // Create creates a new user
 func (r *userRepository) Create(user *entity.User) error {
-	return r.db.Create(user).Error
+	return translateError(r.db.Create(user).Error)
+}
+
+// FindAll returns a page of users with their roles
+func (r *userRepository) FindAll(filter UserFilter, params ListParams) (*Page[entity.User], error) {
+	query := r.db.Model(&entity.User{})
+
+	if filter.Query != "" {
+		like := containsPattern(strings.ToLower(filter.Query))
+		query = query.Where("(LOWER(users.email) LIKE ? ESCAPE '!' OR LOWER(users.name) LIKE ? ESCAPE '!')", like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("users.id IN (SELECT user_roles.user_id FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE roles.name = ?)", filter.Role)
+	}
+
+	switch filter.Status {
+	case "":
+	case UserStatusActive:
+		query = query.Where("users.suspended_at IS NULL")
+	case UserStatusSuspended:
+		query = query.Where("users.suspended_at IS NOT NULL")
+	case UserStatusDeleted:
+		query = query.Unscoped().Where("users.deleted_at IS NOT NULL")
+	default:
+		return nil, invalidListParams("unknown user status %q", filter.Status)
+	}
+
+	return paginate(query, params, userSortFields, "id", preloadRoles)
 }
 
 // FindByID finds a user by ID
 func (r *userRepository) FindByID(id uint) (*entity.User, error) {
 	var user entity.User
-	err := r.db.First(&user, id).Error
+	err := r.db.Preload("Roles.Permissions").First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("user_not_found", "user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIDWithDeleted finds a user by ID, including soft deleted ones
+func (r *userRepository) FindByIDWithDeleted(id uint) (*entity.User, error) {
+	var user entity.User
+	err := r.db.Unscoped().Preload("Roles.Permissions").First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperror.NotFound("user_not_found", "user not found")
 		}
 		return nil, err
 	}
@@ -48,22 +123,74 @@ func (r *userRepository) FindByID(id uint) (*entity.User, error) {
 // FindByEmail finds a user by email
 func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
 	var user entity.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperror.NotFound("user_not_found", "user not found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update updates a user
+// CountByRole counts the active, unsuspended users assigned the named role
+func (r *userRepository) CountByRole(role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&entity.User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ? AND users.suspended_at IS NULL", role).
+		Count(&count).Error
+	return count, err
+}
+
+// Update updates a user. Role assignments are left untouched.
 func (r *userRepository) Update(user *entity.User) error {
-	return r.db.Save(user).Error
+	return translateError(r.db.Omit("Roles").Save(user).Error)
 }
 
-// Delete deletes a user
+// Delete soft deletes a user
 func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&entity.User{}, id).Error
+	result := r.db.Delete(&entity.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("user_not_found", "user not found")
+	}
+	return nil
+}
+
+// Restore undoes the soft deletion of a user
+func (r *userRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("user_not_found", "deleted user not found")
+	}
+	return nil
+}
+
+// AdvanceTOTPCounter records the time step of an accepted TOTP code. It
+// fails with ErrTOTPCodeReused when the step, or a later one, was already used.
+func (r *userRepository) AdvanceTOTPCounter(id uint, counter int64) error {
+	result := r.db.Model(&entity.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTOTPCodeReused
+	}
+	return nil
+}
+
+// preloadRoles loads the roles of listed users
+func preloadRoles(db *gorm.DB) *gorm.DB {
+	return db.Preload("Roles")
 }
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"reflect"
+
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+)
+
+// auditExportBatchSize is the number of entries loaded at once when exporting
+const auditExportBatchSize = 500
+
+// Audited actions
+const (
+	AuditRegister          = "auth.register"
+	AuditLogin             = "auth.login"
+	AuditLoginFailed       = "auth.login_failed"
+	AuditLogout            = "auth.logout"
+	AuditPasswordChanged   = "auth.password_changed"
+	AuditPasswordReset     = "auth.password_reset"
+	AuditEmailChanged      = "auth.email_changed"
+	AuditEmailVerified     = "auth.email_verified"
+	AuditEmailChangeAsked  = "auth.email_change_requested"
+	AuditProfileUpdated    = "auth.profile_updated"
+	AuditAccountDeleted    = "auth.account_deleted"
+	AuditTwoFactorEnabled  = "auth.two_factor_enabled"
+	AuditTwoFactorDisabled = "auth.two_factor_disabled"
+	AuditRecoveryCodesNew  = "auth.recovery_codes_regenerated"
+	AuditAPIKeyCreated     = "auth.api_key_created"
+	AuditAPIKeyRevoked     = "auth.api_key_revoked"
+	AuditIdentityUnlinked  = "auth.identity_unlinked"
+	AuditBookCreated       = "book.created"
+	AuditBookUpdated       = "book.updated"
+	AuditBookDeleted       = "book.deleted"
+	AuditCategoryCreated   = "category.created"
+	AuditCategoryUpdated   = "category.updated"
+	AuditCategoryDeleted   = "category.deleted"
+	AuditUserCreated       = "user.created"
+	AuditUserPasswordSet   = "user.password_reset"
+	AuditUserSuspended     = "user.suspended"
+	AuditUserUnsuspended   = "user.unsuspended"
+	AuditUserDeleted       = "user.deleted"
+	AuditUserRestored      = "user.restored"
+	AuditUserUnlocked      = "user.unlocked"
+	AuditUserRolesAssigned = "user.roles_assigned"
+	AuditRoleCreated       = "role.created"
+	AuditRoleUpdated       = "role.updated"
+	AuditRoleDeleted       = "role.deleted"
+)
+
+// Audit target types
+const (
+	auditTargetUser     = "user"
+	auditTargetBook     = "book"
+	auditTargetCategory = "category"
+	auditTargetRole     = "role"
+	auditTargetAPIKey   = "api_key"
+	auditTargetIdentity = "identity"
+)
+
+// Actor identifies who performs an action and from where. UserID is zero
+// for anonymous requests and the CLI.
+type Actor struct {
+	UserID    uint
+	IP        string
+	UserAgent string
+}
+
+// Auditor appends entries to the audit log
+type Auditor struct {
+	repo repository.AuditLogRepository
+}
+
+// NewAuditor creates an auditor
+func NewAuditor(repo repository.AuditLogRepository) *Auditor {
+	return &Auditor{repo: repo}
+}
+
+// record appends an entry for an action of actor on a target. A zero
+// targetID records no target.
+func (a *Auditor) record(actor Actor, action, targetType string, targetID uint, changes entity.AuditChanges, details entity.AuditDetails) error {
+	entry := &entity.AuditLog{
+		IP:        actor.IP,
+		UserAgent: truncate(actor.UserAgent, 255),
+		Action:    action,
+		Changes:   changes,
+		Details:   details,
+	}
+	if actor.UserID != 0 {
+		entry.ActorID = &actor.UserID
+	}
+	if targetID != 0 {
+		entry.TargetType = targetType
+		entry.TargetID = &targetID
+	}
+	return a.repo.Create(entry)
+}
+
+// auditDiff returns the fields whose values differ between two snapshots.
+// A nil before records a creation, a nil after a deletion.
+func auditDiff(before, after map[string]interface{}) entity.AuditChanges {
+	changes := entity.AuditChanges{}
+	for field, value := range after {
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = entity.AuditChange{Before: old, After: value}
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = entity.AuditChange{Before: old}
+		}
+	}
+	return changes
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// AuditService reads the audit log
+type AuditService interface {
+	GetAll(filter repository.AuditLogFilter, params repository.ListParams) (*repository.Page[entity.AuditLog], error)
+	Each(filter repository.AuditLogFilter, fn func(entry *entity.AuditLog) error) error
+}
+
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+// GetAll returns a page of audit log entries
+func (s *auditService) GetAll(filter repository.AuditLogFilter, params repository.ListParams) (*repository.Page[entity.AuditLog], error) {
+	return s.auditRepo.FindAll(filter, params)
+}
+
+// Each calls fn for every matching entry, oldest first, without loading the
+// whole log into memory
+func (s *auditService) Each(filter repository.AuditLogFilter, fn func(entry *entity.AuditLog) error) error {
+	return s.auditRepo.FindInBatches(filter, auditExportBatchSize, func(entries []entity.AuditLog) error {
+		for i := range entries {
+			if err := fn(&entries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
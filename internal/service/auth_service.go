@@ -2,60 +2,180 @@ package service
 
 import (
 	"errors"
+	"slices"
 	"time"
 
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/domain/entity"
 	"dot-be-go/internal/domain/repository"
-	"dot-be-go/pkg/hash"
 	"dot-be-go/pkg/jwt"
+	"dot-be-go/pkg/mailer"
+	"dot-be-go/pkg/oidc"
+	"dot-be-go/pkg/token"
 )
 
 // AuthRequest represents authentication request data
 type AuthRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
-	Name     string `json:"name,omitempty" validate:"omitempty,min=3"`
+	Actor    Actor  `json:"-"`
+}
+
+// RegisterRequest represents registration request data
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Actor    Actor  `json:"-"`
+}
+
+// RefreshRequest represents refresh and logout request data
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	Actor        Actor  `json:"-"`
 }
 
 // AuthResponse represents authentication response data
 type AuthResponse struct {
-	Token    string       `json:"token"`
-	User     *entity.User `json:"user"`
-	ExpireAt time.Time    `json:"expire_at"`
+	Token                string       `json:"token"`
+	RefreshToken         string       `json:"refresh_token"`
+	User                 *entity.User `json:"user"`
+	ExpireAt             time.Time    `json:"expire_at"`
+	RefreshTokenExpireAt time.Time    `json:"refresh_token_expire_at"`
 }
 
 // AuthService handles authentication operations
 type AuthService interface {
-	Register(req *AuthRequest) (*AuthResponse, error)
-	Login(req *AuthRequest) (*AuthResponse, error)
+	Register(req *RegisterRequest) (*AuthResponse, error)
+	Login(req *AuthRequest) (*AuthResponse, *MFAChallenge, error)
+	CompleteMFALogin(req *MFALoginRequest) (*AuthResponse, error)
+	Refresh(req *RefreshRequest) (*AuthResponse, error)
+	Logout(req *RefreshRequest) error
+	ValidateSession(sessionID string, userID uint) error
 	GetUserByID(id uint) (*entity.User, error)
+	UpdateProfile(actor Actor, req *UpdateProfileRequest) (*entity.User, error)
+	ChangePassword(actor Actor, sessionID string, req *ChangePasswordRequest) error
+	RequestEmailChange(actor Actor, req *ChangeEmailRequest) error
+	ConfirmEmailChange(actor Actor, req *ConfirmEmailRequest) (*entity.User, error)
+	DeleteAccount(actor Actor, req *DeleteAccountRequest) error
+	ForgotPassword(req *ForgotPasswordRequest) error
+	ResetPassword(req *ResetPasswordRequest) error
+	VerifyEmail(req *VerifyEmailRequest) (*entity.User, error)
+	ResendVerification(req *ResendVerificationRequest) error
+	SetupTwoFactor(userID uint) (*TwoFactorSetup, error)
+	ConfirmTwoFactor(actor Actor, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(actor Actor, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error)
+	DisableTwoFactor(actor Actor, req *DisableTwoFactorRequest) error
+	JWKS() jwt.JWKSet
+	CreateAPIKey(actor Actor, req *APIKeyRequest) (*CreatedAPIKey, error)
+	ListAPIKeys(userID uint) ([]entity.APIKey, error)
+	RevokeAPIKey(actor Actor, id uint) error
+	AuthenticateAPIKey(raw string) (*APIKeyPrincipal, error)
+	OIDCProviders() []string
+	StartOIDCLogin(provider string) (*OIDCAuthorization, error)
+	CompleteOIDCLogin(provider string, req *OIDCCallbackRequest) (*AuthResponse, *MFAChallenge, error)
+	ListIdentities(userID uint) ([]entity.UserIdentity, error)
+	UnlinkIdentity(actor Actor, id uint) error
 }
 
 type authService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
-	jwtExpiry time.Duration
+	userRepo      repository.UserRepository
+	tokenRepo     repository.RefreshTokenRepository
+	roleRepo      repository.RoleRepository
+	resetRepo     repository.PasswordResetTokenRepository
+	recoveryRepo  repository.RecoveryCodeRepository
+	apiKeyRepo    repository.APIKeyRepository
+	identityRepo  repository.IdentityRepository
+	books         BookService
+	mailer        mailer.Mailer
+	issuer        string
+	keys          *jwt.KeyRing
+	jwtSecret     string
+	jwtExpiry     time.Duration
+	refreshExpiry time.Duration
+	verification  EmailVerificationConfig
+	throttle      *AuthThrottle
+	oidcProviders []*oidc.Provider
+	passwords     *Passwords
+	audit         *Auditor
+}
+
+// AuthServiceDeps holds the dependencies and settings of the auth service
+type AuthServiceDeps struct {
+	UserRepo     repository.UserRepository
+	TokenRepo    repository.RefreshTokenRepository
+	RoleRepo     repository.RoleRepository
+	ResetRepo    repository.PasswordResetTokenRepository
+	RecoveryRepo repository.RecoveryCodeRepository
+	APIKeyRepo   repository.APIKeyRepository
+	IdentityRepo repository.IdentityRepository
+	Books        BookService
+	Mailer       mailer.Mailer
+	Passwords    *Passwords
+	Throttle     *AuthThrottle
+	Audit        *Auditor
+
+	// Issuer names the app in tokens and two-factor setup
+	Issuer string
+	// Keys sign access tokens; JWTSecret signs verification and MFA tokens
+	Keys          *jwt.KeyRing
+	JWTSecret     string
+	JWTExpiry     time.Duration
+	RefreshExpiry time.Duration
+	Verification  EmailVerificationConfig
+	OIDCProviders []*oidc.Provider
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo repository.UserRepository, jwtSecret string, jwtExpiry time.Duration) AuthService {
+func NewAuthService(deps AuthServiceDeps) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
-		jwtExpiry: jwtExpiry,
+		userRepo:      deps.UserRepo,
+		tokenRepo:     deps.TokenRepo,
+		roleRepo:      deps.RoleRepo,
+		resetRepo:     deps.ResetRepo,
+		recoveryRepo:  deps.RecoveryRepo,
+		apiKeyRepo:    deps.APIKeyRepo,
+		identityRepo:  deps.IdentityRepo,
+		books:         deps.Books,
+		mailer:        deps.Mailer,
+		issuer:        deps.Issuer,
+		keys:          deps.Keys,
+		jwtSecret:     deps.JWTSecret,
+		jwtExpiry:     deps.JWTExpiry,
+		refreshExpiry: deps.RefreshExpiry,
+		verification:  deps.Verification,
+		throttle:      deps.Throttle,
+		oidcProviders: deps.OIDCProviders,
+		passwords:     deps.Passwords,
+		audit:         deps.Audit,
 	}
 }
 
 // Register creates a new user and returns auth response
-func (s *authService) Register(req *AuthRequest) (*AuthResponse, error) {
+func (s *authService) Register(req *RegisterRequest) (*AuthResponse, error) {
+	// Every registration counts against the client address
+	if err := s.throttle.check(throttleRegister, "", req.Actor.IP); err != nil {
+		return nil, err
+	}
+	if err := s.throttle.hit(throttleRegister, "", req.Actor.IP); err != nil {
+		return nil, err
+	}
+
 	// Check if user already exists
-	existingUser, err := s.userRepo.FindByEmail(req.Email)
-	if err == nil || existingUser != nil {
-		return nil, errors.New("email already registered")
+	if _, err := s.userRepo.FindByEmail(req.Email); err == nil {
+		return nil, apperror.Conflict("email_taken", "email already registered")
+	} else if !apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, err
+	}
+
+	// Default role
+	roles, err := resolveRoles(s.roleRepo, []string{entity.RoleUser})
+	if err != nil {
+		return nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := hash.GenerateHash(req.Password)
+	hashedPassword, err := s.passwords.hash("password", req.Password, req.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -65,53 +185,294 @@ func (s *authService) Register(req *AuthRequest) (*AuthResponse, error) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Role:     "user", // Default role
+		Roles:    roles,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
+	actor := req.Actor
+	actor.UserID = user.ID
+	if err := s.audit.record(actor, AuditRegister, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
 
-	// Generate token
-	token, err := jwt.GenerateToken(user, s.jwtSecret, s.jwtExpiry)
-	if err != nil {
+	if err := s.sendVerification(user); err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{
-		Token:    token,
-		User:     user,
-		ExpireAt: time.Now().Add(s.jwtExpiry),
-	}, nil
+	return s.newSession(user)
 }
 
-// Login authenticates a user and returns auth response
-func (s *authService) Login(req *AuthRequest) (*AuthResponse, error) {
+// Login authenticates a user and returns auth response. Users with
+// two-factor authentication get an MFA challenge to complete with
+// CompleteMFALogin instead. Failed attempts are throttled per account and
+// per client address.
+func (s *authService) Login(req *AuthRequest) (*AuthResponse, *MFAChallenge, error) {
+	if err := s.throttle.check(throttleLogin, req.Email, req.Actor.IP); err != nil {
+		if auditErr := s.auditLoginFailed(req.Actor, req.Email, nil, "too_many_attempts"); auditErr != nil {
+			return nil, nil, auditErr
+		}
+		return nil, nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return nil, nil, s.loginFailed(req.Actor, req.Email, nil)
+		}
+		return nil, nil, err
 	}
 
 	// Check password
-	if !hash.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.New("invalid email or password")
+	if !s.passwords.verify(req.Password, user.Password) {
+		return nil, nil, s.loginFailed(req.Actor, req.Email, user)
+	}
+	if err := s.rehashPassword(user, req.Password); err != nil {
+		return nil, nil, err
 	}
 
-	// Generate token
-	token, err := jwt.GenerateToken(user, s.jwtSecret, s.jwtExpiry)
+	if user.IsSuspended() {
+		if err := s.auditLoginFailed(req.Actor, req.Email, user, "account_suspended"); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errAccountSuspended()
+	}
+	// The failed attempts of 2FA users are cleared once the second step succeeds
+	if user.HasTwoFactor() {
+		return nil, s.newMFAChallenge(user), nil
+	}
+	if err := s.throttle.unlock(req.Email); err != nil {
+		return nil, nil, err
+	}
+	if err := s.auditLogin(req.Actor, user, "password"); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.newSession(user)
+	return resp, nil, err
+}
+
+// rehashPassword upgrades a stored hash made with an older algorithm or
+// weaker parameters while the plain password is at hand
+func (s *authService) rehashPassword(user *entity.User, password string) error {
+	if !s.passwords.Hasher.NeedsRehash(user.Password) {
+		return nil
+	}
+
+	hashedPassword, err := s.passwords.Hasher.Hash(password)
 	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	return s.userRepo.Update(user)
+}
+
+// Refresh rotates a refresh token and returns a new token pair. Presenting a
+// token that was already rotated or revoked revokes its whole family.
+func (s *authService) Refresh(req *RefreshRequest) (*AuthResponse, error) {
+	current, err := s.tokenRepo.FindByHash(token.Hash(req.RefreshToken))
+	if err != nil {
+		return nil, invalidRefreshToken(err)
+	}
+
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errRefreshTokenReused()
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, apperror.Unauthorized("refresh_token_expired", "refresh token expired")
+	}
+
+	// A concurrent request may have rotated the token in the meantime
+	if err := s.tokenRepo.MarkUsed(current.ID); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenUsed) {
+			if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, errRefreshTokenReused()
+		}
 		return nil, err
 	}
 
-	return &AuthResponse{
-		Token:    token,
-		User:     user,
-		ExpireAt: time.Now().Add(s.jwtExpiry),
-	}, nil
+	user, err := s.userRepo.FindByID(current.UserID)
+	if err != nil {
+		return nil, invalidRefreshToken(err)
+	}
+	if user.IsSuspended() {
+		if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errAccountSuspended()
+	}
+
+	return s.issueTokens(user, current.FamilyID)
+}
+
+// Logout revokes the session the refresh token belongs to
+func (s *authService) Logout(req *RefreshRequest) error {
+	current, err := s.tokenRepo.FindByHash(token.Hash(req.RefreshToken))
+	if err != nil {
+		return invalidRefreshToken(err)
+	}
+
+	if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+		return err
+	}
+	actor := req.Actor
+	actor.UserID = current.UserID
+	return s.audit.record(actor, AuditLogout, auditTargetUser, current.UserID, nil, nil)
+}
+
+// ValidateSession checks that the session an access token was issued for is
+// still active. Suspending or deleting a user revokes all of their sessions.
+func (s *authService) ValidateSession(sessionID string, userID uint) error {
+	if sessionID == "" {
+		return errSessionRevoked()
+	}
+
+	active, err := s.tokenRepo.IsFamilyActive(sessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		if user, err := s.userRepo.FindByID(userID); err == nil && user.IsSuspended() {
+			return errAccountSuspended()
+		}
+		return errSessionRevoked()
+	}
+
+	return nil
 }
 
 // GetUserByID returns a user by ID
 func (s *authService) GetUserByID(id uint) (*entity.User, error) {
 	return s.userRepo.FindByID(id)
 }
+
+// newSession starts a new refresh token family for the user
+func (s *authService) newSession(user *entity.User) (*AuthResponse, error) {
+	familyID, err := token.GenerateID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, familyID)
+}
+
+// issueTokens creates an access token and a refresh token within a family
+func (s *authService) issueTokens(user *entity.User, familyID string) (*AuthResponse, error) {
+	refreshToken, err := token.Generate(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &entity.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: token.Hash(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.refreshExpiry),
+	}
+	if err := s.tokenRepo.Create(record); err != nil {
+		return nil, err
+	}
+
+	// Generate token
+	accessToken, err := jwt.GenerateToken(user, s.permissionsFor(user), familyID, s.keys, s.jwtExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:                accessToken,
+		RefreshToken:         refreshToken,
+		User:                 user,
+		ExpireAt:             now.Add(s.jwtExpiry),
+		RefreshTokenExpireAt: record.ExpiresAt,
+	}, nil
+}
+
+// JWKS returns the public keys access tokens can be verified with
+func (s *authService) JWKS() jwt.JWKSet {
+	return s.keys.JWKS()
+}
+
+// permissionsFor returns the permissions embedded in the user's access
+// tokens. Users whose role requires 2FA get none until they enrol, and
+// unverified users only keep the configured subset when verification is
+// required.
+func (s *authService) permissionsFor(user *entity.User) []string {
+	if user.RequiresTwoFactor() && !user.HasTwoFactor() {
+		return []string{}
+	}
+
+	permissions := user.PermissionNames()
+	if !s.verification.Required || user.IsEmailVerified() {
+		return permissions
+	}
+
+	allowed := []string{}
+	for _, permission := range permissions {
+		if slices.Contains(s.verification.UnverifiedPermissions, permission) {
+			allowed = append(allowed, permission)
+		}
+	}
+	return allowed
+}
+
+// loginFailed records a failed login and returns the error to report
+func (s *authService) loginFailed(actor Actor, email string, user *entity.User) error {
+	if err := s.auditLoginFailed(actor, email, user, "invalid_credentials"); err != nil {
+		return err
+	}
+	if err := s.throttle.hit(throttleLogin, email, actor.IP); err != nil {
+		return err
+	}
+	return errInvalidCredentials()
+}
+
+// auditLogin records a successful login of user by method
+func (s *authService) auditLogin(actor Actor, user *entity.User, method string) error {
+	actor.UserID = user.ID
+	return s.audit.record(actor, AuditLogin, auditTargetUser, user.ID, nil, entity.AuditDetails{"method": method})
+}
+
+// auditLoginFailed records a rejected login for an email address. user is
+// nil when the address is unknown.
+func (s *authService) auditLoginFailed(actor Actor, email string, user *entity.User, reason string) error {
+	var targetID uint
+	if user != nil {
+		targetID = user.ID
+	}
+	return s.audit.record(actor, AuditLoginFailed, auditTargetUser, targetID, nil, entity.AuditDetails{"email": email, "reason": reason})
+}
+
+func errInvalidCredentials() error {
+	return apperror.Unauthorized("invalid_credentials", "invalid email or password")
+}
+
+func errRefreshTokenReused() error {
+	return apperror.Unauthorized("refresh_token_reused", "refresh token reuse detected, session revoked")
+}
+
+func errAccountSuspended() error {
+	return apperror.Forbidden("account_suspended", "account has been suspended")
+}
+
+func errSessionRevoked() error {
+	return apperror.Unauthorized("session_revoked", "session has been revoked")
+}
+
+// invalidRefreshToken reports an unknown refresh token or its deleted owner
+// as unauthorized and passes any other lookup error through
+func invalidRefreshToken(err error) error {
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/thumbnail"
+	"dot-be-go/pkg/token"
+)
+
+// BookCoverOriginal is the size name of the uploaded cover image in
+// entity.BookCover; the thumbnails are named after thumbnail.DefaultSizes
+const BookCoverOriginal = "original"
+
+// coverExtensions maps the accepted cover content types to file extensions
+var coverExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// BookCoverConfig limits cover uploads
+type BookCoverConfig struct {
+	// MaxSize is the largest cover file accepted, in bytes
+	MaxSize int64
+	// MaxPixels is the largest number of pixels of a cover image, checked
+	// before decoding it
+	MaxPixels int
+}
+
+// SetCover stores an uploaded JPEG, PNG or WebP image as the cover of a
+// book together with its thumbnails, replacing any previous cover. Every
+// upload is stored under a new key so cached URLs of a replaced cover never
+// serve the new image.
+func (s *bookService) SetCover(actor Actor, id uint, userID uint, file io.Reader) (*entity.Book, error) {
+	if s.covers == nil {
+		return nil, apperror.Unavailable("cover_storage_unavailable", "cover storage is not configured")
+	}
+
+	book, err := s.bookRepo.FindByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.coverConfig.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.coverConfig.MaxSize {
+		return nil, apperror.ValidationField("file", "max", fmt.Sprintf("file must be at most %d bytes", s.coverConfig.MaxSize))
+	}
+
+	contentType, err := thumbnail.Detect(data)
+	if err != nil {
+		return nil, apperror.ValidationField("file", "image", "file must be a JPEG, PNG or WebP image")
+	}
+	thumbnails, err := thumbnail.Generate(data, thumbnail.DefaultSizes, s.coverConfig.MaxPixels)
+	if errors.Is(err, thumbnail.ErrTooLarge) {
+		return nil, apperror.ValidationField("file", "dimensions", fmt.Sprintf("image must have at most %d pixels", s.coverConfig.MaxPixels))
+	}
+	if err != nil {
+		return nil, apperror.ValidationField("file", "image", "file is not a valid JPEG, PNG or WebP image")
+	}
+
+	version, err := token.GenerateID(8)
+	if err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("covers/%d/%s/", book.ID, version)
+
+	type blob struct {
+		name        string
+		key         string
+		data        []byte
+		contentType string
+	}
+	blobs := []blob{{BookCoverOriginal, prefix + BookCoverOriginal + coverExtensions[contentType], data, contentType}}
+	for _, thumb := range thumbnails {
+		blobs = append(blobs, blob{thumb.Size.Name, prefix + thumb.Size.Name + ".jpg", thumb.Data, "image/jpeg"})
+	}
+
+	cover, keys := entity.BookCover{}, entity.BookCover{}
+	for _, b := range blobs {
+		if err = s.covers.Put(context.Background(), b.key, b.data, b.contentType); err != nil {
+			err = apperror.Unavailable("cover_storage_unavailable", "the cover storage did not answer").Wrap(err)
+			break
+		}
+		keys[b.name] = b.key
+		cover[b.name] = s.covers.URL(b.key)
+	}
+	if err == nil {
+		err = s.bookRepo.UpdateCover(book.ID, cover, keys)
+	}
+	if err != nil {
+		s.deleteCoverBlobs(keys)
+		return nil, err
+	}
+
+	// The replaced blobs are no longer referenced; a failure leaves orphans
+	// behind but does not affect the book
+	s.deleteCoverBlobs(book.CoverKeys)
+
+	change := entity.AuditChanges{"cover": {Before: coverAuditValue(book.Cover), After: cover[BookCoverOriginal]}}
+	if err := s.audit.record(actor, AuditBookUpdated, auditTargetBook, book.ID, change, nil); err != nil {
+		return nil, err
+	}
+
+	book.Cover, book.CoverKeys = cover, keys
+	return book, nil
+}
+
+// DeleteCover removes the cover of a book and its blobs
+func (s *bookService) DeleteCover(actor Actor, id uint, userID uint) (*entity.Book, error) {
+	book, err := s.bookRepo.FindByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(book.CoverKeys) == 0 {
+		return nil, apperror.NotFound("book_cover_not_found", "book has no cover")
+	}
+
+	if err := s.removeCoverBlobs(book.CoverKeys); err != nil {
+		return nil, err
+	}
+	if err := s.bookRepo.UpdateCover(book.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	change := entity.AuditChanges{"cover": {Before: coverAuditValue(book.Cover)}}
+	if err := s.audit.record(actor, AuditBookUpdated, auditTargetBook, book.ID, change, nil); err != nil {
+		return nil, err
+	}
+
+	book.Cover, book.CoverKeys = nil, nil
+	return book, nil
+}
+
+// removeCoverBlobs deletes the blobs of a cover, failing when the storage
+// does not answer so the book keeps referencing them
+func (s *bookService) removeCoverBlobs(keys entity.BookCover) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if s.covers == nil {
+		return apperror.Unavailable("cover_storage_unavailable", "cover storage is not configured")
+	}
+	for _, key := range keys {
+		if err := s.covers.Delete(context.Background(), key); err != nil {
+			return apperror.Unavailable("cover_storage_unavailable", "the cover storage did not answer").Wrap(err)
+		}
+	}
+	return nil
+}
+
+// deleteCoverBlobs deletes the blobs of a cover, ignoring failures
+func (s *bookService) deleteCoverBlobs(keys entity.BookCover) {
+	_ = s.removeCoverBlobs(keys)
+}
+
+// coverAuditValue returns the URL of the original cover image for audit
+// logs, or nil without a cover
+func coverAuditValue(cover entity.BookCover) interface{} {
+	if url, ok := cover[BookCoverOriginal]; ok {
+		return url
+	}
+	return nil
+}
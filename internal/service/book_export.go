@@ -0,0 +1,286 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/marc"
+)
+
+// Book export formats
+const (
+	BookExportCSV     = "csv"
+	BookExportJSON    = "json"
+	BookExportNDJSON  = "ndjson"
+	BookExportMARCXML = "marcxml"
+	BookExportBibTeX  = "bibtex"
+	BookExportRIS     = "ris"
+)
+
+// bookExportFormats lists the export formats in the order they are documented
+var bookExportFormats = []string{BookExportCSV, BookExportJSON, BookExportNDJSON, BookExportMARCXML, BookExportBibTeX, BookExportRIS}
+
+// EachBook walks a set of books, calling fn for every one of them
+type EachBook func(fn func(book *entity.Book) error) error
+
+// BookExport writes books in one export format
+type BookExport struct {
+	Format      string
+	ContentType string
+	// Extension is the usual file extension of the format, with the dot
+	Extension string
+	write     func(w io.Writer, each EachBook) error
+}
+
+// NewBookExport returns the export of a format. An empty format selects CSV.
+func NewBookExport(format string) (*BookExport, error) {
+	if format == "" {
+		format = BookExportCSV
+	}
+
+	export := &BookExport{Format: format}
+	switch format {
+	case BookExportCSV:
+		export.ContentType, export.Extension, export.write = "text/csv; charset=utf-8", ".csv", exportBooksCSV
+	case BookExportJSON:
+		export.ContentType, export.Extension, export.write = "application/json", ".json", exportBooksJSON
+	case BookExportNDJSON:
+		export.ContentType, export.Extension, export.write = "application/x-ndjson", ".ndjson", exportBooksNDJSON
+	case BookExportMARCXML:
+		export.ContentType, export.Extension, export.write = "application/marcxml+xml", ".xml", exportBooksMARCXML
+	case BookExportBibTeX:
+		export.ContentType, export.Extension, export.write = "application/x-bibtex; charset=utf-8", ".bib", exportBooksBibTeX
+	case BookExportRIS:
+		export.ContentType, export.Extension, export.write = "application/x-research-info-systems", ".ris", exportBooksRIS
+	default:
+		return nil, apperror.ValidationField("format", "oneof", "format must be one of: "+strings.Join(bookExportFormats, ", "))
+	}
+	return export, nil
+}
+
+// Write streams the books walked by each to w
+func (x *BookExport) Write(w io.Writer, each EachBook) error {
+	return x.write(w, each)
+}
+
+// categoryNames returns the names of the categories of a book
+func categoryNames(book *entity.Book) []string {
+	names := make([]string, len(book.Categories))
+	for i, category := range book.Categories {
+		names[i] = category.Name
+	}
+	return names
+}
+
+// exportBooksCSV writes one row per book with category names joined by
+// semicolons. The columns can be imported again as they are.
+func exportBooksCSV(w io.Writer, each EachBook) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "title", "author", "isbn", "publish_year", "description", "categories", "user_id", "created_at"}); err != nil {
+		return err
+	}
+
+	err := each(func(book *entity.Book) error {
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(book.ID), 10),
+			book.Title,
+			book.Author,
+			book.ISBN,
+			strconv.Itoa(book.PublishYear),
+			book.Description,
+			strings.Join(categoryNames(book), ";"),
+			strconv.FormatUint(uint64(book.UserID), 10),
+			book.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportBooksJSON streams a JSON array without holding every book in memory
+func exportBooksJSON(w io.Writer, each EachBook) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := each(func(book *entity.Book) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		raw, err := json.Marshal(book)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append([]byte("\n  "), raw...))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n]\n")
+	return err
+}
+
+// bookExportLine is a line of an NDJSON export. Its fields are those of a
+// BookRequest plus category names, so an export can be imported again.
+type bookExportLine struct {
+	ID          uint      `json:"id"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	ISBN        string    `json:"isbn"`
+	PublishYear int       `json:"publish_year"`
+	Description string    `json:"description"`
+	CategoryIDs []uint    `json:"category_ids"`
+	Categories  []string  `json:"categories"`
+	UserID      uint      `json:"user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// exportBooksNDJSON writes one JSON object per line
+func exportBooksNDJSON(w io.Writer, each EachBook) error {
+	encoder := json.NewEncoder(w)
+	return each(func(book *entity.Book) error {
+		categoryIDs := make([]uint, len(book.Categories))
+		for i, category := range book.Categories {
+			categoryIDs[i] = category.ID
+		}
+
+		return encoder.Encode(bookExportLine{
+			ID:          book.ID,
+			Title:       book.Title,
+			Author:      book.Author,
+			ISBN:        book.ISBN,
+			PublishYear: book.PublishYear,
+			Description: book.Description,
+			CategoryIDs: categoryIDs,
+			Categories:  categoryNames(book),
+			UserID:      book.UserID,
+			CreatedAt:   book.CreatedAt,
+			UpdatedAt:   book.UpdatedAt,
+		})
+	})
+}
+
+// exportBooksMARCXML writes a MARCXML collection
+func exportBooksMARCXML(w io.Writer, each EachBook) error {
+	writer := marc.NewXMLWriter(w)
+	err := each(func(book *entity.Book) error {
+		return writer.Write(bookMARCRecord(book))
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// bookMARCRecord describes a book as a MARC 21 bibliographic record: 020
+// ISBN, 100 author, 245 title, 264 publication year, 520 description and a
+// 650 subject per category
+func bookMARCRecord(book *entity.Book) *marc.Record {
+	record := &marc.Record{Leader: "00000nam a2200000 i 4500"}
+	record.AddControl("001", strconv.FormatUint(uint64(book.ID), 10))
+	record.AddControl("005", book.UpdatedAt.UTC().Format("20060102150405.0"))
+	// 008: date entered, single publication date, unknown place and language
+	record.AddControl("008", fmt.Sprintf("%ss%04d    xx %17sund d", book.CreatedAt.UTC().Format("060102"), book.PublishYear, ""))
+
+	record.AddData("020", ' ', ' ', marc.Subfield{Code: 'a', Value: book.ISBN})
+	record.AddData("100", '1', ' ', marc.Subfield{Code: 'a', Value: book.Author})
+	record.AddData("245", '1', '0', marc.Subfield{Code: 'a', Value: book.Title})
+	record.AddData("264", ' ', '1', marc.Subfield{Code: 'c', Value: strconv.Itoa(book.PublishYear)})
+	record.AddData("520", ' ', ' ', marc.Subfield{Code: 'a', Value: book.Description})
+	for _, name := range categoryNames(book) {
+		record.AddData("650", ' ', '4', marc.Subfield{Code: 'a', Value: name})
+	}
+	return record
+}
+
+// bibTeXEscaper escapes the characters BibTeX treats specially
+var bibTeXEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"&", `\&`,
+	"%", `\%`,
+	"$", `\$`,
+	"#", `\#`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+	"^", `\textasciicircum{}`,
+)
+
+// exportBooksBibTeX writes a @book entry per book keyed by its ISBN
+func exportBooksBibTeX(w io.Writer, each EachBook) error {
+	return each(func(book *entity.Book) error {
+		key := book.ISBN
+		if key == "" {
+			key = "book" + strconv.FormatUint(uint64(book.ID), 10)
+		}
+
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "@book{%s,\n", key)
+		field := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(&entry, "  %s = {%s},\n", name, bibTeXEscaper.Replace(singleLine(value)))
+			}
+		}
+		field("title", book.Title)
+		field("author", book.Author)
+		field("year", strconv.Itoa(book.PublishYear))
+		field("isbn", book.ISBN)
+		field("abstract", book.Description)
+		field("keywords", strings.Join(categoryNames(book), ", "))
+		entry.WriteString("}\n\n")
+
+		_, err := io.WriteString(w, entry.String())
+		return err
+	})
+}
+
+// exportBooksRIS writes a BOOK reference per book
+func exportBooksRIS(w io.Writer, each EachBook) error {
+	return each(func(book *entity.Book) error {
+		var entry strings.Builder
+		tag := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(&entry, "%s  - %s\r\n", name, singleLine(value))
+			}
+		}
+		tag("TY", "BOOK")
+		tag("ID", strconv.FormatUint(uint64(book.ID), 10))
+		tag("TI", book.Title)
+		tag("AU", book.Author)
+		tag("PY", strconv.Itoa(book.PublishYear))
+		tag("SN", book.ISBN)
+		tag("AB", book.Description)
+		for _, name := range categoryNames(book) {
+			tag("KW", name)
+		}
+		entry.WriteString("ER  - \r\n")
+
+		_, err := io.WriteString(w, entry.String())
+		return err
+	})
+}
+
+// singleLine joins the lines of a value with spaces for line based formats
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
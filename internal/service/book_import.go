@@ -0,0 +1,561 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+	"dot-be-go/pkg/isbn"
+)
+
+const (
+	// bookImportProgressInterval is the number of rows between progress updates
+	bookImportProgressInterval = 100
+	// bookImportErrorBatchSize is the number of row errors loaded at once for the report
+	bookImportErrorBatchSize = 500
+	// bookImportStaleAfter is how long an unfinished import may go without a
+	// progress update before it is considered interrupted
+	bookImportStaleAfter = 10 * time.Minute
+)
+
+// Validator checks a request against its validate struct tags
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// BookImportRequest represents the options of a book import
+type BookImportRequest struct {
+	Format string `json:"format" form:"format" validate:"omitempty,oneof=csv ndjson marc marcxml"`
+	Mode   string `json:"mode" form:"mode" validate:"omitempty,oneof=best_effort atomic"`
+	DryRun bool   `json:"dry_run" form:"dry_run"`
+	Upsert bool   `json:"upsert" form:"upsert"`
+	// Mapping maps CSV columns to book fields as a JSON object, e.g.
+	// {"Judul": "title"}. Columns named after a field need no mapping.
+	Mapping string `json:"mapping" form:"mapping"`
+	// SubjectRules holds subject rules as a JSON array, applied before the
+	// configured ones. UnknownSubjects selects what happens to headings that
+	// map to no category. Both only apply to MARC imports.
+	SubjectRules    string `json:"subject_rules" form:"subject_rules"`
+	UnknownSubjects string `json:"unknown_subjects" form:"unknown_subjects" validate:"omitempty,oneof=skip create reject"`
+}
+
+// BookImportConfig limits book imports
+type BookImportConfig struct {
+	// MaxRows is the largest number of rows an import file may have
+	MaxRows int
+	// SyncRows is the largest number of rows imported before responding;
+	// larger files are imported in the background
+	SyncRows int
+	// SubjectRules map the subject headings of MARC records to categories
+	SubjectRules []SubjectRule
+}
+
+// BookImportService imports books in bulk
+type BookImportService interface {
+	Start(actor Actor, ownerScope uint, req *BookImportRequest, file io.Reader) (*entity.BookImport, error)
+	GetByID(id uint, userID uint) (*entity.BookImport, error)
+	EachError(job *entity.BookImport, fn func(rowError *entity.BookImportError) error) error
+}
+
+type bookImportService struct {
+	bookRepo     repository.BookRepository
+	categoryRepo repository.CategoryRepository
+	importRepo   repository.BookImportRepository
+	validator    Validator
+	audit        *Auditor
+	config       BookImportConfig
+}
+
+// NewBookImportService creates a new book import service
+func NewBookImportService(
+	bookRepo repository.BookRepository,
+	categoryRepo repository.CategoryRepository,
+	importRepo repository.BookImportRepository,
+	validator Validator,
+	audit *Auditor,
+	config BookImportConfig,
+) BookImportService {
+	return &bookImportService{
+		bookRepo:     bookRepo,
+		categoryRepo: categoryRepo,
+		importRepo:   importRepo,
+		validator:    validator,
+		audit:        audit,
+		config:       config,
+	}
+}
+
+// plannedBook is a valid import row and the write it leads to. before is
+// nil for new books; pending lists categories to create for the book.
+type plannedBook struct {
+	line    int
+	book    *entity.Book
+	before  map[string]interface{}
+	pending []*entity.Category
+}
+
+// bookImportRun carries the state of a running import
+type bookImportRun struct {
+	actor      Actor
+	ownerScope uint
+	job        *entity.BookImport
+	// seen maps the ISBNs of the file to the line they first appear on
+	seen      map[string]int
+	rowErrors []entity.BookImportError
+
+	subjectRules    []compiledSubjectRule
+	unknownSubjects string
+	// categories caches the categories subject headings map to by name, nil
+	// for missing ones
+	categories map[string]*entity.Category
+	// counted holds the pending categories a dry run counted as created
+	counted map[*entity.Category]bool
+}
+
+// Start parses an import file and imports its rows as the actor. Upserts
+// may update books of the owner scope: the actor, or anyone when zero.
+// Small files are imported before returning; larger ones are imported in
+// the background and the returned job is still pending.
+func (s *bookImportService) Start(actor Actor, ownerScope uint, req *BookImportRequest, file io.Reader) (*entity.BookImport, error) {
+	mapping := map[string]string{}
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, apperror.ValidationField("mapping", "json", "mapping must be a JSON object of column names to fields")
+		}
+	}
+
+	var rules []SubjectRule
+	if req.SubjectRules != "" {
+		if err := json.Unmarshal([]byte(req.SubjectRules), &rules); err != nil {
+			return nil, apperror.ValidationField("subject_rules", "json", "subject_rules must be a JSON array of rules")
+		}
+	}
+	subjectRules, err := compileSubjectRules(append(rules, s.config.SubjectRules...))
+	if err != nil {
+		return nil, err
+	}
+	unknownSubjects := req.UnknownSubjects
+	if unknownSubjects == "" {
+		unknownSubjects = UnknownSubjectsSkip
+	}
+
+	rows, err := readBookImport(file, req.Format, mapping, s.config.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = entity.BookImportBestEffort
+	}
+	job := &entity.BookImport{
+		UserID:    actor.UserID,
+		Format:    req.Format,
+		Mode:      mode,
+		DryRun:    req.DryRun,
+		Upsert:    req.Upsert,
+		Status:    entity.BookImportPending,
+		TotalRows: len(rows),
+	}
+	if err := s.importRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	run := &bookImportRun{
+		actor:           actor,
+		ownerScope:      ownerScope,
+		job:             job,
+		seen:            map[string]int{},
+		subjectRules:    subjectRules,
+		unknownSubjects: unknownSubjects,
+		categories:      map[string]*entity.Category{},
+		counted:         map[*entity.Category]bool{},
+	}
+	if len(rows) <= s.config.SyncRows {
+		s.run(run, rows)
+		return job, nil
+	}
+
+	// The background run works on its own copy of the job
+	background := *job
+	run.job = &background
+	go s.run(run, rows)
+	return job, nil
+}
+
+// GetByID returns an import of a user. Unfinished imports that stopped
+// reporting progress, e.g. because the server restarted, are marked failed.
+func (s *bookImportService) GetByID(id uint, userID uint) (*entity.BookImport, error) {
+	job, err := s.importRepo.FindByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.IsFinished() && time.Since(job.UpdatedAt) > bookImportStaleAfter {
+		s.finish(job, entity.BookImportFailed, "the import was interrupted")
+	}
+	return job, nil
+}
+
+// EachError calls fn for every row error of an import in file order
+func (s *bookImportService) EachError(job *entity.BookImport, fn func(rowError *entity.BookImportError) error) error {
+	return s.importRepo.FindErrorsInBatches(job.ID, bookImportErrorBatchSize, func(rowErrors []entity.BookImportError) error {
+		for i := range rowErrors {
+			if err := fn(&rowErrors[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// run imports the rows of a job. Failures are recorded on the job rather
+// than returned since background runs have no caller to report to.
+func (s *bookImportService) run(run *bookImportRun, rows []bookImportRow) {
+	job := run.job
+	now := time.Now()
+	job.Status = entity.BookImportRunning
+	job.StartedAt = &now
+	if err := s.importRepo.Update(job); err != nil {
+		s.finish(job, entity.BookImportFailed, err.Error())
+		return
+	}
+
+	var err error
+	if job.Mode == entity.BookImportAtomic {
+		err = s.runAtomic(run, rows)
+	} else {
+		err = s.runBestEffort(run, rows)
+	}
+	if err == nil {
+		err = s.flush(run)
+	}
+
+	switch {
+	case err != nil:
+		s.finish(job, entity.BookImportFailed, err.Error())
+	case job.Mode == entity.BookImportAtomic && job.Failed > 0:
+		s.finish(job, entity.BookImportFailed, fmt.Sprintf("%d of %d rows failed, no books were imported", job.Failed, job.TotalRows))
+	default:
+		s.finish(job, entity.BookImportCompleted, "")
+	}
+}
+
+// runBestEffort writes every valid row on its own and reports the others
+func (s *bookImportService) runBestEffort(run *bookImportRun, rows []bookImportRow) error {
+	for i := range rows {
+		planned, err := s.plan(run, &rows[i])
+		if err != nil {
+			return err
+		}
+		if planned != nil {
+			if err := s.apply(run, planned); err != nil && !s.reject(run, planned.line, rows[i].book.ISBN, err) {
+				return err
+			}
+		}
+
+		if err := s.progress(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply writes a row of a best effort import, or only counts it in a dry run
+func (s *bookImportService) apply(run *bookImportRun, planned *plannedBook) error {
+	if run.job.DryRun {
+		s.count(run, planned)
+		return nil
+	}
+	if err := s.createPendingCategories(run, planned); err != nil {
+		return err
+	}
+	if err := s.write(run, s.bookRepo, planned); err != nil {
+		return err
+	}
+	return s.record(run, planned)
+}
+
+// runAtomic checks every row first and writes them in a single transaction
+// only when all of them are valid
+func (s *bookImportService) runAtomic(run *bookImportRun, rows []bookImportRow) error {
+	plans := make([]*plannedBook, 0, len(rows))
+	for i := range rows {
+		planned, err := s.plan(run, &rows[i])
+		if err != nil {
+			return err
+		}
+		if planned != nil {
+			plans = append(plans, planned)
+			s.count(run, planned)
+		}
+		if err := s.progress(run); err != nil {
+			return err
+		}
+	}
+	if run.job.Failed > 0 {
+		run.job.Created, run.job.Updated, run.job.CategoriesCreated = 0, 0, 0
+		return nil
+	}
+	if run.job.DryRun {
+		return nil
+	}
+
+	// The category repository takes no part in the transaction, so new
+	// categories are created up front and kept should the transaction fail
+	for _, planned := range plans {
+		if err := s.createPendingCategories(run, planned); err != nil {
+			return err
+		}
+	}
+
+	var failed *plannedBook
+	err := s.bookRepo.Transaction(func(repo repository.BookRepository) error {
+		for _, planned := range plans {
+			if err := s.write(run, repo, planned); err != nil {
+				failed = planned
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		run.job.Created, run.job.Updated = 0, 0
+		if failed == nil || !s.reject(run, failed.line, failed.book.ISBN, err) {
+			return err
+		}
+		return nil
+	}
+
+	for _, planned := range plans {
+		if err := s.record(run, planned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plan checks a row like Create and Update do and returns the write it
+// leads to. Rejected rows are reported and return nil.
+func (s *bookImportService) plan(run *bookImportRun, row *bookImportRow) (*plannedBook, error) {
+	run.job.Processed++
+	rejected := func(err error) (*plannedBook, error) {
+		if s.reject(run, row.line, row.book.ISBN, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(row.parseErrors) > 0 {
+		for _, rowError := range row.parseErrors {
+			rowError.ImportID = run.job.ID
+			rowError.Line = row.line
+			rowError.ISBN = row.book.ISBN
+			run.rowErrors = append(run.rowErrors, rowError)
+		}
+		run.job.Failed++
+		return nil, nil
+	}
+	if err := s.validator.Validate(&row.book); err != nil {
+		return rejected(err)
+	}
+
+	canonicalISBN, err := isbn.Normalize(row.book.ISBN)
+	if err != nil {
+		return rejected(apperror.ValidationField("isbn", "isbn", "invalid ISBN: "+err.Error()))
+	}
+	if line, ok := run.seen[canonicalISBN]; ok {
+		return rejected(apperror.ValidationField("isbn", "duplicate", fmt.Sprintf("ISBN already appears on line %d", line)))
+	}
+	run.seen[canonicalISBN] = row.line
+
+	categories, err := s.findCategories(row)
+	if err != nil {
+		return rejected(err)
+	}
+
+	subjectCategories, pending, err := s.resolveSubjects(run, row)
+	if err != nil {
+		return rejected(err)
+	}
+	for _, category := range subjectCategories {
+		if !containsCategory(categories, category.ID) {
+			categories = append(categories, category)
+		}
+	}
+
+	planned := &plannedBook{line: row.line, pending: pending}
+	existing, err := s.bookRepo.FindByISBN(canonicalISBN)
+	switch {
+	case err == nil:
+		if !run.job.Upsert {
+			return rejected(apperror.ValidationField("isbn", "unique", "a book with this ISBN already exists"))
+		}
+		if run.ownerScope != 0 && existing.UserID != run.ownerScope {
+			return rejected(apperror.ValidationField("isbn", "unique", "a book with this ISBN belongs to another user"))
+		}
+		planned.book = existing
+		planned.before = bookAuditFields(existing)
+	case apperror.IsKind(err, apperror.KindNotFound):
+		planned.book = &entity.Book{UserID: run.actor.UserID}
+	default:
+		return nil, err
+	}
+
+	planned.book.Title = row.book.Title
+	planned.book.Author = row.book.Author
+	planned.book.ISBN = canonicalISBN
+	planned.book.PublishYear = row.book.PublishYear
+	planned.book.Description = row.book.Description
+	planned.book.Categories = categories
+	return planned, nil
+}
+
+// findCategories resolves the category IDs and names of a row
+func (s *bookImportService) findCategories(row *bookImportRow) ([]entity.Category, error) {
+	categories := make([]entity.Category, 0, len(row.book.CategoryIDs)+len(row.categoryNames))
+	for i, categoryID := range row.book.CategoryIDs {
+		category, err := s.categoryRepo.FindByID(categoryID)
+		if err != nil {
+			if apperror.IsKind(err, apperror.KindNotFound) {
+				field := fmt.Sprintf("category_ids[%d]", i)
+				return nil, apperror.ValidationField(field, "exists", fmt.Sprintf("category %d does not exist", categoryID))
+			}
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	for _, name := range row.categoryNames {
+		category, err := s.categoryRepo.FindByName(name)
+		if err != nil {
+			if apperror.IsKind(err, apperror.KindNotFound) {
+				return nil, apperror.ValidationField("categories", "exists", fmt.Sprintf("category %q does not exist", name))
+			}
+			return nil, err
+		}
+		if !containsCategory(categories, category.ID) {
+			categories = append(categories, *category)
+		}
+	}
+	return categories, nil
+}
+
+// write creates or updates the book of a row and counts it
+func (s *bookImportService) write(run *bookImportRun, repo repository.BookRepository, planned *plannedBook) error {
+	var err error
+	if planned.before == nil {
+		err = repo.Create(planned.book)
+	} else {
+		err = repo.Update(planned.book)
+	}
+	if err != nil {
+		return err
+	}
+	if run.job.Mode != entity.BookImportAtomic {
+		s.count(run, planned)
+	}
+	return nil
+}
+
+// count adds a row to the created or updated books of the job. Dry runs
+// also count the categories the row would create.
+func (s *bookImportService) count(run *bookImportRun, planned *plannedBook) {
+	if planned.before == nil {
+		run.job.Created++
+	} else {
+		run.job.Updated++
+	}
+
+	if run.job.DryRun {
+		for _, category := range planned.pending {
+			if !run.counted[category] {
+				run.counted[category] = true
+				run.job.CategoriesCreated++
+			}
+		}
+	}
+}
+
+// record writes the audit entry of an imported book
+func (s *bookImportService) record(run *bookImportRun, planned *plannedBook) error {
+	action, before := AuditBookCreated, planned.before
+	if before != nil {
+		action = AuditBookUpdated
+	}
+	return s.audit.record(run.actor, action, auditTargetBook, planned.book.ID, auditDiff(before, bookAuditFields(planned.book)), bookImportDetails(run))
+}
+
+// bookImportDetails links an audit entry to the import that caused it
+func bookImportDetails(run *bookImportRun) entity.AuditDetails {
+	return entity.AuditDetails{"import_id": strconv.FormatUint(uint64(run.job.ID), 10)}
+}
+
+// reject reports a row error and reports whether err was one. Errors other
+// than domain errors, such as a lost database connection, abort the import.
+func (s *bookImportService) reject(run *bookImportRun, line int, isbnValue string, err error) bool {
+	appErr, ok := apperror.As(err)
+	if !ok {
+		return false
+	}
+
+	rowError := entity.BookImportError{ImportID: run.job.ID, Line: line, ISBN: isbnValue}
+	if len(appErr.Fields) == 0 {
+		rowError.Rule = appErr.Code
+		rowError.Message = appErr.Message
+		run.rowErrors = append(run.rowErrors, rowError)
+	}
+	for _, field := range appErr.Fields {
+		rowError.Field = field.Field
+		rowError.Rule = field.Rule
+		rowError.Message = field.Message
+		run.rowErrors = append(run.rowErrors, rowError)
+	}
+	run.job.Failed++
+	return true
+}
+
+// progress saves the row errors and progress every few rows
+func (s *bookImportService) progress(run *bookImportRun) error {
+	if run.job.Processed%bookImportProgressInterval != 0 {
+		return nil
+	}
+	if err := s.flush(run); err != nil {
+		return err
+	}
+	return s.importRepo.Update(run.job)
+}
+
+// flush saves the pending row errors
+func (s *bookImportService) flush(run *bookImportRun) error {
+	if err := s.importRepo.AddErrors(run.rowErrors); err != nil {
+		return err
+	}
+	run.rowErrors = run.rowErrors[:0]
+	return nil
+}
+
+// finish stores the final state of a job
+func (s *bookImportService) finish(job *entity.BookImport, status, message string) {
+	now := time.Now()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &now
+	// A job that cannot be saved is reported as interrupted once stale
+	if err := s.importRepo.Update(job); err != nil {
+		job.Error = err.Error()
+	}
+}
+
+func containsCategory(categories []entity.Category, id uint) bool {
+	for _, category := range categories {
+		if category.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/isbn"
+	"dot-be-go/pkg/marc"
+)
+
+// How subject headings that map to no existing category are handled
+const (
+	UnknownSubjectsSkip   = "skip"
+	UnknownSubjectsCreate = "create"
+	UnknownSubjectsReject = "reject"
+)
+
+// maxImportDescription is the longest description kept from a MARC record.
+// Summaries are often longer than a BookRequest allows.
+const maxImportDescription = 1000
+
+// maxSubjectRuleMatch is the longest pattern of a subject rule
+const maxSubjectRuleMatch = 200
+
+// yearPattern matches a year in a date such as "c2009." or "[1999?]"
+var yearPattern = regexp.MustCompile(`(?:^|\D)(1\d{3}|2\d{3})(?:\D|$)`)
+
+// SubjectRule maps the MARC subject headings matching a pattern to a
+// category. The first matching rule applies.
+type SubjectRule struct {
+	// Match is a case-insensitive pattern of a heading where * matches any
+	// text, e.g. "Computer programming*"
+	Match string `json:"match"`
+	// Category names the category of the matching headings. Empty keeps the
+	// heading as the category name.
+	Category string `json:"category"`
+	// Ignore drops the matching headings
+	Ignore bool `json:"ignore"`
+}
+
+// compiledSubjectRule is a subject rule with its pattern compiled
+type compiledSubjectRule struct {
+	SubjectRule
+	pattern *regexp.Regexp
+}
+
+// compileSubjectRules checks and compiles subject rules
+func compileSubjectRules(rules []SubjectRule) ([]compiledSubjectRule, error) {
+	compiled := make([]compiledSubjectRule, len(rules))
+	for i, rule := range rules {
+		field := fmt.Sprintf("subject_rules[%d].match", i)
+		if strings.TrimSpace(rule.Match) == "" {
+			return nil, apperror.ValidationField(field, "required", "match is required")
+		}
+		if len(rule.Match) > maxSubjectRuleMatch {
+			return nil, apperror.ValidationField(field, "max", fmt.Sprintf("match must be at most %d bytes", maxSubjectRuleMatch))
+		}
+		parts := strings.Split(strings.TrimSpace(rule.Match), "*")
+		for j, part := range parts {
+			parts[j] = regexp.QuoteMeta(part)
+		}
+		pattern, err := regexp.Compile(`(?i)^` + strings.Join(parts, ".*") + `$`)
+		if err != nil {
+			return nil, apperror.ValidationField(field, "pattern", "match is not a valid pattern")
+		}
+		compiled[i] = compiledSubjectRule{SubjectRule: rule, pattern: pattern}
+	}
+	return compiled, nil
+}
+
+// subjectCategory returns the category name a heading maps to, or false
+// when a rule drops it
+func subjectCategory(rules []compiledSubjectRule, heading string) (string, bool) {
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(heading) {
+			continue
+		}
+		if rule.Ignore {
+			return "", false
+		}
+		if rule.Category != "" {
+			return rule.Category, true
+		}
+		break
+	}
+	return heading, true
+}
+
+// readBookImportMARC reads the records of a binary MARC 21 or MARCXML file.
+// The line of a row is the number of its record in the file.
+func readBookImportMARC(reader interface{ Read() (*marc.Record, error) }, maxRows int) ([]bookImportRow, error) {
+	var rows []bookImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+
+		var formatErr *marc.FormatError
+		if err != nil && !errors.As(err, &formatErr) {
+			return nil, apperror.Invalid("invalid_import_file", "the import file is not valid MARC: "+err.Error()).Wrap(err)
+		}
+		if len(rows) == maxRows {
+			return nil, errImportTooLarge(maxRows)
+		}
+
+		row := bookImportRow{line: len(rows) + 1}
+		if formatErr != nil {
+			row.fail("", "marc", formatErr.Error())
+		} else {
+			row.setMARC(record)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// setMARC maps a MARC 21 bibliographic record to the book of the row: 020
+// ISBN, 100 author, 245 title, 264 or 260 publication year, 520 description
+// and 650 subject headings
+func (r *bookImportRow) setMARC(record *marc.Record) {
+	r.book.ISBN = marcISBN(record)
+	r.book.Title = marcTitle(record)
+	r.book.Author = marcAuthor(record)
+	r.book.PublishYear = marcYear(record)
+
+	var summaries []string
+	for _, field := range record.Fields("520") {
+		if summary := strings.TrimSpace(field.Subfield('a')); summary != "" {
+			summaries = append(summaries, summary)
+		}
+	}
+	r.book.Description = truncate(strings.Join(summaries, "\n\n"), maxImportDescription)
+
+	for _, field := range record.Fields("650") {
+		if heading := trimMARCPunctuation(field.Subfield('a')); heading != "" {
+			r.subjects = append(r.subjects, heading)
+		}
+	}
+}
+
+// marcISBN returns the first valid ISBN of the 020 fields, or the first one
+// given when none is valid. Qualifiers such as "(pbk.)" are dropped.
+func marcISBN(record *marc.Record) string {
+	var first string
+	for _, field := range record.Fields("020") {
+		value, _, _ := strings.Cut(strings.TrimSpace(field.Subfield('a')), " ")
+		if value == "" {
+			continue
+		}
+		if isbn.IsValid(value) {
+			return value
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	return first
+}
+
+// marcTitle joins the title and remainder of title of the 245 field
+func marcTitle(record *marc.Record) string {
+	fields := record.Fields("245")
+	if len(fields) == 0 {
+		return ""
+	}
+
+	title := trimMARCPunctuation(fields[0].Subfield('a'))
+	if remainder := trimMARCPunctuation(fields[0].Subfield('b')); remainder != "" {
+		title += ": " + remainder
+	}
+	return title
+}
+
+// marcAuthor returns the main entry personal name, or the corporate or
+// first added personal name when there is none
+func marcAuthor(record *marc.Record) string {
+	for _, tag := range []string{"100", "110", "700"} {
+		for _, field := range record.Fields(tag) {
+			if name := strings.TrimRight(strings.TrimSpace(field.Subfield('a')), " ,"); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// marcYear returns the publication year of the 264 publication statement,
+// the 260 imprint or the 008 fixed length data
+func marcYear(record *marc.Record) int {
+	var dates []string
+	for _, field := range record.Fields("264") {
+		if field.Ind2 == '1' {
+			dates = append(dates, field.Subfield('c'))
+		}
+	}
+	for _, field := range record.Fields("260") {
+		dates = append(dates, field.Subfield('c'))
+	}
+	if fixed := record.Control("008"); len(fixed) >= 11 {
+		dates = append(dates, fixed[7:11])
+	}
+
+	for _, date := range dates {
+		if match := yearPattern.FindStringSubmatch(date); match != nil {
+			year, _ := strconv.Atoi(match[1])
+			return year
+		}
+	}
+	return 0
+}
+
+// trimMARCPunctuation drops the ISBD punctuation ending a subfield, such as
+// the " /" before a statement of responsibility
+func trimMARCPunctuation(value string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), " /:;,.="))
+}
+
+// resolveSubjects maps the subject headings of a row to categories by the
+// subject rules of the import. Headings of categories that do not exist yet
+// are returned as pending categories to create when unknown subjects are
+// created; otherwise they are skipped or reject the row.
+func (s *bookImportService) resolveSubjects(run *bookImportRun, row *bookImportRow) ([]entity.Category, []*entity.Category, error) {
+	var categories []entity.Category
+	var pending []*entity.Category
+	for _, heading := range row.subjects {
+		name, ok := subjectCategory(run.subjectRules, heading)
+		if !ok {
+			continue
+		}
+
+		category, known := run.categories[name]
+		if !known {
+			found, err := s.categoryRepo.FindByName(name)
+			if err != nil && !apperror.IsKind(err, apperror.KindNotFound) {
+				return nil, nil, err
+			}
+			category = found
+			if category == nil && run.unknownSubjects == UnknownSubjectsCreate {
+				if err := s.validator.Validate(&CategoryRequest{Name: name}); err != nil {
+					return nil, nil, apperror.ValidationField("subjects", "category", fmt.Sprintf("subject %q is not a valid category name", heading))
+				}
+				category = &entity.Category{Name: name}
+			}
+			run.categories[name] = category
+		}
+
+		switch {
+		case category == nil && run.unknownSubjects == UnknownSubjectsReject:
+			return nil, nil, apperror.ValidationField("subjects", "exists", fmt.Sprintf("subject %q maps to no category", heading))
+		case category == nil:
+			continue
+		case category.ID == 0:
+			if !containsPending(pending, category) {
+				pending = append(pending, category)
+			}
+		case !containsCategory(categories, category.ID):
+			categories = append(categories, *category)
+		}
+	}
+	return categories, pending, nil
+}
+
+// createPendingCategories creates the categories a row needs that do not
+// exist yet. Pending categories are shared between the rows of a run, so
+// each is created once.
+func (s *bookImportService) createPendingCategories(run *bookImportRun, planned *plannedBook) error {
+	for _, category := range planned.pending {
+		if category.ID == 0 {
+			if err := s.categoryRepo.Create(category); err != nil {
+				return err
+			}
+			run.job.CategoriesCreated++
+			if err := s.audit.record(run.actor, AuditCategoryCreated, auditTargetCategory, category.ID, auditDiff(nil, categoryAuditFields(category)), bookImportDetails(run)); err != nil {
+				return err
+			}
+		}
+		if !containsCategory(planned.book.Categories, category.ID) {
+			planned.book.Categories = append(planned.book.Categories, *category)
+		}
+	}
+	return nil
+}
+
+func containsPending(pending []*entity.Category, category *entity.Category) bool {
+	for _, p := range pending {
+		if p == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/marc"
+)
+
+// Book import file formats
+const (
+	BookImportCSV     = "csv"
+	BookImportNDJSON  = "ndjson"
+	BookImportMARC    = "marc"
+	BookImportMARCXML = "marcxml"
+)
+
+// maxImportLineSize is the longest NDJSON line accepted
+const maxImportLineSize = 1 << 20
+
+// bookImportFields lists the book fields a CSV column can be mapped to.
+// categories holds category names, category_ids category IDs; both take
+// several values separated by semicolons.
+var bookImportFields = []string{"title", "author", "isbn", "publish_year", "description", "category_ids", "categories"}
+
+// requiredImportFields must be mapped to a column of a CSV file
+var requiredImportFields = []string{"title", "author", "isbn", "publish_year"}
+
+// bookImportRow is a row of an import file
+type bookImportRow struct {
+	line          int
+	book          BookRequest
+	categoryNames []string
+	// subjects holds the subject headings of a MARC record
+	subjects    []string
+	parseErrors []entity.BookImportError
+}
+
+// fail records a value of the row that could not be parsed
+func (r *bookImportRow) fail(field, rule, message string) {
+	r.parseErrors = append(r.parseErrors, entity.BookImportError{Field: field, Rule: rule, Message: message})
+}
+
+// readBookImport parses the rows of an import file. Malformed files are
+// rejected as a whole; values that cannot be parsed are reported per row.
+func readBookImport(r io.Reader, format string, mapping map[string]string, maxRows int) ([]bookImportRow, error) {
+	var rows []bookImportRow
+	var err error
+	switch format {
+	case BookImportCSV:
+		rows, err = readBookImportCSV(r, mapping, maxRows)
+	case BookImportNDJSON:
+		rows, err = readBookImportNDJSON(r, maxRows)
+	case BookImportMARC:
+		rows, err = readBookImportMARC(marc.NewReader(r), maxRows)
+	case BookImportMARCXML:
+		rows, err = readBookImportMARC(marc.NewXMLReader(r), maxRows)
+	default:
+		return nil, apperror.ValidationField("format", "oneof", "format must be one of: csv, ndjson, marc, marcxml")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, apperror.Invalid("empty_import_file", "the import file contains no rows")
+	}
+	return rows, nil
+}
+
+// readBookImportCSV parses a CSV file with a header row. Columns are mapped
+// to book fields by mapping, or by their name when it matches a field.
+func readBookImportCSV(r io.Reader, mapping map[string]string, maxRows int) ([]bookImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, invalidImportFile(err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := mapImportColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []bookImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, invalidImportFile(err)
+		}
+		if len(rows) == maxRows {
+			return nil, errImportTooLarge(maxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := bookImportRow{line: line}
+		for field, col := range columns {
+			if col < len(record) {
+				row.set(field, strings.TrimSpace(record[col]))
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// mapImportColumns returns the column index of every mapped book field
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for column, field := range mapping {
+		if !slices.Contains(bookImportFields, field) {
+			return nil, apperror.ValidationField("mapping", "oneof",
+				fmt.Sprintf("column %q is mapped to unknown field %q, use one of: %s", column, field, strings.Join(bookImportFields, ", ")))
+		}
+		if !slices.Contains(header, column) {
+			return nil, apperror.ValidationField("mapping", "exists", fmt.Sprintf("column %q is not in the CSV header", column))
+		}
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		field, ok := mapping[column]
+		if !ok {
+			field = strings.ToLower(strings.TrimSpace(column))
+			if !slices.Contains(bookImportFields, field) {
+				continue
+			}
+		}
+		if _, taken := columns[field]; !taken {
+			columns[field] = i
+		}
+	}
+
+	for _, field := range requiredImportFields {
+		if _, ok := columns[field]; !ok {
+			return nil, apperror.ValidationField("mapping", "required", "no CSV column is mapped to "+field)
+		}
+	}
+	return columns, nil
+}
+
+// set assigns a CSV value to a book field
+func (r *bookImportRow) set(field, value string) {
+	switch field {
+	case "title":
+		r.book.Title = value
+	case "author":
+		r.book.Author = value
+	case "isbn":
+		r.book.ISBN = value
+	case "description":
+		r.book.Description = value
+	case "publish_year":
+		if value == "" {
+			return
+		}
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			r.fail(field, "numeric", "publish_year must be a number")
+			return
+		}
+		r.book.PublishYear = year
+	case "category_ids":
+		for _, part := range splitImportList(value) {
+			id, err := strconv.ParseUint(part, 10, 64)
+			if err != nil {
+				r.fail(field, "numeric", fmt.Sprintf("category ID %q is not a number", part))
+				return
+			}
+			r.book.CategoryIDs = append(r.book.CategoryIDs, uint(id))
+		}
+	case "categories":
+		r.categoryNames = splitImportList(value)
+	}
+}
+
+// readBookImportNDJSON parses one JSON book request per line. Blank lines
+// are skipped.
+func readBookImportNDJSON(r io.Reader, maxRows int) ([]bookImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	var rows []bookImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, errImportTooLarge(maxRows)
+		}
+
+		var record struct {
+			BookRequest
+			Categories []string `json:"categories"`
+		}
+		row := bookImportRow{line: line}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			row.fail("", "json", "invalid JSON: "+err.Error())
+		} else {
+			row.book = record.BookRequest
+			row.categoryNames = record.Categories
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, apperror.Invalid("invalid_import_file", fmt.Sprintf("line %d is longer than %d bytes", line+1, maxImportLineSize))
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+// splitImportList splits a semicolon separated CSV value
+func splitImportList(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func invalidImportFile(err error) error {
+	return apperror.Invalid("invalid_import_file", "the import file is not valid CSV: "+err.Error()).Wrap(err)
+}
+
+func errImportTooLarge(maxRows int) error {
+	return apperror.Invalid("import_too_large", fmt.Sprintf("the import file has more than %d rows", maxRows))
+}
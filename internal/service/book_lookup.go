@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/pkg/booklookup"
+	"dot-be-go/pkg/isbn"
+)
+
+// maxLookupSubjects is the number of subjects of a lookup matched against
+// categories. Some sources list dozens of subjects per book.
+const maxLookupSubjects = 20
+
+// BookLookupRequest represents a metadata lookup by ISBN
+type BookLookupRequest struct {
+	ISBN string `json:"isbn" validate:"required,min=10,max=20"`
+}
+
+// BookLookupResult is the metadata found for an ISBN, shaped like a
+// BookRequest so it can prefill one
+type BookLookupResult struct {
+	ISBN        string `json:"isbn"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	PublishYear int    `json:"publish_year"`
+	Description string `json:"description"`
+	// Subjects lists the subjects given by the source and CategoryIDs the
+	// existing categories named like one of them
+	Subjects    []string `json:"subjects"`
+	CategoryIDs []uint   `json:"category_ids"`
+	Source      string   `json:"source"`
+}
+
+// Lookup finds the metadata of a book by ISBN through the lookup provider
+func (s *bookService) Lookup(isbnValue string) (*BookLookupResult, error) {
+	canonicalISBN, err := isbn.Normalize(isbnValue)
+	if err != nil {
+		return nil, apperror.ValidationField("isbn", "isbn", "invalid ISBN: "+err.Error())
+	}
+	if s.lookup == nil {
+		return nil, apperror.Unavailable("book_lookup_unavailable", "book metadata lookup is not configured")
+	}
+
+	metadata, err := s.lookup.Lookup(context.Background(), canonicalISBN)
+	if errors.Is(err, booklookup.ErrNotFound) {
+		return nil, apperror.NotFound("book_metadata_not_found", "no metadata found for ISBN "+canonicalISBN)
+	}
+	if err != nil {
+		return nil, apperror.Unavailable("book_lookup_unavailable", "the book metadata sources did not answer").Wrap(err)
+	}
+
+	result := &BookLookupResult{
+		ISBN:        canonicalISBN,
+		Title:       truncate(metadata.Title, 255),
+		Author:      truncate(strings.Join(metadata.Authors, ", "), 100),
+		PublishYear: metadata.PublishYear,
+		Description: truncate(metadata.Description, 1000),
+		Subjects:    []string{},
+		CategoryIDs: []uint{},
+		Source:      metadata.Source,
+	}
+	for i, subject := range metadata.Subjects {
+		result.Subjects = append(result.Subjects, subject)
+		if i >= maxLookupSubjects {
+			continue
+		}
+
+		category, err := s.categoryRepo.FindByName(subject)
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result.CategoryIDs, category.ID) {
+			result.CategoryIDs = append(result.CategoryIDs, category.ID)
+		}
+	}
+	return result, nil
+}
+
+// Autofill fills the empty fields of a book request from the metadata of
+// its ISBN; fields given in the request win. Requests with an invalid ISBN
+// or an ISBN no source knows are left as they are for validation to report.
+func (s *bookService) Autofill(req *BookRequest) error {
+	if req.Title != "" && req.Author != "" && req.PublishYear != 0 && req.Description != "" && len(req.CategoryIDs) > 0 {
+		return nil
+	}
+
+	result, err := s.Lookup(req.ISBN)
+	if apperror.IsKind(err, apperror.KindValidation) || apperror.IsKind(err, apperror.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if req.Title == "" {
+		req.Title = result.Title
+	}
+	if req.Author == "" {
+		req.Author = result.Author
+	}
+	if req.PublishYear == 0 {
+		req.PublishYear = result.PublishYear
+	}
+	if req.Description == "" {
+		req.Description = result.Description
+	}
+	if len(req.CategoryIDs) == 0 {
+		req.CategoryIDs = result.CategoryIDs
+	}
+	return nil
+}
@@ -1,10 +1,25 @@
 package service
 
 import (
-	"errors"
+	"fmt"
+	"io"
 
+	"dot-be-go/internal/domain/apperror"
 	"dot-be-go/internal/domain/entity"
 	"dot-be-go/internal/domain/repository"
+	"dot-be-go/pkg/booklookup"
+	"dot-be-go/pkg/highlight"
+	"dot-be-go/pkg/isbn"
+	"dot-be-go/pkg/storage"
+)
+
+const (
+	// exportBatchSize is the number of books loaded at once when streaming
+	exportBatchSize = 200
+	// descriptionSnippetLength is the maximum length of a highlighted description
+	descriptionSnippetLength = 200
+	// isbnBackfillBatchSize is the number of books normalized per batch
+	isbnBackfillBatchSize = 500
 )
 
 // BookRequest represents book request data
@@ -15,64 +30,117 @@ type BookRequest struct {
 	PublishYear int    `json:"publish_year" validate:"required,min=1000,max=9999"`
 	Description string `json:"description" validate:"max=1000"`
 	CategoryIDs []uint `json:"category_ids" validate:"dive,min=1"`
+	// Autofill fills the empty fields from the metadata of the ISBN
+	Autofill bool `json:"autofill"`
+}
+
+// BookSearchResult represents a book matched by a search with its relevance
+// score and highlighted snippets of the matching fields
+type BookSearchResult struct {
+	entity.Book
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// ISBNBackfillIssue describes a stored ISBN that could not be normalized
+type ISBNBackfillIssue struct {
+	BookID uint   `json:"book_id"`
+	ISBN   string `json:"isbn"`
+	Reason string `json:"reason"`
+}
+
+// ISBNBackfillResult summarizes a run of NormalizeISBNs
+type ISBNBackfillResult struct {
+	Scanned int                 `json:"scanned"`
+	Updated int                 `json:"updated"`
+	Failed  []ISBNBackfillIssue `json:"failed"`
 }
 
 // BookService handles book operations
 type BookService interface {
-	Create(userID uint, req *BookRequest) (*entity.Book, error)
-	GetAll(userID uint) ([]entity.Book, error)
+	Create(actor Actor, req *BookRequest) (*entity.Book, error)
+	GetAll(userID uint, filter repository.BookFilter, params repository.ListParams) (*repository.Page[entity.Book], error)
 	GetByID(id uint, userID uint) (*entity.Book, error)
-	Update(id uint, userID uint, req *BookRequest) (*entity.Book, error)
-	Delete(id uint, userID uint) error
-	GetByCategory(categoryID uint) ([]entity.Book, error)
+	Update(actor Actor, id uint, userID uint, req *BookRequest) (*entity.Book, error)
+	Delete(actor Actor, id uint, userID uint) error
+	DeleteByUser(userID uint) error
+	GetByCategory(categoryID uint, filter repository.BookFilter, params repository.ListParams) (*repository.Page[entity.Book], error)
+	Search(userID uint, query string, params repository.ListParams) (*repository.Page[BookSearchResult], error)
+	NormalizeISBNs(actor Actor) (*ISBNBackfillResult, error)
+	Each(userID uint, fn func(book *entity.Book) error) error
+	Lookup(isbn string) (*BookLookupResult, error)
+	Autofill(req *BookRequest) error
+	SetCover(actor Actor, id uint, userID uint, file io.Reader) (*entity.Book, error)
+	DeleteCover(actor Actor, id uint, userID uint) (*entity.Book, error)
 }
 
 type bookService struct {
 	bookRepo     repository.BookRepository
 	categoryRepo repository.CategoryRepository
+	searchRepo   repository.BookSearchRepository
+	audit        *Auditor
+	lookup       booklookup.Provider
+	covers       storage.Storage
+	coverConfig  BookCoverConfig
 }
 
-// NewBookService creates a new book service
-func NewBookService(bookRepo repository.BookRepository, categoryRepo repository.CategoryRepository) BookService {
+// NewBookService creates a new book service. Metadata lookups by ISBN are
+// disabled when lookup is nil, cover uploads when covers is nil.
+func NewBookService(
+	bookRepo repository.BookRepository,
+	categoryRepo repository.CategoryRepository,
+	searchRepo repository.BookSearchRepository,
+	audit *Auditor,
+	lookup booklookup.Provider,
+	covers storage.Storage,
+	coverConfig BookCoverConfig,
+) BookService {
 	return &bookService{
 		bookRepo:     bookRepo,
 		categoryRepo: categoryRepo,
+		searchRepo:   searchRepo,
+		audit:        audit,
+		lookup:       lookup,
+		covers:       covers,
+		coverConfig:  coverConfig,
 	}
 }
 
-// Create creates a new book
-func (s *bookService) Create(userID uint, req *BookRequest) (*entity.Book, error) {
+// Create creates a new book owned by the actor
+func (s *bookService) Create(actor Actor, req *BookRequest) (*entity.Book, error) {
+	canonicalISBN, err := isbn.Normalize(req.ISBN)
+	if err != nil {
+		return nil, apperror.ValidationField("isbn", "isbn", "invalid ISBN: "+err.Error())
+	}
+
+	categories, err := s.findCategories(req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	book := &entity.Book{
 		Title:       req.Title,
 		Author:      req.Author,
-		ISBN:        req.ISBN,
+		ISBN:        canonicalISBN,
 		PublishYear: req.PublishYear,
 		Description: req.Description,
-		UserID:      userID,
-		Categories:  []entity.Category{},
-	}
-
-	// Add categories
-	if len(req.CategoryIDs) > 0 {
-		for _, categoryID := range req.CategoryIDs {
-			category, err := s.categoryRepo.FindByID(categoryID)
-			if err != nil {
-				return nil, errors.New("category not found: " + err.Error())
-			}
-			book.Categories = append(book.Categories, *category)
-		}
+		UserID:      actor.UserID,
+		Categories:  categories,
 	}
 
 	if err := s.bookRepo.Create(book); err != nil {
 		return nil, err
 	}
+	if err := s.audit.record(actor, AuditBookCreated, auditTargetBook, book.ID, auditDiff(nil, bookAuditFields(book)), nil); err != nil {
+		return nil, err
+	}
 
 	return book, nil
 }
 
-// GetAll returns all books for a user
-func (s *bookService) GetAll(userID uint) ([]entity.Book, error) {
-	return s.bookRepo.FindAll(userID)
+// GetAll returns a page of books for a user
+func (s *bookService) GetAll(userID uint, filter repository.BookFilter, params repository.ListParams) (*repository.Page[entity.Book], error) {
+	return s.bookRepo.FindAll(userID, filter, params)
 }
 
 // GetByID returns a book by ID for a specific user
@@ -81,43 +149,202 @@ func (s *bookService) GetByID(id uint, userID uint) (*entity.Book, error) {
 }
 
 // Update updates a book
-func (s *bookService) Update(id uint, userID uint, req *BookRequest) (*entity.Book, error) {
+func (s *bookService) Update(actor Actor, id uint, userID uint, req *BookRequest) (*entity.Book, error) {
+	canonicalISBN, err := isbn.Normalize(req.ISBN)
+	if err != nil {
+		return nil, apperror.ValidationField("isbn", "isbn", "invalid ISBN: "+err.Error())
+	}
+
+	categories, err := s.findCategories(req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	book, err := s.bookRepo.FindByID(id, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	before := bookAuditFields(book)
 	book.Title = req.Title
 	book.Author = req.Author
-	book.ISBN = req.ISBN
+	book.ISBN = canonicalISBN
 	book.PublishYear = req.PublishYear
 	book.Description = req.Description
+	book.Categories = categories
 
-	// Replace categories
-	book.Categories = []entity.Category{}
-	if len(req.CategoryIDs) > 0 {
-		for _, categoryID := range req.CategoryIDs {
-			category, err := s.categoryRepo.FindByID(categoryID)
-			if err != nil {
-				return nil, errors.New("category not found: " + err.Error())
+	if err := s.bookRepo.Update(book); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditBookUpdated, auditTargetBook, book.ID, auditDiff(before, bookAuditFields(book)), nil); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// Delete deletes a book and the blobs of its cover. The blobs go first, so
+// a storage failure leaves the book in place for a retry rather than
+// orphaning them.
+func (s *bookService) Delete(actor Actor, id uint, userID uint) error {
+	book, err := s.bookRepo.FindByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.removeCoverBlobs(book.CoverKeys); err != nil {
+		return err
+	}
+	if err := s.bookRepo.Delete(id, userID); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditBookDeleted, auditTargetBook, book.ID, auditDiff(bookAuditFields(book), nil), nil)
+}
+
+// DeleteByUser removes the covers of every book of a user, then soft
+// deletes the books. When the storage does not answer no book is deleted,
+// so the call can be retried.
+func (s *bookService) DeleteByUser(userID uint) error {
+	if userID == 0 {
+		return apperror.Invalid("invalid_user", "a user is required")
+	}
+	err := s.Each(userID, func(book *entity.Book) error {
+		return s.removeCoverBlobs(book.CoverKeys)
+	})
+	if err != nil {
+		return err
+	}
+	return s.bookRepo.DeleteByUser(userID)
+}
+
+// GetByCategory returns a page of books by category ID
+func (s *bookService) GetByCategory(categoryID uint, filter repository.BookFilter, params repository.ListParams) (*repository.Page[entity.Book], error) {
+	return s.bookRepo.FindByCategory(categoryID, filter, params)
+}
+
+// Search runs a full-text search over the user's books and highlights the matching terms
+func (s *bookService) Search(userID uint, query string, params repository.ListParams) (*repository.Page[BookSearchResult], error) {
+	hits, err := s.searchRepo.Search(userID, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := highlight.Terms(query)
+	results := make([]BookSearchResult, len(hits.Items))
+	for i, hit := range hits.Items {
+		results[i] = BookSearchResult{
+			Book:       hit.Book,
+			Score:      hit.Score,
+			Highlights: map[string]string{},
+		}
+
+		fields := map[string]string{
+			"title":       highlight.Snippet(hit.Book.Title, terms, 0),
+			"author":      highlight.Snippet(hit.Book.Author, terms, 0),
+			"description": highlight.Snippet(hit.Book.Description, terms, descriptionSnippetLength),
+		}
+		for name, snippet := range fields {
+			if snippet != "" {
+				results[i].Highlights[name] = snippet
 			}
-			book.Categories = append(book.Categories, *category)
 		}
 	}
 
-	if err := s.bookRepo.Update(book); err != nil {
-		return nil, err
+	return &repository.Page[BookSearchResult]{
+		Items:   results,
+		Total:   hits.Total,
+		Page:    hits.Page,
+		Limit:   hits.Limit,
+		HasNext: hits.HasNext,
+		HasPrev: hits.HasPrev,
+	}, nil
+}
+
+// NormalizeISBNs rewrites every stored ISBN to its canonical ISBN-13 form.
+// Rows that are invalid or collide with an existing ISBN are left untouched
+// and reported.
+func (s *bookService) NormalizeISBNs(actor Actor) (*ISBNBackfillResult, error) {
+	result := &ISBNBackfillResult{Failed: []ISBNBackfillIssue{}}
+
+	var lastID uint
+	for {
+		books, err := s.bookRepo.FindBatch(lastID, isbnBackfillBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(books) == 0 {
+			return result, nil
+		}
+
+		for _, book := range books {
+			lastID = book.ID
+			result.Scanned++
+
+			canonical, err := isbn.Normalize(book.ISBN)
+			if err != nil {
+				result.Failed = append(result.Failed, ISBNBackfillIssue{BookID: book.ID, ISBN: book.ISBN, Reason: err.Error()})
+				continue
+			}
+			if canonical == book.ISBN {
+				continue
+			}
+
+			if err := s.bookRepo.UpdateISBN(book.ID, canonical); err != nil {
+				result.Failed = append(result.Failed, ISBNBackfillIssue{BookID: book.ID, ISBN: book.ISBN, Reason: err.Error()})
+				continue
+			}
+			changes := entity.AuditChanges{"isbn": {Before: book.ISBN, After: canonical}}
+			if err := s.audit.record(actor, AuditBookUpdated, auditTargetBook, book.ID, changes, nil); err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
 	}
+}
 
-	return book, nil
+// Each calls fn for every book of a user, or of all users when userID is
+// zero, without loading the whole catalog into memory
+func (s *bookService) Each(userID uint, fn func(book *entity.Book) error) error {
+	return s.bookRepo.FindInBatches(userID, exportBatchSize, func(books []entity.Book) error {
+		for i := range books {
+			if err := fn(&books[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// Delete deletes a book
-func (s *bookService) Delete(id uint, userID uint) error {
-	return s.bookRepo.Delete(id, userID)
+// bookAuditFields returns the audited fields of a book
+func bookAuditFields(book *entity.Book) map[string]interface{} {
+	categoryIDs := make([]uint, len(book.Categories))
+	for i, category := range book.Categories {
+		categoryIDs[i] = category.ID
+	}
+
+	return map[string]interface{}{
+		"title":        book.Title,
+		"author":       book.Author,
+		"isbn":         book.ISBN,
+		"publish_year": book.PublishYear,
+		"description":  book.Description,
+		"category_ids": categoryIDs,
+		"user_id":      book.UserID,
+	}
 }
 
-// GetByCategory returns books by category ID
-func (s *bookService) GetByCategory(categoryID uint) ([]entity.Book, error) {
-	return s.bookRepo.FindByCategory(categoryID)
+// findCategories loads the categories referenced by a book request
+func (s *bookService) findCategories(ids []uint) ([]entity.Category, error) {
+	categories := make([]entity.Category, 0, len(ids))
+	for i, categoryID := range ids {
+		category, err := s.categoryRepo.FindByID(categoryID)
+		if err != nil {
+			if apperror.IsKind(err, apperror.KindNotFound) {
+				field := fmt.Sprintf("category_ids[%d]", i)
+				return nil, apperror.ValidationField(field, "exists", fmt.Sprintf("category %d does not exist", categoryID))
+			}
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, nil
 }
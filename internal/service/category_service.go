@@ -1,6 +1,8 @@
 package service
 
 import (
+	"strings"
+
 	"dot-be-go/internal/domain/entity"
 	"dot-be-go/internal/domain/repository"
 )
@@ -11,28 +13,45 @@ type CategoryRequest struct {
 	Description string `json:"description" validate:"max=255"`
 }
 
+// CategoryImportIssue describes a category that could not be imported
+type CategoryImportIssue struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// CategoryImportResult summarizes a category import
+type CategoryImportResult struct {
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Skipped int                   `json:"skipped"`
+	Failed  []CategoryImportIssue `json:"failed"`
+}
+
 // CategoryService handles category operations
 type CategoryService interface {
-	Create(req *CategoryRequest) (*entity.Category, error)
-	GetAll() ([]entity.Category, error)
+	Create(actor Actor, req *CategoryRequest) (*entity.Category, error)
+	GetAll(filter repository.CategoryFilter, params repository.ListParams) (*repository.Page[entity.Category], error)
 	GetByID(id uint) (*entity.Category, error)
-	Update(id uint, req *CategoryRequest) (*entity.Category, error)
-	Delete(id uint) error
+	Update(actor Actor, id uint, req *CategoryRequest) (*entity.Category, error)
+	Delete(actor Actor, id uint) error
+	Import(actor Actor, reqs []CategoryRequest, updateExisting bool) *CategoryImportResult
 }
 
 type categoryService struct {
 	categoryRepo repository.CategoryRepository
+	audit        *Auditor
 }
 
 // NewCategoryService creates a new category service
-func NewCategoryService(categoryRepo repository.CategoryRepository) CategoryService {
+func NewCategoryService(categoryRepo repository.CategoryRepository, audit *Auditor) CategoryService {
 	return &categoryService{
 		categoryRepo: categoryRepo,
+		audit:        audit,
 	}
 }
 
 // Create creates a new category
-func (s *categoryService) Create(req *CategoryRequest) (*entity.Category, error) {
+func (s *categoryService) Create(actor Actor, req *CategoryRequest) (*entity.Category, error) {
 	category := &entity.Category{
 		Name:        req.Name,
 		Description: req.Description,
@@ -41,13 +60,16 @@ func (s *categoryService) Create(req *CategoryRequest) (*entity.Category, error)
 	if err := s.categoryRepo.Create(category); err != nil {
 		return nil, err
 	}
+	if err := s.audit.record(actor, AuditCategoryCreated, auditTargetCategory, category.ID, auditDiff(nil, categoryAuditFields(category)), nil); err != nil {
+		return nil, err
+	}
 
 	return category, nil
 }
 
-// GetAll returns all categories
-func (s *categoryService) GetAll() ([]entity.Category, error) {
-	return s.categoryRepo.FindAll()
+// GetAll returns a page of categories
+func (s *categoryService) GetAll(filter repository.CategoryFilter, params repository.ListParams) (*repository.Page[entity.Category], error) {
+	return s.categoryRepo.FindAll(filter, params)
 }
 
 // GetByID returns a category by ID
@@ -56,16 +78,17 @@ func (s *categoryService) GetByID(id uint) (*entity.Category, error) {
 }
 
 // Update updates a category
-func (s *categoryService) Update(id uint, req *CategoryRequest) (*entity.Category, error) {
+func (s *categoryService) Update(actor Actor, id uint, req *CategoryRequest) (*entity.Category, error) {
 	category, err := s.categoryRepo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	before := categoryAuditFields(category)
 	category.Name = req.Name
 	category.Description = req.Description
 
-	if err := s.categoryRepo.Update(category); err != nil {
+	if err := s.update(actor, category, before); err != nil {
 		return nil, err
 	}
 
@@ -73,6 +96,69 @@ func (s *categoryService) Update(id uint, req *CategoryRequest) (*entity.Categor
 }
 
 // Delete deletes a category
-func (s *categoryService) Delete(id uint) error {
-	return s.categoryRepo.Delete(id)
+func (s *categoryService) Delete(actor Actor, id uint) error {
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.categoryRepo.Delete(id); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditCategoryDeleted, auditTargetCategory, category.ID, auditDiff(categoryAuditFields(category), nil), nil)
+}
+
+// Import creates categories in bulk. Categories whose name already exists
+// are skipped, or have their description replaced when updateExisting is set.
+func (s *categoryService) Import(actor Actor, reqs []CategoryRequest, updateExisting bool) *CategoryImportResult {
+	result := &CategoryImportResult{Failed: []CategoryImportIssue{}}
+
+	for i := range reqs {
+		req := reqs[i]
+		req.Name = strings.TrimSpace(req.Name)
+		if len(req.Name) < 2 || len(req.Name) > 100 {
+			result.Failed = append(result.Failed, CategoryImportIssue{Name: req.Name, Reason: "name must be between 2 and 100 characters long"})
+			continue
+		}
+
+		existing, err := s.categoryRepo.FindByName(req.Name)
+		if err == nil {
+			if !updateExisting {
+				result.Skipped++
+				continue
+			}
+			before := categoryAuditFields(existing)
+			existing.Description = req.Description
+			if err := s.update(actor, existing, before); err != nil {
+				result.Failed = append(result.Failed, CategoryImportIssue{Name: req.Name, Reason: err.Error()})
+				continue
+			}
+			result.Updated++
+			continue
+		}
+
+		if _, err := s.Create(actor, &req); err != nil {
+			result.Failed = append(result.Failed, CategoryImportIssue{Name: req.Name, Reason: err.Error()})
+			continue
+		}
+		result.Created++
+	}
+
+	return result
+}
+
+// update saves a category and records the changes against its audited
+// fields before the change
+func (s *categoryService) update(actor Actor, category *entity.Category, before map[string]interface{}) error {
+	if err := s.categoryRepo.Update(category); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditCategoryUpdated, auditTargetCategory, category.ID, auditDiff(before, categoryAuditFields(category)), nil)
+}
+
+// categoryAuditFields returns the audited fields of a category
+func categoryAuditFields(category *entity.Category) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        category.Name,
+		"description": category.Description,
+	}
 }
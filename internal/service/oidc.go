@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/oidc"
+	"dot-be-go/pkg/token"
+)
+
+// oidcRequestExpiry is how long a user has to sign in at the provider
+const oidcRequestExpiry = 10 * time.Minute
+
+// OIDCAuthorization tells the client where to send the user to sign in
+type OIDCAuthorization struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	ExpireAt         time.Time `json:"expire_at"`
+}
+
+// OIDCCallbackRequest carries the parameters the provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+	Actor Actor  `json:"-"`
+}
+
+// OIDCProviders returns the names of the configured login providers
+func (s *authService) OIDCProviders() []string {
+	names := make([]string, len(s.oidcProviders))
+	for i, provider := range s.oidcProviders {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+// StartOIDCLogin starts an authorization code flow with PKCE. The verifier
+// and nonce stay on the server; only the state travels through the browser.
+func (s *authService) StartOIDCLogin(providerName string) (*OIDCAuthorization, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := token.Generate(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := token.Generate(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("oidc provider %s: %w", provider.Name(), err)
+	}
+
+	request := &entity.OIDCAuthRequest{
+		StateHash:    token.Hash(state),
+		Provider:     provider.Name(),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcRequestExpiry),
+	}
+	if err := s.identityRepo.CreateAuthRequest(request); err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthorization{AuthorizationURL: authURL, ExpireAt: request.ExpiresAt}, nil
+}
+
+// CompleteOIDCLogin redeems the code the provider redirected back with and
+// signs in the linked user. Unknown identities are linked to the user with
+// the same verified email, or to a new user when there is none. Users with
+// two-factor authentication get an MFA challenge as with a password login.
+func (s *authService) CompleteOIDCLogin(providerName string, req *OIDCCallbackRequest) (*AuthResponse, *MFAChallenge, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request, err := s.identityRepo.ConsumeAuthRequest(token.Hash(req.State))
+	if err != nil {
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return nil, nil, errInvalidOIDCState()
+		}
+		return nil, nil, err
+	}
+	if request.Provider != provider.Name() || time.Now().After(request.ExpiresAt) {
+		return nil, nil, errInvalidOIDCState()
+	}
+
+	claims, err := provider.Exchange(context.Background(), req.Code, request.CodeVerifier, request.Nonce)
+	if err != nil {
+		return nil, nil, apperror.Unauthorized("oidc_login_failed", "the login could not be verified with the provider").Wrap(err)
+	}
+
+	user, err := s.userForIdentity(provider.Name(), claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsSuspended() {
+		if err := s.auditLoginFailed(req.Actor, user.Email, user, "account_suspended"); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errAccountSuspended()
+	}
+	if user.HasTwoFactor() {
+		return nil, s.newMFAChallenge(user), nil
+	}
+	if err := s.auditLogin(req.Actor, user, "oidc:"+provider.Name()); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.newSession(user)
+	return resp, nil, err
+}
+
+// ListIdentities returns the external identities linked to a user
+func (s *authService) ListIdentities(userID uint) ([]entity.UserIdentity, error) {
+	return s.identityRepo.FindByUser(userID)
+}
+
+// UnlinkIdentity removes a linked identity. Users without a password must
+// keep at least one identity to sign in with.
+func (s *authService) UnlinkIdentity(actor Actor, id uint) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" {
+		identities, err := s.identityRepo.FindByUser(user.ID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return apperror.Conflict("last_login_method", "set a password before unlinking your only sign-in provider")
+		}
+	}
+	if err := s.identityRepo.Delete(id, user.ID); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditIdentityUnlinked, auditTargetIdentity, id, nil, nil)
+}
+
+// userForIdentity returns the user linked to a provider account, linking or
+// creating one on its first login
+func (s *authService) userForIdentity(providerName string, claims *oidc.Claims) (*entity.User, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(providerName, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLogin(identity.ID, time.Now()); err != nil {
+			return nil, err
+		}
+		return s.userRepo.FindByID(identity.UserID)
+	}
+	if !apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, err
+	}
+
+	// Linking by email is only safe when both sides proved ownership of it
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, apperror.Forbidden("oidc_email_unverified", "the provider did not confirm an email address for this account")
+	}
+
+	user, err := s.userRepo.FindByEmail(claims.Email)
+	switch {
+	case err == nil:
+		if !user.IsEmailVerified() {
+			return nil, apperror.Conflict("account_link_unverified", "verify the email address of the existing account before signing in with this provider")
+		}
+	case apperror.IsKind(err, apperror.KindNotFound):
+		if user, err = s.createOIDCUser(claims); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	identity = &entity.UserIdentity{
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createOIDCUser registers a user without a password for a provider account.
+// A password can be set later through the password reset flow.
+func (s *authService) createOIDCUser(claims *oidc.Claims) (*entity.User, error) {
+	roles, err := resolveRoles(s.roleRepo, []string{entity.RoleUser})
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	now := time.Now()
+	user := &entity.User{
+		Name:            name,
+		Email:           claims.Email,
+		Roles:           roles,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// oidcProvider returns a configured provider by name
+func (s *authService) oidcProvider(name string) (*oidc.Provider, error) {
+	for _, provider := range s.oidcProviders {
+		if provider.Name() == name {
+			return provider, nil
+		}
+	}
+	return nil, apperror.NotFound("oidc_provider_not_found", "unknown login provider "+name)
+}
+
+func errInvalidOIDCState() error {
+	return apperror.Invalid("invalid_oidc_state", "the login request is invalid or has expired")
+}
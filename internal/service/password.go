@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/pkg/breach"
+	"dot-be-go/pkg/hash"
+)
+
+// Passwords hashes passwords and enforces the password policy on new ones
+type Passwords struct {
+	Hasher    *hash.Hasher
+	MinLength int
+	// Breached lists compromised passwords; nil skips the check
+	Breached *breach.List
+}
+
+// hash checks a new password of the account with the given email against
+// the policy and hashes it. Violations are reported on field.
+func (p *Passwords) hash(field, password, email string) (string, error) {
+	if err := p.check(field, password, email); err != nil {
+		return "", err
+	}
+
+	hashed, err := p.Hasher.Hash(password)
+	if errors.Is(err, hash.ErrPasswordTooLong) {
+		return "", apperror.ValidationField(field, "max", "password must be at most 72 bytes long")
+	}
+	return hashed, err
+}
+
+// verify reports whether a password matches a stored hash
+func (p *Passwords) verify(password, hashed string) bool {
+	return p.Hasher.Verify(password, hashed)
+}
+
+// check enforces the password policy
+func (p *Passwords) check(field, password, email string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return apperror.ValidationField(field, "min", fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	if containsEmail(password, email) {
+		return apperror.ValidationField(field, "contains_email", "password must not contain your email address")
+	}
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return apperror.ValidationField(field, "breached", "this password has appeared in a data breach, choose another one")
+		}
+	}
+	return nil
+}
+
+// containsEmail reports whether a password contains an email address or
+// its local part. Local parts shorter than three characters are ignored.
+func containsEmail(password, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	return strings.Contains(password, email) || (len(local) >= 3 && strings.Contains(password, local))
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+	"dot-be-go/pkg/mailer"
+	"dot-be-go/pkg/token"
+)
+
+// passwordResetExpiry is how long a password reset token stays valid
+const passwordResetExpiry = time.Hour
+
+// ForgotPasswordRequest represents password reset request data
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Actor Actor  `json:"-"`
+}
+
+// ResetPasswordRequest represents the data needed to set a new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+	Actor    Actor  `json:"-"`
+}
+
+// ForgotPassword mails a password reset token to the user with the given
+// email. Unknown emails are ignored so callers cannot tell whether an
+// account exists. Every request counts against the email and the client
+// address.
+func (s *authService) ForgotPassword(req *ForgotPasswordRequest) error {
+	if err := s.throttle.check(throttlePasswordReset, req.Email, req.Actor.IP); err != nil {
+		return err
+	}
+	if err := s.throttle.hit(throttlePasswordReset, req.Email, req.Actor.IP); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(req.Email)
+	if err != nil {
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Only the most recent token can be used
+	if err := s.resetRepo.InvalidateForUser(user.ID); err != nil {
+		return err
+	}
+
+	resetToken, err := token.Generate(32)
+	if err != nil {
+		return err
+	}
+
+	record := &entity.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: token.Hash(resetToken),
+		ExpiresAt: time.Now().Add(passwordResetExpiry),
+	}
+	if err := s.resetRepo.Create(record); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Reset your password by sending the token below to POST /api/auth/reset-password.\n\n%s\n\nThe token expires in %s. If you did not ask for a reset, ignore this email.",
+			resetToken, passwordResetExpiry),
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes
+// every session of the user. Invalid tokens are throttled per client address.
+func (s *authService) ResetPassword(req *ResetPasswordRequest) error {
+	if err := s.throttle.check(throttlePasswordReset, "", req.Actor.IP); err != nil {
+		return err
+	}
+
+	record, err := s.resetRepo.FindByHash(token.Hash(req.Token))
+	if err != nil {
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return s.resetFailed(req.Actor.IP)
+		}
+		return err
+	}
+	if record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return s.resetFailed(req.Actor.IP)
+	}
+
+	user, err := s.userRepo.FindByID(record.UserID)
+	if err != nil {
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return errInvalidResetToken()
+		}
+		return err
+	}
+
+	// Checked before the token is consumed so a rejected password can be retried
+	hashedPassword, err := s.passwords.hash("password", req.Password, user.Email)
+	if err != nil {
+		return err
+	}
+
+	// A concurrent request may have consumed the token in the meantime
+	if err := s.resetRepo.MarkUsed(record.ID); err != nil {
+		if errors.Is(err, repository.ErrPasswordResetTokenUsed) {
+			return errInvalidResetToken()
+		}
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+
+	actor := req.Actor
+	actor.UserID = user.ID
+	return s.audit.record(actor, AuditPasswordReset, auditTargetUser, user.ID, nil, nil)
+}
+
+// resetFailed records an invalid reset token and returns the error to report
+func (s *authService) resetFailed(ip string) error {
+	if err := s.throttle.hit(throttlePasswordReset, "", ip); err != nil {
+		return err
+	}
+	return errInvalidResetToken()
+}
+
+func errInvalidResetToken() error {
+	return apperror.Invalid("invalid_reset_token", "invalid or expired password reset token")
+}
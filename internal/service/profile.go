@@ -0,0 +1,244 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/mailer"
+	"dot-be-go/pkg/token"
+)
+
+// emailChangeExpiry is how long an email change confirmation token stays valid
+const emailChangeExpiry = 24 * time.Hour
+
+// Book handling options when an account is deleted
+const (
+	DeleteAccountBooksDelete = "delete"
+	DeleteAccountBooksKeep   = "keep"
+)
+
+// UpdateProfileRequest represents a partial profile update
+type UpdateProfileRequest struct {
+	Name *string `json:"name" validate:"omitempty,min=3,max=100"`
+}
+
+// ChangePasswordRequest represents password change request data
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// ChangeEmailRequest represents email change request data
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ConfirmEmailRequest represents email change confirmation data
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// DeleteAccountRequest represents account deletion request data. Books are
+// deleted with the account unless Books is "keep".
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+	Books    string `json:"books" validate:"omitempty,oneof=delete keep"`
+}
+
+// UpdateProfile updates the name of a user
+func (s *authService) UpdateProfile(actor Actor, req *UpdateProfileRequest) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := map[string]interface{}{"name": user.Name}
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	changes := auditDiff(before, map[string]interface{}{"name": user.Name})
+	if len(changes) > 0 {
+		if err := s.audit.record(actor, AuditProfileUpdated, auditTargetUser, user.ID, changes, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the password of a user and revokes every session
+// except the one making the request
+func (s *authService) ChangePassword(actor Actor, sessionID string, req *ChangePasswordRequest) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmPassword(actor, user, req.CurrentPassword, "current_password"); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwords.hash("new_password", req.NewPassword, user.Email)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeOthersForUser(user.ID, sessionID); err != nil {
+		return err
+	}
+
+	return s.audit.record(actor, AuditPasswordChanged, auditTargetUser, user.ID, nil, nil)
+}
+
+// RequestEmailChange stores the new address as pending and mails a
+// confirmation token to it. The current address is notified.
+func (s *authService) RequestEmailChange(actor Actor, req *ChangeEmailRequest) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmPassword(actor, user, req.Password, "password"); err != nil {
+		return err
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if strings.EqualFold(email, user.Email) {
+		return apperror.ValidationField("email", "changed", "email must differ from the current address")
+	}
+	if _, err := s.userRepo.FindByEmail(email); err == nil {
+		return apperror.Conflict("email_taken", "email already registered")
+	} else if !apperror.IsKind(err, apperror.KindNotFound) {
+		return err
+	}
+
+	confirmToken, err := token.Generate(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(emailChangeExpiry)
+	changes := auditDiff(map[string]interface{}{"pending_email": user.PendingEmail}, map[string]interface{}{"pending_email": email})
+	user.PendingEmail = email
+	user.PendingEmailTokenHash = token.Hash(confirmToken)
+	user.PendingEmailExpiresAt = &expiresAt
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if err := s.audit.record(actor, AuditEmailChangeAsked, auditTargetUser, user.ID, changes, nil); err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Confirm this address by sending the token below to POST /api/profile/email/confirm.\n\n%s\n\nThe token expires in %s.",
+			confirmToken, emailChangeExpiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Email change requested",
+		Body:    fmt.Sprintf("A change of your account email to %s was requested. If this was not you, change your password.", email),
+	})
+}
+
+// ConfirmEmailChange replaces the email of a user with the pending address
+func (s *authService) ConfirmEmailChange(actor Actor, req *ConfirmEmailRequest) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := user.PendingEmail != "" &&
+		user.PendingEmailExpiresAt != nil &&
+		time.Now().Before(*user.PendingEmailExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(user.PendingEmailTokenHash), []byte(token.Hash(req.Token))) == 1
+	if !valid {
+		return nil, apperror.Invalid("invalid_email_token", "invalid or expired email confirmation token")
+	}
+
+	changes := entity.AuditChanges{"email": {Before: user.Email, After: user.PendingEmail}}
+	now := time.Now()
+	user.Email = user.PendingEmail
+	user.EmailVerifiedAt = &now
+	user.PendingEmail = ""
+	user.PendingEmailTokenHash = ""
+	user.PendingEmailExpiresAt = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditEmailChanged, auditTargetUser, user.ID, changes, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteAccount soft deletes a user, optionally with their books, and
+// revokes all of their sessions
+func (s *authService) DeleteAccount(actor Actor, req *DeleteAccountRequest) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmPassword(actor, user, req.Password, "password"); err != nil {
+		return err
+	}
+	if err := ensureAnotherAdmin(s.userRepo, user); err != nil {
+		return err
+	}
+
+	books := DeleteAccountBooksKeep
+	if req.Books != DeleteAccountBooksKeep {
+		if err := s.books.DeleteByUser(user.ID); err != nil {
+			return err
+		}
+		books = DeleteAccountBooksDelete
+	}
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+
+	return s.audit.record(actor, AuditAccountDeleted, auditTargetUser, user.ID, nil, entity.AuditDetails{"books": books})
+}
+
+// confirmPassword checks the current password of a signed in user before a
+// sensitive change. Wrong passwords are throttled per account and client
+// address, so a stolen access token cannot be used to guess the password.
+func (s *authService) confirmPassword(actor Actor, user *entity.User, password, field string) error {
+	if err := s.throttle.check(throttlePassword, user.Email, actor.IP); err != nil {
+		return err
+	}
+	if !s.passwords.verify(password, user.Password) {
+		if err := s.throttle.hit(throttlePassword, user.Email, actor.IP); err != nil {
+			return err
+		}
+		return errWrongPassword(field)
+	}
+	return s.throttle.reset(throttlePassword, user.Email)
+}
+
+func errWrongPassword(field string) error {
+	return apperror.ValidationField(field, "password", "password is incorrect")
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+)
+
+// RoleRequest represents role request data
+type RoleRequest struct {
+	Name             string   `json:"name" validate:"required,min=2,max=50"`
+	Description      string   `json:"description" validate:"max=255"`
+	Permissions      []string `json:"permissions" validate:"dive,required"`
+	RequireTwoFactor bool     `json:"require_two_factor"`
+}
+
+// AssignRolesRequest represents the roles to assign to a user
+type AssignRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,min=1,dive,required"`
+}
+
+// RoleService handles role, permission and role assignment operations
+type RoleService interface {
+	Create(actor Actor, req *RoleRequest) (*entity.Role, error)
+	GetAll() ([]entity.Role, error)
+	GetByID(id uint) (*entity.Role, error)
+	Update(actor Actor, id uint, req *RoleRequest) (*entity.Role, error)
+	Delete(actor Actor, id uint) error
+	GetPermissions() ([]entity.Permission, error)
+	GetUserRoles(userID uint) ([]entity.Role, error)
+	AssignRoles(actor Actor, userID uint, names []string) (*entity.User, error)
+}
+
+type roleService struct {
+	roleRepo  repository.RoleRepository
+	userRepo  repository.UserRepository
+	tokenRepo repository.RefreshTokenRepository
+	audit     *Auditor
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, audit *Auditor) RoleService {
+	return &roleService{
+		roleRepo:  roleRepo,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		audit:     audit,
+	}
+}
+
+// Create creates a new role
+func (s *roleService) Create(actor Actor, req *RoleRequest) (*entity.Role, error) {
+	permissions, err := s.resolvePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &entity.Role{
+		Name:             strings.TrimSpace(req.Name),
+		Description:      req.Description,
+		Permissions:      permissions,
+		RequireTwoFactor: req.RequireTwoFactor,
+	}
+
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditRoleCreated, auditTargetRole, role.ID, auditDiff(nil, roleAuditFields(role)), nil); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// GetAll returns every role
+func (s *roleService) GetAll() ([]entity.Role, error) {
+	return s.roleRepo.FindAll()
+}
+
+// GetByID returns a role by ID
+func (s *roleService) GetByID(id uint) (*entity.Role, error) {
+	return s.roleRepo.FindByID(id)
+}
+
+// Update updates a role and replaces its permissions. Built-in roles cannot
+// be renamed and the admin role always keeps every permission. Removing a
+// permission or requiring 2FA revokes the sessions of the role's users so
+// the change applies immediately, like AssignRoles; added permissions apply
+// from the next token refresh.
+func (s *roleService) Update(actor Actor, id uint, req *RoleRequest) (*entity.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if role.IsSystem() && name != role.Name {
+		return nil, apperror.Conflict("system_role", "built-in roles cannot be renamed")
+	}
+
+	permissions, err := s.resolvePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if role.Name == entity.RoleAdmin {
+		if permissions, err = s.roleRepo.FindAllPermissions(); err != nil {
+			return nil, err
+		}
+	}
+
+	before := roleAuditFields(role)
+	revoke := removesPermission(role.Permissions, permissions) || (req.RequireTwoFactor && !role.RequireTwoFactor)
+	role.Name = name
+	role.Description = req.Description
+	role.Permissions = permissions
+	role.RequireTwoFactor = req.RequireTwoFactor
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	if revoke {
+		if err := s.tokenRepo.RevokeAllForRole(role.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.audit.record(actor, AuditRoleUpdated, auditTargetRole, role.ID, auditDiff(before, roleAuditFields(role)), nil); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// Delete deletes a custom role and removes it from every user, revoking
+// their sessions so they lose its permissions immediately
+func (s *roleService) Delete(actor Actor, id uint) error {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem() {
+		return apperror.Conflict("system_role", "built-in roles cannot be deleted")
+	}
+
+	if err := s.tokenRepo.RevokeAllForRole(role.ID); err != nil {
+		return err
+	}
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditRoleDeleted, auditTargetRole, role.ID, auditDiff(roleAuditFields(role), nil), nil)
+}
+
+// GetPermissions returns every known permission
+func (s *roleService) GetPermissions() ([]entity.Permission, error) {
+	return s.roleRepo.FindAllPermissions()
+}
+
+// GetUserRoles returns the roles assigned to a user
+func (s *roleService) GetUserRoles(userID uint) ([]entity.Role, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Roles, nil
+}
+
+// AssignRoles replaces the roles of a user and revokes their sessions so the
+// new permissions apply immediately. The last admin cannot lose the admin role.
+func (s *roleService) AssignRoles(actor Actor, userID uint, names []string) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := resolveRoles(s.roleRepo, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsRole(roles, entity.RoleAdmin) {
+		if err := ensureAnotherAdmin(s.userRepo, user); err != nil {
+			return nil, err
+		}
+	}
+
+	before := sortedRoleNames(user.Roles)
+	if err := s.roleRepo.ReplaceUserRoles(user, roles); err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	changes := auditDiff(map[string]interface{}{"roles": before}, map[string]interface{}{"roles": sortedRoleNames(roles)})
+	if err := s.audit.record(actor, AuditUserRolesAssigned, auditTargetUser, user.ID, changes, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// removesPermission reports whether a permission of before is missing from after
+func removesPermission(before, after []entity.Permission) bool {
+	kept := make(map[uint]bool, len(after))
+	for _, permission := range after {
+		kept[permission.ID] = true
+	}
+	for _, permission := range before {
+		if !kept[permission.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePermissions loads permissions by name and rejects unknown ones
+func (s *roleService) resolvePermissions(names []string) ([]entity.Permission, error) {
+	permissions := []entity.Permission{}
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	found, err := s.roleRepo.FindPermissionsByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]entity.Permission, len(found))
+	for _, permission := range found {
+		byName[permission.Name] = permission
+	}
+	for _, name := range names {
+		if _, ok := byName[name]; !ok {
+			return nil, apperror.ValidationField("permissions", "exists", "unknown permission: "+name)
+		}
+	}
+
+	return append(permissions, found...), nil
+}
+
+// resolveRoles loads roles by name and rejects unknown ones
+func resolveRoles(roleRepo repository.RoleRepository, names []string) ([]entity.Role, error) {
+	roles, err := roleRepo.FindByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if !containsRole(roles, name) {
+			return nil, apperror.ValidationField("roles", "exists", "unknown role: "+name)
+		}
+	}
+
+	return roles, nil
+}
+
+// roleAuditFields returns the fields of a role recorded in the audit log
+func roleAuditFields(role *entity.Role) map[string]interface{} {
+	permissions := make([]string, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = permission.Name
+	}
+	sort.Strings(permissions)
+
+	return map[string]interface{}{
+		"name":               role.Name,
+		"description":        role.Description,
+		"permissions":        permissions,
+		"require_two_factor": role.RequireTwoFactor,
+	}
+}
+
+func sortedRoleNames(roles []entity.Role) []string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsRole(roles []entity.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/pkg/throttle"
+)
+
+// Scopes keep the attempt counts of different endpoints apart
+const (
+	throttleLogin         = "login"
+	throttleRegister      = "register"
+	throttlePasswordReset = "password_reset"
+	throttleVerification  = "verification"
+	// throttlePassword counts wrong current passwords of signed in users
+	throttlePassword = "password"
+)
+
+// AuthThrottle guards the auth endpoints against brute force. Account
+// counts attempts per email address, IP per client address.
+type AuthThrottle struct {
+	Account *throttle.Limiter
+	IP      *throttle.Limiter
+}
+
+// check fails with a rate limited error while the account or the IP is
+// blocked in a scope. Empty values are not checked.
+func (t *AuthThrottle) check(scope, email, ip string) error {
+	var wait time.Duration
+	if email != "" {
+		accountWait, err := t.Account.Check(accountKey(scope, email))
+		if err != nil {
+			return err
+		}
+		wait = accountWait
+	}
+	if ip != "" {
+		ipWait, err := t.IP.Check(ipKey(scope, ip))
+		if err != nil {
+			return err
+		}
+		wait = max(wait, ipWait)
+	}
+
+	if wait > 0 {
+		return apperror.RateLimited("too_many_attempts", "too many attempts, try again later", wait)
+	}
+	return nil
+}
+
+// hit records an attempt for the account and the IP in a scope. Empty
+// values are not recorded.
+func (t *AuthThrottle) hit(scope, email, ip string) error {
+	if email != "" {
+		if _, err := t.Account.Hit(accountKey(scope, email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if _, err := t.IP.Hit(ipKey(scope, ip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlock forgets the failed logins of an account
+func (t *AuthThrottle) unlock(email string) error {
+	return t.reset(throttleLogin, email)
+}
+
+// reset forgets the attempts of an account in a scope
+func (t *AuthThrottle) reset(scope, email string) error {
+	return t.Account.Reset(accountKey(scope, email))
+}
+
+func accountKey(scope, email string) string {
+	return scope + ":account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(scope, ip string) string {
+	return scope + ":ip:" + ip
+}
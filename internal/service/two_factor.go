@@ -0,0 +1,364 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+	"dot-be-go/pkg/token"
+	"dot-be-go/pkg/totp"
+)
+
+const (
+	// mfaChallengeExpiry is how long a login may take to supply its second factor
+	mfaChallengeExpiry = 5 * time.Minute
+	// mfaTokenPrefix keeps challenge tokens from being mistaken for other
+	// payloads signed with the same secret
+	mfaTokenPrefix = "mfa"
+	// recoveryCodeCount is the number of recovery codes issued at once
+	recoveryCodeCount = 10
+)
+
+// MFAChallenge is returned by Login instead of tokens when the user has
+// two-factor authentication enabled
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpireAt    time.Time `json:"expire_at"`
+}
+
+// MFALoginRequest represents the second step of a two-factor login
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+	Actor    Actor  `json:"-"`
+}
+
+// TwoFactorSetup holds the secret of a pending TOTP enrolment
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	// QRCode is the URI as a PNG data URI
+	QRCode string `json:"qr_code"`
+}
+
+// TwoFactorCodeRequest represents a request authorized by a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorRequest represents two-factor deactivation data. Code may
+// be a TOTP code or a recovery code.
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// RecoveryCodesResponse lists freshly issued recovery codes. They are only
+// shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SetupTwoFactor generates a TOTP secret for the user. It only takes effect
+// once confirmed with a code.
+func (s *authService) SetupTwoFactor(userID uint) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.HasTwoFactor() {
+		return nil, errTwoFactorEnabled()
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.issuer, user.Email, secret)
+	png, err := totp.QRCode(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication with a first code and
+// issues recovery codes
+func (s *authService) ConfirmTwoFactor(actor Actor, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.HasTwoFactor() {
+		return nil, errTwoFactorEnabled()
+	}
+	if user.TOTPSecret == "" {
+		return nil, apperror.Conflict("two_factor_not_set_up", "two-factor authentication has not been set up")
+	}
+
+	counter, ok := totp.Validate(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, errInvalidTwoFactorCode()
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	user.TOTPLastCounter = counter
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditTwoFactorEnabled, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user
+func (s *authService) RegenerateRecoveryCodes(actor Actor, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasTwoFactor() {
+		return nil, errTwoFactorDisabled()
+	}
+
+	ok, err := s.verifyTOTP(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errInvalidTwoFactorCode()
+	}
+	if err := s.audit.record(actor, AuditRecoveryCodesNew, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// DisableTwoFactor turns two-factor authentication off unless a role of the
+// user requires it
+func (s *authService) DisableTwoFactor(actor Actor, req *DisableTwoFactorRequest) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.HasTwoFactor() {
+		return errTwoFactorDisabled()
+	}
+	if user.RequiresTwoFactor() {
+		return apperror.Conflict("two_factor_required", "two-factor authentication is required by your role")
+	}
+	if err := s.confirmPassword(actor, user, req.Password, "password"); err != nil {
+		return err
+	}
+
+	ok, err := s.verifySecondFactor(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidTwoFactorCode()
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastCounter = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if err := s.recoveryRepo.DeleteForUser(user.ID); err != nil {
+		return err
+	}
+
+	return s.audit.record(actor, AuditTwoFactorDisabled, auditTargetUser, user.ID, nil, nil)
+}
+
+// CompleteMFALogin finishes a login with the second factor, a TOTP code or a
+// recovery code. Wrong codes count as failed logins of the account.
+func (s *authService) CompleteMFALogin(req *MFALoginRequest) (*AuthResponse, error) {
+	userID, err := s.parseMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if apperror.IsKind(err, apperror.KindNotFound) {
+			return nil, errInvalidMFAToken()
+		}
+		return nil, err
+	}
+	if !user.HasTwoFactor() {
+		return nil, errInvalidMFAToken()
+	}
+	if user.IsSuspended() {
+		if err := s.auditLoginFailed(req.Actor, user.Email, user, "account_suspended"); err != nil {
+			return nil, err
+		}
+		return nil, errAccountSuspended()
+	}
+	if err := s.throttle.check(throttleLogin, user.Email, req.Actor.IP); err != nil {
+		if auditErr := s.auditLoginFailed(req.Actor, user.Email, user, "too_many_attempts"); auditErr != nil {
+			return nil, auditErr
+		}
+		return nil, err
+	}
+
+	ok, err := s.verifySecondFactor(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.auditLoginFailed(req.Actor, user.Email, user, "invalid_two_factor_code"); err != nil {
+			return nil, err
+		}
+		if err := s.throttle.hit(throttleLogin, user.Email, req.Actor.IP); err != nil {
+			return nil, err
+		}
+		return nil, apperror.Unauthorized("invalid_two_factor_code", "invalid two-factor authentication code")
+	}
+	if err := s.throttle.unlock(user.Email); err != nil {
+		return nil, err
+	}
+	if err := s.auditLogin(req.Actor, user, "two_factor"); err != nil {
+		return nil, err
+	}
+
+	return s.newSession(user)
+}
+
+// newMFAChallenge signs a short-lived token that stands in for the password
+// during the second login step
+func (s *authService) newMFAChallenge(user *entity.User) *MFAChallenge {
+	expiresAt := time.Now().Add(mfaChallengeExpiry)
+	payload := strings.Join([]string{
+		mfaTokenPrefix,
+		strconv.FormatUint(uint64(user.ID), 10),
+		strconv.FormatInt(expiresAt.Unix(), 10),
+	}, ":")
+
+	return &MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token.Sign(payload, s.jwtSecret),
+		ExpireAt:    expiresAt,
+	}
+}
+
+// parseMFAToken checks the signature and expiry of a challenge token and
+// returns the user it was issued for
+func (s *authService) parseMFAToken(mfaToken string) (uint, error) {
+	payload, err := token.Verify(mfaToken, s.jwtSecret)
+	if err != nil {
+		return 0, errInvalidMFAToken()
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != mfaTokenPrefix {
+		return 0, errInvalidMFAToken()
+	}
+	userID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, errInvalidMFAToken()
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, errInvalidMFAToken()
+	}
+
+	return uint(userID), nil
+}
+
+// verifyTOTP accepts a current TOTP code of the user. Each code is accepted
+// only once.
+func (s *authService) verifyTOTP(user *entity.User, code string) (bool, error) {
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok || counter <= user.TOTPLastCounter {
+		return false, nil
+	}
+
+	// A concurrent request may have used the same code in the meantime
+	if err := s.userRepo.AdvanceTOTPCounter(user.ID, counter); err != nil {
+		if errors.Is(err, repository.ErrTOTPCodeReused) {
+			return false, nil
+		}
+		return false, err
+	}
+	user.TOTPLastCounter = counter
+	return true, nil
+}
+
+// verifySecondFactor accepts a TOTP code or consumes a recovery code
+func (s *authService) verifySecondFactor(user *entity.User, code string) (bool, error) {
+	if ok, err := s.verifyTOTP(user, code); ok || err != nil {
+		return ok, err
+	}
+
+	err := s.recoveryRepo.Use(user.ID, token.Hash(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrRecoveryCodeInvalid) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// issueRecoveryCodes replaces the recovery codes of a user with new ones
+func (s *authService) issueRecoveryCodes(userID uint) (*RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		id, err := token.GenerateID(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = id[:5] + "-" + id[5:]
+		hashes[i] = token.Hash(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces in recovery codes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func errTwoFactorEnabled() error {
+	return apperror.Conflict("two_factor_enabled", "two-factor authentication is already enabled")
+}
+
+func errTwoFactorDisabled() error {
+	return apperror.Conflict("two_factor_disabled", "two-factor authentication is not enabled")
+}
+
+func errInvalidTwoFactorCode() error {
+	return apperror.ValidationField("code", "totp", "invalid two-factor authentication code")
+}
+
+func errInvalidMFAToken() error {
+	return apperror.Unauthorized("invalid_mfa_token", "invalid or expired two-factor login token")
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"dot-be-go/internal/domain/apperror"
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/internal/domain/repository"
+)
+
+// CreateUserRequest represents data for creating a user on behalf of an operator
+type CreateUserRequest struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Name     string   `json:"name" validate:"required,min=3,max=100"`
+	Password string   `json:"password" validate:"required,min=6"`
+	Roles    []string `json:"roles" validate:"required,min=1,dive,required"`
+}
+
+// UserService handles user management operations
+type UserService interface {
+	Create(actor Actor, req *CreateUserRequest) (*entity.User, error)
+	ResetPassword(actor Actor, email string, password string) (*entity.User, error)
+	GetAll(filter repository.UserFilter, params repository.ListParams) (*repository.Page[entity.User], error)
+	GetByID(id uint) (*entity.User, error)
+	Suspend(actor Actor, id uint) (*entity.User, error)
+	Unsuspend(actor Actor, id uint) (*entity.User, error)
+	Delete(actor Actor, id uint) error
+	Restore(actor Actor, id uint) (*entity.User, error)
+	Unlock(actor Actor, id uint) (*entity.User, error)
+}
+
+type userService struct {
+	userRepo  repository.UserRepository
+	roleRepo  repository.RoleRepository
+	tokenRepo repository.RefreshTokenRepository
+	throttle  *AuthThrottle
+	passwords *Passwords
+	audit     *Auditor
+}
+
+// NewUserService creates a new user service
+func NewUserService(
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	tokenRepo repository.RefreshTokenRepository,
+	throttle *AuthThrottle,
+	passwords *Passwords,
+	audit *Auditor,
+) UserService {
+	return &userService{
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		tokenRepo: tokenRepo,
+		throttle:  throttle,
+		passwords: passwords,
+		audit:     audit,
+	}
+}
+
+// Create creates a user with the given roles
+func (s *userService) Create(actor Actor, req *CreateUserRequest) (*entity.User, error) {
+	roles, err := resolveRoles(s.roleRepo, req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindByEmail(req.Email); err == nil {
+		return nil, apperror.Conflict("email_taken", "email already registered")
+	} else if !apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, err
+	}
+
+	hashedPassword, err := s.passwords.hash("password", req.Password, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operators vouch for the addresses of the accounts they create
+	now := time.Now()
+	user := &entity.User{
+		Name:            req.Name,
+		Email:           req.Email,
+		Password:        hashedPassword,
+		Roles:           roles,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	details := entity.AuditDetails{"email": user.Email, "roles": strings.Join(user.RoleNames(), " ")}
+	if err := s.audit.record(actor, AuditUserCreated, auditTargetUser, user.ID, nil, details); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResetPassword sets a new password and revokes every session of the user
+func (s *userService) ResetPassword(actor Actor, email string, password string) (*entity.User, error) {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.passwords.hash("password", password, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditUserPasswordSet, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetAll returns a page of users
+func (s *userService) GetAll(filter repository.UserFilter, params repository.ListParams) (*repository.Page[entity.User], error) {
+	return s.userRepo.FindAll(filter, params)
+}
+
+// GetByID returns a user by ID, including soft deleted ones
+func (s *userService) GetByID(id uint) (*entity.User, error) {
+	return s.userRepo.FindByIDWithDeleted(id)
+}
+
+// Suspend blocks a user from logging in and revokes all of their sessions
+func (s *userService) Suspend(actor Actor, id uint) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSuspended() {
+		return user, nil
+	}
+	if err := s.ensureCanDisable(actor.UserID, user); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.SuspendedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditUserSuspended, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Unsuspend allows a suspended user to log in again
+func (s *userService) Unsuspend(actor Actor, id uint) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsSuspended() {
+		return user, nil
+	}
+
+	user.SuspendedAt = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditUserUnsuspended, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Delete soft deletes a user and revokes all of their sessions
+func (s *userService) Delete(actor Actor, id uint) error {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureCanDisable(actor.UserID, user); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	if err := s.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+	return s.audit.record(actor, AuditUserDeleted, auditTargetUser, user.ID, nil, nil)
+}
+
+// Restore undoes the soft deletion of a user
+func (s *userService) Restore(actor Actor, id uint) (*entity.User, error) {
+	if err := s.userRepo.Restore(id); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditUserRestored, auditTargetUser, id, nil, nil); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(id)
+}
+
+// Unlock clears the failed logins of a user so a locked out account can log
+// in again at once
+func (s *userService) Unlock(actor Actor, id uint) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.throttle.unlock(user.Email); err != nil {
+		return nil, err
+	}
+	if err := s.audit.record(actor, AuditUserUnlocked, auditTargetUser, user.ID, nil, nil); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ensureCanDisable rejects suspending or deleting one's own account or the last admin
+func (s *userService) ensureCanDisable(actorID uint, user *entity.User) error {
+	if user.ID == actorID {
+		return apperror.Conflict("self_action", "you cannot suspend or delete your own account")
+	}
+	return ensureAnotherAdmin(s.userRepo, user)
+}
+
+// ensureAnotherAdmin rejects changes that would leave no active admin when
+// user is currently one
+func ensureAnotherAdmin(userRepo repository.UserRepository, user *entity.User) error {
+	if !user.HasRole(entity.RoleAdmin) || user.IsSuspended() {
+		return nil
+	}
+
+	admins, err := userRepo.CountByRole(entity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return apperror.Conflict("last_admin", "this change would leave no active admin")
+	}
+	return nil
+}
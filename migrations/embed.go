@@ -0,0 +1,11 @@
+// Package migrations embeds the versioned SQL migrations for every supported database driver.
+package migrations
+
+import (
+	"embed"
+)
+
+// FS contains one directory of migrations per database driver
+//
+//go:embed postgres/*.sql mysql/*.sql
+var FS embed.FS
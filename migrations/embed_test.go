@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"testing"
+
+	"dot-be-go/pkg/migrate"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDriversInSync makes sure every migration exists for every driver
+func TestDriversInSync(t *testing.T) {
+	postgres, err := migrate.Load(FS, "postgres")
+	assert.NoError(t, err)
+	mysql, err := migrate.Load(FS, "mysql")
+	assert.NoError(t, err)
+
+	assert.Equal(t, len(postgres), len(mysql))
+	for i := range postgres {
+		if i >= len(mysql) {
+			break
+		}
+		assert.Equal(t, postgres[i].Version, mysql[i].Version)
+		assert.Equal(t, postgres[i].Name, mysql[i].Name)
+		assert.NotEmpty(t, postgres[i].Down, "postgres %d has no down script", postgres[i].Version)
+		assert.NotEmpty(t, mysql[i].Down, "mysql %d has no down script", mysql[i].Version)
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS book_categories;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
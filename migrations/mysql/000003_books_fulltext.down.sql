@@ -0,0 +1 @@
+ALTER TABLE books DROP INDEX idx_books_fulltext;
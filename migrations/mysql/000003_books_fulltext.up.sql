@@ -0,0 +1 @@
+ALTER TABLE books ADD FULLTEXT INDEX idx_books_fulltext (title, author, description);
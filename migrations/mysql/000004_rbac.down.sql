@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(20) DEFAULT 'user';
+
+UPDATE users SET role = 'admin' WHERE id IN (
+    SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = 'admin'
+);
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS permissions;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN pending_email_expires_at;
+ALTER TABLE users DROP COLUMN pending_email_token_hash;
+ALTER TABLE users DROP COLUMN pending_email;
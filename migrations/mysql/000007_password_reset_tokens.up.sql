@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_password_reset_tokens_user_id (user_id),
+    UNIQUE INDEX idx_password_reset_tokens_token_hash (token_hash),
+    CONSTRAINT fk_password_reset_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
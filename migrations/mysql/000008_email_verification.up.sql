@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME(3) NULL;
+UPDATE users SET email_verified_at = created_at;
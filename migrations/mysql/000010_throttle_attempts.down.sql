@@ -0,0 +1 @@
+DROP TABLE IF EXISTS throttle_attempts;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS throttle_attempts (
+    throttle_key VARCHAR(191) PRIMARY KEY,
+    attempts INT NOT NULL,
+    last_attempt_at DATETIME(3) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    INDEX idx_throttle_attempts_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    last_login_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_user_identities_provider_subject (provider, subject),
+    INDEX idx_user_identities_user_id (user_id),
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    state_hash VARCHAR(64) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_oidc_auth_requests_state_hash (state_hash),
+    INDEX idx_oidc_auth_requests_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT UNSIGNED NULL,
+    ip VARCHAR(45) NULL,
+    user_agent VARCHAR(255) NULL,
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NULL,
+    target_id BIGINT UNSIGNED NULL,
+    changes TEXT NULL,
+    details TEXT NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_audit_logs_actor_id (actor_id),
+    INDEX idx_audit_logs_action (action),
+    INDEX idx_audit_logs_target (target_type, target_id),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Entries can only be appended; retention is handled with TRUNCATE or by
+-- dropping the triggers
+CREATE TRIGGER audit_logs_no_update BEFORE UPDATE ON audit_logs FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
+CREATE TRIGGER audit_logs_no_delete BEFORE DELETE ON audit_logs FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
+
+INSERT INTO permissions (name, description, created_at, updated_at) VALUES
+    ('audit:read', 'Read and export the audit log', NOW(3), NOW(3));
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'audit:read' WHERE r.name = 'admin';
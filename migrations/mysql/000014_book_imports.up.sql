@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS book_imports (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    upsert BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL,
+    total_rows INT NOT NULL DEFAULT 0,
+    processed_rows INT NOT NULL DEFAULT 0,
+    created_count INT NOT NULL DEFAULT 0,
+    updated_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at DATETIME(3) NULL,
+    finished_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_book_imports_user_id (user_id),
+    CONSTRAINT fk_book_imports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS book_import_errors (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    import_id BIGINT UNSIGNED NOT NULL,
+    line INT NOT NULL,
+    isbn VARCHAR(32) NULL,
+    field VARCHAR(64) NULL,
+    rule VARCHAR(32) NULL,
+    message TEXT NOT NULL,
+    INDEX idx_book_import_errors_import_id (import_id),
+    CONSTRAINT fk_book_import_errors_import FOREIGN KEY (import_id) REFERENCES book_imports (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
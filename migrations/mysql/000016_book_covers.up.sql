@@ -0,0 +1,2 @@
+ALTER TABLE books ADD COLUMN cover TEXT NULL;
+ALTER TABLE books ADD COLUMN cover_keys TEXT NULL;
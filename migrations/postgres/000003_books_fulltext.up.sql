@@ -0,0 +1,6 @@
+-- Must match postgresSearchVector in the book search repository
+CREATE INDEX IF NOT EXISTS idx_books_fulltext ON books USING GIN (
+    (setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'C'))
+);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN pending_email VARCHAR(100);
+ALTER TABLE users ADD COLUMN pending_email_token_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN pending_email_expires_at TIMESTAMPTZ;
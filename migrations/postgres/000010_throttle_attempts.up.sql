@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS throttle_attempts (
+    throttle_key VARCHAR(191) PRIMARY KEY,
+    attempts INTEGER NOT NULL,
+    last_attempt_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_throttle_attempts_expires_at ON throttle_attempts (expires_at);
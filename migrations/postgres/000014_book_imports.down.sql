@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_import_errors;
+DROP TABLE IF EXISTS book_imports;
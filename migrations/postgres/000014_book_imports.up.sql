@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS book_imports (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    upsert BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL,
+    total_rows INT NOT NULL DEFAULT 0,
+    processed_rows INT NOT NULL DEFAULT 0,
+    created_count INT NOT NULL DEFAULT 0,
+    updated_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    CONSTRAINT fk_book_imports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_book_imports_user_id ON book_imports (user_id);
+
+CREATE TABLE IF NOT EXISTS book_import_errors (
+    id BIGSERIAL PRIMARY KEY,
+    import_id BIGINT NOT NULL,
+    line INT NOT NULL,
+    isbn VARCHAR(32),
+    field VARCHAR(64),
+    rule VARCHAR(32),
+    message TEXT NOT NULL,
+    CONSTRAINT fk_book_import_errors_import FOREIGN KEY (import_id) REFERENCES book_imports (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_book_import_errors_import_id ON book_import_errors (import_id);
@@ -0,0 +1 @@
+ALTER TABLE book_imports DROP COLUMN categories_created;
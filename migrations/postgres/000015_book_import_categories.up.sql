@@ -0,0 +1 @@
+ALTER TABLE book_imports ADD COLUMN categories_created INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE books ADD COLUMN cover TEXT;
+ALTER TABLE books ADD COLUMN cover_keys TEXT;
@@ -0,0 +1,203 @@
+// Package booklookup finds the bibliographic metadata of a book by its ISBN
+// through pluggable providers: HTTP APIs such as Open Library and Google
+// Books, and fixtures for tests. Providers can be chained, so fields one
+// source lacks are filled from the next, and cached.
+package booklookup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ErrNotFound is returned when a provider knows no book with the ISBN
+var ErrNotFound = errors.New("booklookup: no book found for the ISBN")
+
+// maxResponseSize bounds the responses read from providers
+const maxResponseSize = 1 << 20
+
+var yearPattern = regexp.MustCompile(`(?:^|\D)(1\d{3}|2\d{3})(?:\D|$)`)
+
+// Metadata is the bibliographic data of a book
+type Metadata struct {
+	ISBN        string   `json:"isbn"`
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors"`
+	PublishYear int      `json:"publish_year"`
+	Description string   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	// Source names the providers the data came from
+	Source string `json:"source"`
+}
+
+// Provider looks up books by ISBN
+type Provider interface {
+	// Name identifies the provider, e.g. in the source of its results
+	Name() string
+	// Lookup returns the metadata of the book with a canonical ISBN-13, or
+	// ErrNotFound
+	Lookup(ctx context.Context, isbn string) (*Metadata, error)
+}
+
+// complete reports whether every field of the metadata is set
+func (m *Metadata) complete() bool {
+	return m.Title != "" && len(m.Authors) > 0 && m.PublishYear != 0 && m.Description != "" && len(m.Subjects) > 0
+}
+
+// merge fills the fields of m that are empty from other
+func (m *Metadata) merge(other *Metadata) {
+	filled := false
+	if m.Title == "" && other.Title != "" {
+		m.Title, filled = other.Title, true
+	}
+	if len(m.Authors) == 0 && len(other.Authors) > 0 {
+		m.Authors, filled = other.Authors, true
+	}
+	if m.PublishYear == 0 && other.PublishYear != 0 {
+		m.PublishYear, filled = other.PublishYear, true
+	}
+	if m.Description == "" && other.Description != "" {
+		m.Description, filled = other.Description, true
+	}
+	if len(m.Subjects) == 0 && len(other.Subjects) > 0 {
+		m.Subjects, filled = other.Subjects, true
+	}
+	if filled {
+		m.Source += "," + other.Source
+	}
+}
+
+// Chain asks its providers in order. The first provider knowing the book
+// supplies the metadata and later ones fill the fields it lacks.
+type Chain []Provider
+
+// Name returns "chain"
+func (c Chain) Name() string {
+	return "chain"
+}
+
+// Lookup returns the merged metadata of the providers knowing the book. An
+// error of a provider is returned only when no other one knows the book.
+func (c Chain) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	var result *Metadata
+	var lastErr error
+	for _, provider := range c {
+		metadata, err := provider.Lookup(ctx, isbn)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if result == nil {
+			result = metadata
+		} else {
+			result.merge(metadata)
+		}
+		if result.complete() {
+			break
+		}
+	}
+
+	if result != nil {
+		return result, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNotFound
+}
+
+// Fixtures is a provider answering from fixed metadata keyed by ISBN-13.
+// It is meant for tests and offline development.
+type Fixtures map[string]Metadata
+
+// LoadFixtures reads fixtures from a JSON object of metadata keyed by ISBN
+func LoadFixtures(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("booklookup: %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// Name returns "fixture"
+func (f Fixtures) Name() string {
+	return "fixture"
+}
+
+// Lookup returns a copy of the fixture of the ISBN
+func (f Fixtures) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	metadata, ok := f[isbn]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	metadata.ISBN = isbn
+	if metadata.Source == "" {
+		metadata.Source = f.Name()
+	}
+	return &metadata, nil
+}
+
+// getJSON fetches a URL and decodes its JSON body into v. A 404 response is
+// reported as ErrNotFound.
+func getJSON(ctx context.Context, client *http.Client, provider, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("booklookup: %s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("booklookup: %s returned status %d", provider, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("booklookup: %s: %w", provider, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("booklookup: %s returned invalid JSON: %w", provider, err)
+	}
+	return nil
+}
+
+// parseYear returns the first year in a free form date such as "March 2008"
+func parseYear(date string) int {
+	match := yearPattern.FindStringSubmatch(date)
+	if match == nil {
+		return 0
+	}
+	year, _ := strconv.Atoi(match[1])
+	return year
+}
+
+// joinTitle joins a title and its subtitle
+func joinTitle(title, subtitle string) string {
+	if subtitle == "" {
+		return title
+	}
+	return title + ": " + subtitle
+}
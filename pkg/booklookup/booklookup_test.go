@@ -0,0 +1,195 @@
+package booklookup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cleanCode = "9780132350884"
+
+// countingProvider counts lookups and answers with a fixed result
+type countingProvider struct {
+	calls    int
+	metadata *Metadata
+	err      error
+}
+
+func (p *countingProvider) Name() string {
+	return "counting"
+}
+
+func (p *countingProvider) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	p.calls++
+	if p.metadata == nil {
+		return nil, p.err
+	}
+	metadata := *p.metadata
+	return &metadata, nil
+}
+
+func TestOpenLibrary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/books", r.URL.Path)
+		assert.Equal(t, "data", r.URL.Query().Get("jscmd"))
+		if r.URL.Query().Get("bibkeys") != "ISBN:"+cleanCode {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"ISBN:9780132350884": {
+			"title": "Clean code", "subtitle": "a handbook of agile software craftsmanship",
+			"authors": [{"name": "Robert C. Martin"}], "publish_date": "c2009",
+			"subjects": [{"name": "Agile software development"}, {"name": "Computer software"}]
+		}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenLibrary(server.URL+"/", nil)
+	metadata, err := provider.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		ISBN:        cleanCode,
+		Title:       "Clean code: a handbook of agile software craftsmanship",
+		Authors:     []string{"Robert C. Martin"},
+		PublishYear: 2009,
+		Subjects:    []string{"Agile software development", "Computer software"},
+		Source:      "openlibrary",
+	}, metadata)
+
+	_, err = provider.Lookup(context.Background(), "9780441172719")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGoogleBooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+		switch r.URL.Query().Get("q") {
+		case "isbn:" + cleanCode:
+			w.Write([]byte(`{"totalItems": 1, "items": [{"volumeInfo": {
+				"title": "Clean Code", "authors": ["Robert C. Martin"], "publishedDate": "2008-08-01",
+				"description": "Even bad code can function.", "categories": ["Computers"]
+			}}]}`))
+		case "isbn:9780441172719":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"totalItems": 0}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewGoogleBooks(server.URL, "secret", nil)
+	metadata, err := provider.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean Code", metadata.Title)
+	assert.Equal(t, 2008, metadata.PublishYear)
+	assert.Equal(t, "Even bad code can function.", metadata.Description)
+	assert.Equal(t, []string{"Computers"}, metadata.Subjects)
+
+	_, err = provider.Lookup(context.Background(), "9780134757599")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = provider.Lookup(context.Background(), "9780441172719")
+	assert.EqualError(t, err, "booklookup: googlebooks returned status 503")
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	provider := NewOpenLibrary(server.URL, &http.Client{Timeout: 20 * time.Millisecond})
+	_, err := provider.Lookup(context.Background(), cleanCode)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestChain_FillsMissingFields(t *testing.T) {
+	first := Fixtures{cleanCode: {Title: "Clean code", Authors: []string{"Robert C. Martin"}, Source: "first"}}
+	second := Fixtures{cleanCode: {Title: "Clean Code", PublishYear: 2008, Description: "Even bad code can function.", Source: "second"}}
+	unused := &countingProvider{err: ErrNotFound}
+
+	metadata, err := Chain{first, second, unused}.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean code", metadata.Title)
+	assert.Equal(t, 2008, metadata.PublishYear)
+	assert.Equal(t, "Even bad code can function.", metadata.Description)
+	assert.Equal(t, "first,second", metadata.Source)
+	assert.Equal(t, 1, unused.calls)
+
+	_, err = Chain{first, second}.Lookup(context.Background(), "9780441172719")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestChain_Errors(t *testing.T) {
+	failing := &countingProvider{err: errors.New("connection refused")}
+	found := Fixtures{cleanCode: {Title: "Clean Code"}}
+
+	metadata, err := Chain{failing, found}.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean Code", metadata.Title)
+
+	_, err = Chain{failing, found}.Lookup(context.Background(), "9780441172719")
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestCache(t *testing.T) {
+	provider := &countingProvider{metadata: &Metadata{Title: "Clean Code", Authors: []string{"Robert C. Martin"}}}
+	cache := NewCache(provider, time.Hour, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	metadata, err := cache.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	metadata.Authors[0] = "changed"
+	metadata, _ = cache.Lookup(context.Background(), cleanCode)
+	assert.Equal(t, []string{"Robert C. Martin"}, metadata.Authors)
+	assert.Equal(t, 1, provider.calls)
+
+	now = now.Add(2 * time.Hour)
+	cache.Lookup(context.Background(), cleanCode)
+	assert.Equal(t, 2, provider.calls)
+
+	// The entry expiring first makes room
+	cache.Lookup(context.Background(), "9780441172719")
+	now = now.Add(time.Minute)
+	cache.Lookup(context.Background(), "9780134757599")
+	assert.Len(t, cache.entries, 2)
+	assert.NotContains(t, cache.entries, cleanCode)
+}
+
+func TestCache_NotFoundAndErrors(t *testing.T) {
+	provider := &countingProvider{err: ErrNotFound}
+	cache := NewCache(provider, time.Hour, 10)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.Lookup(context.Background(), cleanCode)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, 1, provider.calls)
+
+	provider.err = errors.New("timeout")
+	for i := 0; i < 2; i++ {
+		_, err := cache.Lookup(context.Background(), "9780441172719")
+		assert.EqualError(t, err, "timeout")
+	}
+	assert.Equal(t, 3, provider.calls)
+}
+
+func TestLoadFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"9780132350884": {"title": "Clean Code", "authors": ["Robert C. Martin"]}}`), 0o600))
+
+	fixtures, err := LoadFixtures(path)
+	assert.NoError(t, err)
+	metadata, err := fixtures.Lookup(context.Background(), cleanCode)
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{ISBN: cleanCode, Title: "Clean Code", Authors: []string{"Robert C. Martin"}, Source: "fixture"}, metadata)
+}
@@ -0,0 +1,98 @@
+package booklookup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Cache remembers the answers of a provider for a while, including that it
+// knows no book with an ISBN. Errors such as timeouts are not cached.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	size     int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	metadata *Metadata
+	expires  time.Time
+}
+
+// NewCache caches the answers of a provider for ttl. At most size answers
+// are kept; the one expiring first is dropped to make room.
+func NewCache(provider Provider, ttl time.Duration, size int) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		size:     size,
+		now:      time.Now,
+		entries:  map[string]cacheEntry{},
+	}
+}
+
+// Name returns the name of the cached provider
+func (c *Cache) Name() string {
+	return c.provider.Name()
+}
+
+// Lookup returns the cached answer for the ISBN, or asks the provider
+func (c *Cache) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[isbn]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.copy()
+	}
+
+	metadata, err := c.provider.Lookup(ctx, isbn)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	entry = cacheEntry{metadata: metadata, expires: c.now().Add(c.ttl)}
+	c.mu.Lock()
+	c.store(isbn, entry)
+	c.mu.Unlock()
+	return entry.copy()
+}
+
+// store adds an entry, evicting expired entries or the one expiring first
+// when the cache is full. The caller holds the lock.
+func (c *Cache) store(isbn string, entry cacheEntry) {
+	if _, ok := c.entries[isbn]; !ok && len(c.entries) >= c.size {
+		now := c.now()
+		var oldest string
+		for key, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, key)
+				continue
+			}
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = key
+			}
+		}
+		if len(c.entries) >= c.size && oldest != "" {
+			delete(c.entries, oldest)
+		}
+	}
+	if c.size > 0 {
+		c.entries[isbn] = entry
+	}
+}
+
+// copy returns a copy of the cached answer, so callers may modify it
+func (e cacheEntry) copy() (*Metadata, error) {
+	if e.metadata == nil {
+		return nil, ErrNotFound
+	}
+	metadata := *e.metadata
+	metadata.Authors = append([]string(nil), e.metadata.Authors...)
+	metadata.Subjects = append([]string(nil), e.metadata.Subjects...)
+	return &metadata, nil
+}
@@ -0,0 +1,146 @@
+package booklookup
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a request to a provider when no client is given
+const DefaultTimeout = 5 * time.Second
+
+// Default API endpoints
+const (
+	OpenLibraryURL = "https://openlibrary.org"
+	GoogleBooksURL = "https://www.googleapis.com"
+)
+
+// newClient returns client, or a client with DefaultTimeout when nil
+func newClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: DefaultTimeout}
+	}
+	return client
+}
+
+// OpenLibrary looks up books with the Open Library Books API
+type OpenLibrary struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenLibrary creates an Open Library provider for an API base URL such
+// as OpenLibraryURL. A nil client uses an HTTP client with DefaultTimeout.
+func NewOpenLibrary(baseURL string, client *http.Client) *OpenLibrary {
+	return &OpenLibrary{baseURL: strings.TrimRight(baseURL, "/"), client: newClient(client)}
+}
+
+// openLibraryBook is the subset of a Books API record the provider reads
+type openLibraryBook struct {
+	Title       string `json:"title"`
+	Subtitle    string `json:"subtitle"`
+	PublishDate string `json:"publish_date"`
+	Authors     []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Subjects []struct {
+		Name string `json:"name"`
+	} `json:"subjects"`
+}
+
+// Name returns "openlibrary"
+func (p *OpenLibrary) Name() string {
+	return "openlibrary"
+}
+
+// Lookup fetches the book of an ISBN. The Books API answers with an empty
+// object when it knows no such book.
+func (p *OpenLibrary) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	key := "ISBN:" + isbn
+	query := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+
+	var books map[string]openLibraryBook
+	if err := getJSON(ctx, p.client, p.Name(), p.baseURL+"/api/books?"+query.Encode(), &books); err != nil {
+		return nil, err
+	}
+	book, ok := books[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	metadata := &Metadata{
+		ISBN:        isbn,
+		Title:       joinTitle(book.Title, book.Subtitle),
+		PublishYear: parseYear(book.PublishDate),
+		Source:      p.Name(),
+	}
+	for _, author := range book.Authors {
+		metadata.Authors = append(metadata.Authors, author.Name)
+	}
+	for _, subject := range book.Subjects {
+		metadata.Subjects = append(metadata.Subjects, subject.Name)
+	}
+	return metadata, nil
+}
+
+// GoogleBooks looks up books with the Google Books volumes API
+type GoogleBooks struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewGoogleBooks creates a Google Books provider for an API base URL such
+// as GoogleBooksURL. The API key is optional but raises the quota. A nil
+// client uses an HTTP client with DefaultTimeout.
+func NewGoogleBooks(baseURL, apiKey string, client *http.Client) *GoogleBooks {
+	return &GoogleBooks{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, client: newClient(client)}
+}
+
+// googleBooksVolumes is the subset of a volumes search the provider reads
+type googleBooksVolumes struct {
+	Items []struct {
+		VolumeInfo struct {
+			Title         string   `json:"title"`
+			Subtitle      string   `json:"subtitle"`
+			Authors       []string `json:"authors"`
+			PublishedDate string   `json:"publishedDate"`
+			Description   string   `json:"description"`
+			Categories    []string `json:"categories"`
+		} `json:"volumeInfo"`
+	} `json:"items"`
+}
+
+// Name returns "googlebooks"
+func (p *GoogleBooks) Name() string {
+	return "googlebooks"
+}
+
+// Lookup searches the volumes with the ISBN and returns the first one
+func (p *GoogleBooks) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	query := url.Values{"q": {"isbn:" + isbn}}
+	if p.apiKey != "" {
+		query.Set("key", p.apiKey)
+	}
+
+	var volumes googleBooksVolumes
+	if err := getJSON(ctx, p.client, p.Name(), p.baseURL+"/books/v1/volumes?"+query.Encode(), &volumes); err != nil {
+		return nil, err
+	}
+	if len(volumes.Items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	info := volumes.Items[0].VolumeInfo
+	return &Metadata{
+		ISBN:        isbn,
+		Title:       joinTitle(info.Title, info.Subtitle),
+		Authors:     info.Authors,
+		PublishYear: parseYear(info.PublishedDate),
+		Description: info.Description,
+		Subjects:    info.Categories,
+		Source:      p.Name(),
+	}, nil
+}
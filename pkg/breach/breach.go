@@ -0,0 +1,161 @@
+// Package breach checks passwords against a local copy of a breached
+// password list such as Pwned Passwords. Passwords are looked up by their
+// SHA-1 hash, so the list never contains plain passwords.
+//
+// Two layouts are supported. A file holds one uppercase SHA-1 hash per
+// line, optionally followed by ":<count>", sorted ascending; it is searched
+// without being loaded into memory. A directory holds k-anonymity range
+// files named after the first five hex digits of the hash, e.g. 5BAA6.txt,
+// each listing the remaining 35 digits as "<suffix>:<count>" lines.
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	hashLength   = 40
+	prefixLength = 5
+	// scanSize is the span below which a file is scanned instead of halved
+	scanSize = 4096
+)
+
+// List is a breached password list
+type List struct {
+	file *os.File
+	size int64
+	dir  string
+}
+
+// Open opens a list file or a directory of range files
+func Open(path string) (*List, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &List{dir: path}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &List{file: file, size: info.Size()}, nil
+}
+
+// Close closes the list file
+func (l *List) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Contains reports whether a password is on the list
+func (l *List) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if l.dir != "" {
+		return l.containsInRange(target)
+	}
+	return l.containsInFile(target)
+}
+
+// containsInRange scans the range file of the hash prefix
+func (l *List) containsInRange(target string) (bool, error) {
+	prefix, suffix := target[:prefixLength], target[prefixLength:]
+
+	file, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if lineHash(scanner.Text()) == suffix {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// containsInFile binary searches the sorted list file. lo always starts a
+// line whose hash sorts before the target, so the target can only follow it.
+func (l *List) containsInFile(target string) (bool, error) {
+	lo, hi := int64(0), l.size
+	for hi-lo > scanSize {
+		mid := lo + (hi-lo)/2
+		start, hash, err := l.lineAfter(mid)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case start >= hi:
+			hi = mid
+		case hash < target:
+			lo = start
+		default:
+			hi = start
+		}
+	}
+
+	scanner := bufio.NewScanner(io.NewSectionReader(l.file, lo, l.size-lo))
+	for scanner.Scan() {
+		hash := lineHash(scanner.Text())
+		if hash == target {
+			return true, nil
+		}
+		if hash > target {
+			return false, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// lineAfter returns the offset and hash of the first line starting at or
+// after pos. The offset is the file size when there is none.
+func (l *List) lineAfter(pos int64) (int64, string, error) {
+	start := pos
+	if pos > 0 {
+		buf := make([]byte, 128)
+		for start = pos - 1; ; start += int64(len(buf)) {
+			n, err := l.file.ReadAt(buf, start)
+			if i := strings.IndexByte(string(buf[:n]), '\n'); i >= 0 {
+				start += int64(i) + 1
+				break
+			}
+			if err == io.EOF {
+				return l.size, "", nil
+			}
+			if err != nil {
+				return 0, "", err
+			}
+		}
+	}
+
+	buf := make([]byte, hashLength)
+	n, err := l.file.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, lineHash(string(buf[:n])), nil
+}
+
+// lineHash returns the uppercase hash of a "<hash>:<count>" line
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	return strings.ToUpper(strings.TrimSpace(hash))
+}
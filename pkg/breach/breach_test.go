@@ -0,0 +1,90 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeList writes a sorted list file large enough to be binary searched
+func writeList(t *testing.T, passwords []string) string {
+	lines := make([]string, len(passwords))
+	for i, password := range passwords {
+		lines[i] = fmt.Sprintf("%s:%d", sha1Hex(password), i+1)
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600))
+	return path
+}
+
+func TestList_File(t *testing.T) {
+	passwords := make([]string, 5000)
+	for i := range passwords {
+		passwords[i] = fmt.Sprintf("password%d", i)
+	}
+
+	list, err := Open(writeList(t, passwords))
+	assert.NoError(t, err)
+	defer list.Close()
+
+	for _, password := range passwords {
+		found, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.True(t, found, password)
+	}
+	for _, password := range []string{"", "correct horse battery staple", "password5000"} {
+		found, err := list.Contains(password)
+		assert.NoError(t, err)
+		assert.False(t, found, password)
+	}
+}
+
+func TestList_SmallFile(t *testing.T) {
+	list, err := Open(writeList(t, []string{"password", "123456"}))
+	assert.NoError(t, err)
+	defer list.Close()
+
+	found, _ := list.Contains("password")
+	assert.True(t, found)
+	found, _ = list.Contains("secret123")
+	assert.False(t, found)
+}
+
+func TestList_RangeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("password")
+	assert.Equal(t, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", hash)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"),
+		[]byte("003D68EB55068C33ACE09247EE4C639306B:3\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004\n"), 0o600))
+
+	list, err := Open(dir)
+	assert.NoError(t, err)
+
+	found, err := list.Contains("password")
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	// Missing range files mean no hash with that prefix was breached
+	found, err = list.Contains("secret123")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestOpen_Missing(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
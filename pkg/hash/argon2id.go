@@ -0,0 +1,135 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix     = "$argon2id$"
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Default Argon2id parameters, the second recommendation of RFC 9106 with
+// a lower parallelism
+const (
+	DefaultArgon2Memory      uint32 = 64 * 1024
+	DefaultArgon2Time        uint32 = 3
+	DefaultArgon2Parallelism uint8  = 2
+)
+
+// Argon2id hashes passwords with Argon2id
+type Argon2id struct {
+	// Memory is the memory cost in KiB
+	Memory uint32
+	// Time is the number of passes over the memory
+	Time uint32
+	// Parallelism is the number of lanes
+	Parallelism uint8
+}
+
+// NewArgon2id returns Argon2id with the given costs
+func NewArgon2id(memory, time uint32, parallelism uint8) (*Argon2id, error) {
+	if time < 1 || parallelism < 1 {
+		return nil, errors.New("hash: argon2id time and parallelism must be at least 1")
+	}
+	if memory < 8*uint32(parallelism) {
+		return nil, errors.New("hash: argon2id memory must be at least 8 KiB per lane")
+	}
+	return &Argon2id{Memory: memory, Time: time, Parallelism: parallelism}, nil
+}
+
+// Hash hashes a password with a random salt
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	params := argon2idParams{memory: a.Memory, time: a.Time, parallelism: a.Parallelism}
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.parallelism, argon2idKeyLength)
+	return params.encode(salt, key), nil
+}
+
+// Verify reports whether a password matches an Argon2id hash
+func (a *Argon2id) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// Identifies reports whether a hash is an Argon2id hash
+func (a *Argon2id) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+// Current reports whether a hash uses the configured costs
+func (a *Argon2id) Current(encoded string) bool {
+	params, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false
+	}
+	return params.memory == a.Memory &&
+		params.time == a.Time &&
+		params.parallelism == a.Parallelism &&
+		len(key) == argon2idKeyLength
+}
+
+// argon2idParams are the costs stored in an Argon2id hash
+type argon2idParams struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+}
+
+func (p argon2idParams) encode(salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, p.memory, p.time, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id parses $argon2id$v=19$m=<memory>,t=<time>,p=<lanes>$<salt>$<key>
+func decodeArgon2id(encoded string) (argon2idParams, []byte, []byte, error) {
+	var params argon2idParams
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("hash: unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.parallelism); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if params.time < 1 || params.parallelism < 1 {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) < 16 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,58 @@
+package hash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptMaxPassword is the number of password bytes bcrypt uses
+const bcryptMaxPassword = 72
+
+// Bcrypt hashes passwords with bcrypt. Its hashes keep the traditional
+// $2a$<cost>$ format, which PHC strings are a superset of.
+type Bcrypt struct {
+	Cost int
+}
+
+// NewBcrypt returns bcrypt with the given cost
+func NewBcrypt(cost int) (*Bcrypt, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("hash: bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &Bcrypt{Cost: cost}, nil
+}
+
+// Hash hashes a password. Passwords over 72 bytes are rejected instead of
+// truncated.
+func (b *Bcrypt) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPassword {
+		return "", ErrPasswordTooLong
+	}
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(bytes), err
+}
+
+// Verify reports whether a password matches a bcrypt hash
+func (b *Bcrypt) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Identifies reports whether a hash is a bcrypt hash
+func (b *Bcrypt) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// Current reports whether a hash uses the configured cost
+func (b *Bcrypt) Current(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err == nil && cost == b.Cost
+}
@@ -1,17 +1,72 @@
+// Package hash hashes passwords. Hashes are stored as PHC strings such as
+// $argon2id$v=19$m=65536,t=3,p=2$salt$key, so every stored hash names the
+// algorithm and parameters it was made with and keeps verifying after the
+// configuration changes.
 package hash
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"errors"
 )
 
-// GenerateHash generates a hash from password
-func GenerateHash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+var (
+	// ErrMalformedHash is returned for stored hashes that cannot be parsed
+	ErrMalformedHash = errors.New("hash: malformed password hash")
+	// ErrPasswordTooLong is returned by bcrypt for passwords over 72 bytes,
+	// which it would otherwise truncate silently
+	ErrPasswordTooLong = errors.New("hash: password is longer than 72 bytes")
+)
+
+// Algorithm is a password hashing scheme with its configured parameters
+type Algorithm interface {
+	// Hash hashes a password with the configured parameters
+	Hash(password string) (string, error)
+	// Verify reports whether a password matches a hash of this algorithm.
+	// The parameters are read from the hash.
+	Verify(password, encoded string) (bool, error)
+	// Identifies reports whether a hash was made with this algorithm
+	Identifies(encoded string) bool
+	// Current reports whether a hash of this algorithm was made with the
+	// configured parameters
+	Current(encoded string) bool
+}
+
+// Hasher hashes new passwords with one algorithm and verifies hashes of
+// every supported algorithm
+type Hasher struct {
+	algorithm Algorithm
+	known     []Algorithm
+}
+
+// NewHasher creates a hasher hashing with algorithm. Argon2id and bcrypt
+// hashes are always verified.
+func NewHasher(algorithm Algorithm) *Hasher {
+	return &Hasher{
+		algorithm: algorithm,
+		known:     []Algorithm{algorithm, &Argon2id{}, &Bcrypt{}},
+	}
+}
+
+// Hash hashes a password
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.algorithm.Hash(password)
+}
+
+// Verify reports whether a password matches a stored hash. Hashes of
+// unknown algorithms, including the empty hash of password-less accounts,
+// never match.
+func (h *Hasher) Verify(password, encoded string) bool {
+	for _, algorithm := range h.known {
+		if algorithm.Identifies(encoded) {
+			ok, err := algorithm.Verify(password, encoded)
+			return err == nil && ok
+		}
+	}
+	return false
 }
 
-// CheckPasswordHash checks if password matches hash
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// NeedsRehash reports whether a stored hash was made with another algorithm
+// or other parameters than new hashes are, and should be replaced once the
+// password is known
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	return !h.algorithm.Identifies(encoded) || !h.algorithm.Current(encoded)
 }
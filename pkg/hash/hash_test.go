@@ -0,0 +1,96 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2id_HashAndVerify(t *testing.T) {
+	algorithm, err := NewArgon2id(1024, 1, 1)
+	assert.NoError(t, err)
+	hasher := NewHasher(algorithm)
+
+	encoded, err := hasher.Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"), encoded)
+
+	assert.True(t, hasher.Verify("correct horse battery staple", encoded))
+	assert.False(t, hasher.Verify("correct horse battery stapler", encoded))
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	// Salts are random
+	other, _ := hasher.Hash("correct horse battery staple")
+	assert.NotEqual(t, encoded, other)
+}
+
+func TestArgon2id_LongPasswords(t *testing.T) {
+	hasher := NewHasher(&Argon2id{Memory: 1024, Time: 1, Parallelism: 1})
+
+	long := strings.Repeat("a", 100)
+	encoded, err := hasher.Hash(long)
+	assert.NoError(t, err)
+	assert.True(t, hasher.Verify(long, encoded))
+	assert.False(t, hasher.Verify(long[:72], encoded))
+}
+
+func TestNewArgon2id_RejectsInvalidCosts(t *testing.T) {
+	_, err := NewArgon2id(1024, 0, 1)
+	assert.Error(t, err)
+	_, err = NewArgon2id(1024, 1, 0)
+	assert.Error(t, err)
+	_, err = NewArgon2id(8, 1, 2)
+	assert.Error(t, err)
+}
+
+func TestBcrypt_RejectsLongPasswords(t *testing.T) {
+	hasher := NewHasher(&Bcrypt{Cost: bcrypt.MinCost})
+
+	_, err := hasher.Hash(strings.Repeat("a", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+	encoded, err := hasher.Hash(strings.Repeat("a", 72))
+	assert.NoError(t, err)
+	assert.True(t, hasher.Verify(strings.Repeat("a", 72), encoded))
+}
+
+func TestHasher_VerifiesOtherAlgorithms(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	hasher := NewHasher(&Argon2id{Memory: 1024, Time: 1, Parallelism: 1})
+	assert.True(t, hasher.Verify("secret123", string(legacy)))
+	assert.False(t, hasher.Verify("secret124", string(legacy)))
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+}
+
+func TestHasher_NeedsRehashOnChangedParameters(t *testing.T) {
+	old := NewHasher(&Argon2id{Memory: 1024, Time: 1, Parallelism: 1})
+	encoded, _ := old.Hash("secret123")
+
+	stronger := NewHasher(&Argon2id{Memory: 2048, Time: 2, Parallelism: 1})
+	assert.True(t, stronger.Verify("secret123", encoded))
+	assert.True(t, stronger.NeedsRehash(encoded))
+
+	legacy, _ := NewHasher(&Bcrypt{Cost: bcrypt.MinCost}).Hash("secret123")
+	assert.True(t, NewHasher(&Bcrypt{Cost: bcrypt.MinCost + 1}).NeedsRehash(legacy))
+	assert.False(t, NewHasher(&Bcrypt{Cost: bcrypt.MinCost}).NeedsRehash(legacy))
+}
+
+func TestHasher_RejectsUnknownAndMalformedHashes(t *testing.T) {
+	hasher := NewHasher(&Argon2id{Memory: 1024, Time: 1, Parallelism: 1})
+
+	for _, encoded := range []string{
+		"",
+		"secret123",
+		"$scrypt$ln=16,r=8,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0$aGFzaGhhc2hoYXNoaGFzaA",
+	} {
+		assert.False(t, hasher.Verify("secret123", encoded), encoded)
+		assert.True(t, hasher.NeedsRehash(encoded), encoded)
+	}
+}
@@ -0,0 +1,117 @@
+package highlight
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// OpenTag marks the start of a highlighted term
+	OpenTag = "<mark>"
+	// CloseTag marks the end of a highlighted term
+	CloseTag = "</mark>"
+)
+
+var asciiWord = regexp.MustCompile(`^\w+$`)
+
+// Terms extracts the terms to highlight from a search query. Quotes are
+// dropped and negated terms ("-word") and the OR operator are skipped.
+func Terms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") || field == "OR" || field == "or" {
+			continue
+		}
+		term := strings.ToLower(strings.Trim(field, `"'`))
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// Snippet returns an HTML escaped excerpt of text of at most maxLen runes
+// around the first match, with every matched term wrapped in <mark> tags.
+// It returns an empty string when none of the terms occur in text.
+func Snippet(text string, terms []string, maxLen int) string {
+	if text == "" || len(terms) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+		// \b only understands ASCII word characters
+		if asciiWord.MatchString(term) {
+			quoted[i] = `\b` + quoted[i] + `\b`
+		}
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	if !pattern.MatchString(text) {
+		return ""
+	}
+
+	prefix, suffix := "", ""
+	if maxLen > 0 && utf8.RuneCountInString(text) > maxLen {
+		first := pattern.FindStringIndex(text)[0]
+		text, prefix, suffix = window(text, first, maxLen)
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString(OpenTag)
+		b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+		b.WriteString(CloseTag)
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	b.WriteString(suffix)
+
+	return b.String()
+}
+
+// window cuts maxLen runes out of text so that the byte offset at is shown
+// with some leading context, snapping to word boundaries where possible.
+func window(text string, at int, maxLen int) (string, string, string) {
+	runes := []rune(text)
+	pos := utf8.RuneCountInString(text[:at])
+
+	start := pos - maxLen/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxLen
+	if end > len(runes) {
+		end = len(runes)
+		start = end - maxLen
+	}
+
+	// Avoid cutting words in half
+	for start > 0 && start < pos && runes[start-1] != ' ' {
+		start++
+	}
+	for end < len(runes) && end > pos && runes[end] != ' ' {
+		end--
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(runes) {
+		suffix = "…"
+	}
+
+	return strings.TrimSpace(string(runes[start:end])), prefix, suffix
+}
@@ -0,0 +1,27 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"clean", "code", "go"}, Terms(`"Clean Code" -java OR go go`))
+}
+
+func TestSnippet_EscapesAndMarks(t *testing.T) {
+	got := Snippet("Clean <code> by Bob", []string{"clean", "code"}, 0)
+	assert.Equal(t, "<mark>Clean</mark> &lt;<mark>code</mark>&gt; by Bob", got)
+}
+
+func TestSnippet_WholeWordsOnly(t *testing.T) {
+	assert.Equal(t, "", Snippet("A good book", []string{"go"}, 0))
+}
+
+func TestSnippet_TruncatesAroundMatch(t *testing.T) {
+	text := "Lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor " +
+		"incididunt ut labore the go language is great for services and tools alike"
+	got := Snippet(text, []string{"go"}, 60)
+	assert.Equal(t, "…ut labore the <mark>go</mark> language is great for services and tools…", got)
+}
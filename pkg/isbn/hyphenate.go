@@ -0,0 +1,96 @@
+package isbn
+
+import (
+	"strconv"
+	"strings"
+)
+
+// groupLength describes the length of registration group elements that start with a given prefix
+type groupLength struct {
+	prefix string
+	length int
+}
+
+// registrationGroups maps an EAN prefix to its registration group lengths
+var registrationGroups = map[string][]groupLength{
+	"978": {
+		{"0", 1}, {"1", 1}, {"2", 1}, {"3", 1}, {"4", 1}, {"5", 1},
+		{"60", 3}, {"61", 3}, {"62", 3}, {"63", 3}, {"64", 3}, {"65", 2},
+		{"7", 1},
+		{"80", 2}, {"81", 2}, {"82", 2}, {"83", 2}, {"84", 2}, {"85", 2}, {"86", 2}, {"87", 2}, {"88", 2}, {"89", 2},
+		{"90", 2}, {"91", 2}, {"92", 2}, {"93", 2}, {"94", 2},
+		{"95", 3}, {"96", 3}, {"97", 3}, {"98", 3},
+		{"999", 5}, {"99", 4},
+	},
+	"979": {
+		{"10", 2}, {"11", 2}, {"12", 2}, {"8", 1},
+	},
+}
+
+// registrantRange maps the first seven digits after the group to a registrant length
+type registrantRange struct {
+	min, max int
+	length   int
+}
+
+// registrantRanges covers the English language groups. Other groups are
+// hyphenated without splitting the registrant from the publication element.
+var registrantRanges = map[string][]registrantRange{
+	"978-0": {
+		{0, 1999999, 2},
+		{2000000, 6999999, 3},
+		{7000000, 8499999, 4},
+		{8500000, 8999999, 5},
+		{9000000, 9499999, 6},
+		{9500000, 9999999, 7},
+	},
+	"978-1": {
+		{0, 999999, 2},
+		{1000000, 3999999, 3},
+		{4000000, 5499999, 4},
+		{5500000, 8697999, 5},
+		{8698000, 9989999, 6},
+		{9990000, 9999999, 7},
+	},
+}
+
+// Hyphenate returns the hyphenated display form of a canonical ISBN-13,
+// e.g. "978-0-13-468599-1". Values that are not canonical are returned unchanged.
+func Hyphenate(isbn13 string) string {
+	if len(isbn13) != 13 || validate13(isbn13) != nil {
+		return isbn13
+	}
+
+	prefix, rest, check := isbn13[:3], isbn13[3:12], isbn13[12:]
+
+	groupLen := 0
+	for _, g := range registrationGroups[prefix] {
+		if strings.HasPrefix(rest, g.prefix) {
+			groupLen = g.length
+			break
+		}
+	}
+	if groupLen == 0 {
+		return prefix + "-" + rest + "-" + check
+	}
+
+	group, body := rest[:groupLen], rest[groupLen:]
+	parts := []string{prefix, group}
+
+	ranges, ok := registrantRanges[prefix+"-"+group]
+	if !ok || len(body) < 7 {
+		parts = append(parts, body, check)
+		return strings.Join(parts, "-")
+	}
+
+	value, _ := strconv.Atoi(body[:7])
+	for _, r := range ranges {
+		if value >= r.min && value <= r.max && r.length < len(body) {
+			parts = append(parts, body[:r.length], body[r.length:], check)
+			return strings.Join(parts, "-")
+		}
+	}
+
+	parts = append(parts, body, check)
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,134 @@
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidLength is returned when an ISBN does not have 10 or 13 digits
+	ErrInvalidLength = errors.New("ISBN must have 10 or 13 digits")
+	// ErrInvalidCharacter is returned when an ISBN contains anything but digits, hyphens, spaces or a trailing X
+	ErrInvalidCharacter = errors.New("ISBN contains invalid characters")
+	// ErrInvalidChecksum is returned when the check digit does not match
+	ErrInvalidChecksum = errors.New("ISBN check digit is invalid")
+	// ErrInvalidPrefix is returned when an ISBN-13 does not start with 978 or 979
+	ErrInvalidPrefix = errors.New("ISBN-13 must start with 978 or 979")
+)
+
+// Clean removes hyphens and spaces and upper-cases a trailing x
+func Clean(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '-' || r == ' ' || r == '‐' || r == '‑':
+			continue
+		case r == 'x':
+			b.WriteRune('X')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Normalize validates an ISBN-10 or ISBN-13 and returns its canonical ISBN-13 form
+func Normalize(s string) (string, error) {
+	digits := Clean(s)
+
+	switch len(digits) {
+	case 10:
+		if err := validate10(digits); err != nil {
+			return "", err
+		}
+		return to13(digits), nil
+	case 13:
+		if err := validate13(digits); err != nil {
+			return "", err
+		}
+		return digits, nil
+	default:
+		return "", ErrInvalidLength
+	}
+}
+
+// IsValid reports whether s is a valid ISBN-10 or ISBN-13
+func IsValid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// To10 converts a canonical 978 ISBN-13 to ISBN-10. It returns false for 979
+// ISBNs, which have no ISBN-10 equivalent.
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+
+	body := isbn13[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", true
+	}
+	return body + string(rune('0'+check)), true
+}
+
+func validate10(digits string) error {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		c := digits[i]
+		var v int
+		switch {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c == 'X' && i == 9:
+			v = 10
+		default:
+			return ErrInvalidCharacter
+		}
+		sum += v * (10 - i)
+	}
+
+	if sum%11 != 0 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validate13(digits string) error {
+	for i := 0; i < 13; i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return ErrInvalidCharacter
+		}
+	}
+	if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+		return ErrInvalidPrefix
+	}
+
+	if checkDigit13(digits[:12]) != digits[12] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// checkDigit13 computes the ISBN-13 check digit of the first 12 digits
+func checkDigit13(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(first12[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func to13(isbn10 string) string {
+	first12 := "978" + isbn10[:9]
+	return first12 + string(checkDigit13(first12))
+}
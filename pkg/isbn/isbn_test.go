@@ -0,0 +1,47 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   error
+	}{
+		{"978-0-13-468599-1", "9780134685991", nil},
+		{"9780134685991", "9780134685991", nil},
+		{"0-13-468599-7", "9780134685991", nil},
+		{"0 8044 2957 x", "9780804429573", nil},
+		{"978-0-13-468599-2", "", ErrInvalidChecksum},
+		{"0-13-468599-8", "", ErrInvalidChecksum},
+		{"977-0-13-468599-8", "", ErrInvalidPrefix},
+		{"123", "", ErrInvalidLength},
+		{"0-13-46X599-7", "", ErrInvalidCharacter},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+		assert.Equal(t, tt.err, err, tt.input)
+	}
+}
+
+func TestTo10(t *testing.T) {
+	got, ok := To10("9780804429573")
+	assert.True(t, ok)
+	assert.Equal(t, "080442957X", got)
+
+	_, ok = To10("9791032305690")
+	assert.False(t, ok)
+}
+
+func TestHyphenate(t *testing.T) {
+	assert.Equal(t, "978-0-13-468599-1", Hyphenate("9780134685991"))
+	assert.Equal(t, "978-1-4919-5035-7", Hyphenate("9781491950357"))
+	assert.Equal(t, "978-602-033295-6", Hyphenate("9786020332956"))
+	assert.Equal(t, "not-an-isbn", Hyphenate("not-an-isbn"))
+}
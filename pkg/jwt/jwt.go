@@ -11,33 +11,38 @@ import (
 
 // JWTClaims represents the claims in JWT token
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID        uint     `json:"user_id"`
+	Email         string   `json:"email"`
+	Roles         []string `json:"roles"`
+	Permissions   []string `json:"permissions"`
+	EmailVerified bool     `json:"email_verified"`
+	SessionID     string   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates JWT token for user
-func GenerateToken(user *entity.User, secretKey string, expiryDuration time.Duration) (string, error) {
+// GenerateToken generates JWT token for user bound to the given session and
+// granting the given permissions, signed with the active key of the ring
+func GenerateToken(user *entity.User, permissions []string, sessionID string, keys *KeyRing, expiryDuration time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:        user.ID,
+		Email:         user.Email,
+		Roles:         user.RoleNames(),
+		Permissions:   permissions,
+		EmailVerified: user.IsEmailVerified(),
+		SessionID:     sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiryDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	return keys.sign(claims)
 }
 
-// ValidateToken validates JWT token
-func ValidateToken(tokenString string, secretKey string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+// ValidateToken validates JWT token against the keys of the ring. Only the
+// algorithms of those keys are accepted.
+func ValidateToken(tokenString string, keys *KeyRing) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, keys.keyFunc, jwt.WithValidMethods(keys.methods))
 
 	if err != nil {
 		return nil, err
@@ -49,3 +54,13 @@ func ValidateToken(tokenString string, secretKey string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// HasPermission reports whether the token grants the permission
+func (c *JWTClaims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,303 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported by a key ring
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verification
+const minRSABits = 2048
+
+// ErrUnknownKey is returned when a token names a kid that is not in the ring
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a signing or verification key identified by its kid
+type Key struct {
+	ID        string
+	Algorithm string
+	private   any
+	public    any
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+func NewHMACKey(secret string) *Key {
+	k := []byte(secret)
+	return &Key{
+		ID:        thumbprint(map[string]string{"kty": "oct", "k": b64(k)}),
+		Algorithm: AlgorithmHS256,
+		private:   k,
+		public:    k,
+	}
+}
+
+// NewSigningKey creates an RS256 or EdDSA key from an RSA or Ed25519 private key
+func NewSigningKey(signer crypto.Signer) (*Key, error) {
+	switch priv := signer.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+		key, err := NewVerificationKey(priv.Public())
+		if err != nil {
+			return nil, err
+		}
+		key.private = priv
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", signer)
+	}
+}
+
+// NewVerificationKey creates an RS256 or EdDSA key that can only verify tokens
+func NewVerificationKey(public crypto.PublicKey) (*Key, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		return &Key{ID: thumbprint(rsaJWK(pub)), Algorithm: AlgorithmRS256, public: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: thumbprint(ed25519JWK(pub)), Algorithm: AlgorithmEdDSA, public: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// ParseKeyPEM parses a PEM encoded private key (PKCS#8 or PKCS#1) or public
+// key (PKIX). Private keys can sign, public keys only verify.
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", priv)
+		}
+		return NewSigningKey(signer)
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewSigningKey(priv)
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewVerificationKey(pub)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// LoadKeyFile reads a PEM encoded key from a file
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// KeyRing signs tokens with one active key and verifies them with any key it
+// holds, so tokens signed before a rotation stay valid while the previous key
+// is kept in the ring
+type KeyRing struct {
+	signing *Key
+	keys    map[string]*Key
+	methods []string
+}
+
+// NewKeyRing creates a key ring signing with the given key. The extra keys
+// only verify; keep a rotated out key here until its tokens have expired.
+func NewKeyRing(signing *Key, verification ...*Key) (*KeyRing, error) {
+	if signing == nil || !signing.CanSign() {
+		return nil, errors.New("signing key must hold a private key")
+	}
+
+	ring := &KeyRing{signing: signing, keys: map[string]*Key{}}
+	for _, key := range append([]*Key{signing}, verification...) {
+		if key.Algorithm == AlgorithmHS256 && key != signing {
+			return nil, errors.New("HS256 keys cannot be used as verification keys")
+		}
+		if _, ok := ring.keys[key.ID]; ok {
+			continue
+		}
+		ring.keys[key.ID] = key
+		if !slices.Contains(ring.methods, key.Algorithm) {
+			ring.methods = append(ring.methods, key.Algorithm)
+		}
+	}
+	return ring, nil
+}
+
+// NewHMACKeyRing creates a key ring signing HS256 tokens with a shared secret
+func NewHMACKeyRing(secret string) *KeyRing {
+	ring, _ := NewKeyRing(NewHMACKey(secret))
+	return ring
+}
+
+// LoadKeyRing creates a key ring from PEM files: the private key to sign with
+// and any number of previous keys that still verify
+func LoadKeyRing(signingFile string, verificationFiles []string) (*KeyRing, error) {
+	signing, err := LoadKeyFile(signingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := make([]*Key, 0, len(verificationFiles))
+	for _, path := range verificationFiles {
+		key, err := LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+	return NewKeyRing(signing, verification...)
+}
+
+// SigningKey returns the key new tokens are signed with
+func (r *KeyRing) SigningKey() *Key {
+	return r.signing
+}
+
+// sign signs claims with the active key and sets its kid header
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(r.signing.Algorithm), claims)
+	token.Header["kid"] = r.signing.ID
+	return token.SignedString(r.signing.private)
+}
+
+// keyFunc resolves the verification key of a token by its kid. Tokens
+// without a kid predate key rings and are checked against the signing key.
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := r.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = r.keys[kid]; !ok {
+			return nil, ErrUnknownKey
+		}
+	}
+
+	// Pin the algorithm to the key so a public key is never used as an HMAC secret
+	if token.Method.Alg() != key.Algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// JWK is the public part of a key as described in RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring. Shared HS256 secrets are never
+// published, so an HMAC ring has an empty set.
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range append([]*Key{r.signing}, r.verificationKeys()...) {
+		var members map[string]string
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			members = rsaJWK(pub)
+		case ed25519.PublicKey:
+			members = ed25519JWK(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   members["kty"],
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Algorithm,
+			N:         members["n"],
+			E:         members["e"],
+			Curve:     members["crv"],
+			X:         members["x"],
+		})
+	}
+	return set
+}
+
+// verificationKeys returns the keys other than the signing key ordered by kid
+func (r *KeyRing) verificationKeys() []*Key {
+	keys := make([]*Key, 0, len(r.keys))
+	for _, key := range r.keys {
+		if key != r.signing {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b *Key) int { return strings.Compare(a.ID, b.ID) })
+	return keys
+}
+
+func rsaJWK(pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"n":   b64(pub.N.Bytes()),
+		"e":   b64(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ed25519JWK(pub ed25519.PublicKey) map[string]string {
+	return map[string]string{"kty": "OKP", "crv": "Ed25519", "x": b64(pub)}
+}
+
+// thumbprint computes the RFC 7638 thumbprint of a JWK. Its required members
+// are marshalled with sorted keys, which is the canonical form.
+func thumbprint(members map[string]string) string {
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return b64(sum[:])
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
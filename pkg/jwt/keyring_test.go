@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"dot-be-go/internal/domain/entity"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEd25519Key(t *testing.T) *Key {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	key, err := NewSigningKey(priv)
+	assert.NoError(t, err)
+	return key
+}
+
+func newRSAKey(t *testing.T) *Key {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	key, err := NewSigningKey(priv)
+	assert.NoError(t, err)
+	return key
+}
+
+func testUser() *entity.User {
+	return &entity.User{ID: 7, Email: "reader@example.com"}
+}
+
+func TestKeyRing_SignAndValidate(t *testing.T) {
+	for name, key := range map[string]*Key{
+		"HS256": NewHMACKey("secret"),
+		"RS256": newRSAKey(t),
+		"EdDSA": newEd25519Key(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ring, err := NewKeyRing(key)
+			assert.NoError(t, err)
+
+			signed, err := GenerateToken(testUser(), []string{"book:read"}, "session", ring, time.Minute)
+			assert.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(signed, &JWTClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, name, parsed.Method.Alg())
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := ValidateToken(signed, ring)
+			assert.NoError(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+			assert.Equal(t, "session", claims.SessionID)
+		})
+	}
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	old, current := newEd25519Key(t), newEd25519Key(t)
+
+	before, err := NewKeyRing(old)
+	assert.NoError(t, err)
+	signed, err := GenerateToken(testUser(), nil, "session", before, time.Minute)
+	assert.NoError(t, err)
+
+	// During the overlap the previous key still verifies
+	public, err := NewVerificationKey(old.public)
+	assert.NoError(t, err)
+	overlap, err := NewKeyRing(current, public)
+	assert.NoError(t, err)
+	_, err = ValidateToken(signed, overlap)
+	assert.NoError(t, err)
+
+	// Once it is dropped its tokens are rejected
+	after, err := NewKeyRing(current)
+	assert.NoError(t, err)
+	_, err = ValidateToken(signed, after)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestKeyRing_PinsAlgorithm(t *testing.T) {
+	key := newRSAKey(t)
+	ring, err := NewKeyRing(key)
+	assert.NoError(t, err)
+
+	// An HS256 token keyed with the public key must not pass as RS256
+	der, err := x509.MarshalPKIXPublicKey(key.public)
+	assert.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{UserID: 1})
+	forged.Header["kid"] = key.ID
+	signed, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+	_, err = ValidateToken(signed, ring)
+	assert.Error(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, JWTClaims{UserID: 1}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+	_, err = ValidateToken(unsigned, ring)
+	assert.Error(t, err)
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	rsaKey, edKey := newRSAKey(t), newEd25519Key(t)
+	ring, err := NewKeyRing(rsaKey, edKey)
+	assert.NoError(t, err)
+
+	set := ring.JWKS()
+	if !assert.Len(t, set.Keys, 2) {
+		return
+	}
+	assert.Equal(t, JWK{KeyType: "RSA", KeyID: rsaKey.ID, Use: "sig", Algorithm: "RS256", N: set.Keys[0].N, E: "AQAB"}, set.Keys[0])
+	assert.Equal(t, "OKP", set.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", set.Keys[1].Curve)
+	assert.Equal(t, edKey.ID, set.Keys[1].KeyID)
+
+	assert.Empty(t, NewHMACKeyRing("secret").JWKS().Keys)
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	assert.NoError(t, err)
+
+	key, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.True(t, key.CanSign())
+	assert.Equal(t, AlgorithmEdDSA, key.Algorithm)
+
+	der, err = x509.MarshalPKIXPublicKey(priv.Public())
+	assert.NoError(t, err)
+	public, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.False(t, public.CanSign())
+	assert.Equal(t, key.ID, public.ID)
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	_, err = ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(small)}))
+	assert.Error(t, err)
+
+	_, err = ParseKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer appends every email to a file instead of delivering it. It is
+// meant for local development and tests.
+type FileMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewFileMailer creates a mailer that appends messages to the file at path
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+// Send appends the message to the file
+func (m *FileMailer) Send(msg Message) error {
+	data, err := Format(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\r', '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
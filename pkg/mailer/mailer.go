@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"log"
+)
+
+// Message represents a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// LogMailer writes emails to a logger instead of delivering them. It is meant
+// for local development.
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer creates a mailer that logs every message
+func NewLogMailer(logger *log.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send logs the message
+func (m *LogMailer) Send(msg Message) error {
+	m.logger.Printf("mail to=%q subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
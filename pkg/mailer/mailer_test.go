@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := Format("noreply@example.com", Message{
+		To:      "user@example.com",
+		Subject: "Réinitialiser",
+		Body:    "line one\nline two",
+	}, date)
+	assert.NoError(t, err)
+
+	text := string(data)
+	assert.Contains(t, text, "From: noreply@example.com\r\n")
+	assert.Contains(t, text, "To: user@example.com\r\n")
+	assert.Contains(t, text, "Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n")
+	assert.Contains(t, text, "Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n")
+	assert.True(t, strings.HasSuffix(text, "\r\n\r\nline one\r\nline two\r\n"))
+}
+
+func TestFormat_RejectsHeaderInjection(t *testing.T) {
+	_, err := Format("noreply@example.com", Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "Hello",
+	}, time.Now())
+	assert.Equal(t, ErrInvalidHeader, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path, "noreply@example.com")
+
+	assert.NoError(t, m.Send(Message{To: "a@example.com", Subject: "First", Body: "one"}))
+	assert.NoError(t, m.Send(Message{To: "b@example.com", Subject: "Second", Body: "two"}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "From: noreply@example.com"))
+	assert.Contains(t, string(data), "To: b@example.com")
+}
+
+// blockingMailer records messages once released and fails for one recipient
+type blockingMailer struct {
+	release chan struct{}
+	sent    []Message
+}
+
+func (m *blockingMailer) Send(msg Message) error {
+	<-m.release
+	if msg.To == "down@example.com" {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	next := &blockingMailer{release: make(chan struct{})}
+	var logs bytes.Buffer
+	q := NewQueue(next, 2, log.New(&logs, "", 0))
+
+	// Send returns while the mail server hangs
+	assert.NoError(t, q.Send(Message{To: "a@example.com", Subject: "First"}))
+	assert.NoError(t, q.Send(Message{To: "down@example.com", Subject: "Second"}))
+	assert.Equal(t, ErrInvalidHeader, q.Send(Message{To: "a@example.com\r\nBcc: victim@example.com"}))
+
+	close(next.release)
+	assert.NoError(t, q.Close(context.Background()))
+	assert.NoError(t, q.Send(Message{To: "late@example.com", Subject: "Late"}))
+
+	if assert.Len(t, next.sent, 1) {
+		assert.Equal(t, "a@example.com", next.sent[0].To)
+	}
+	assert.Contains(t, logs.String(), `mail to="down@example.com" subject="Second" failed: connection refused`)
+	assert.Contains(t, logs.String(), `mail to="late@example.com" subject="Late" dropped: queue closed`)
+}
+
+func TestQueue_DropsWhenFull(t *testing.T) {
+	next := &blockingMailer{release: make(chan struct{})}
+	var logs bytes.Buffer
+	q := NewQueue(next, 1, log.New(&logs, "", 0))
+
+	// One message is being delivered and one waits; the rest are dropped
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, q.Send(Message{To: "a@example.com", Subject: "Hello"}))
+	}
+	close(next.release)
+	assert.NoError(t, q.Close(context.Background()))
+
+	assert.LessOrEqual(t, len(next.sent), 2)
+	assert.GreaterOrEqual(t, strings.Count(logs.String(), "dropped: queue full"), 3)
+}
+
+func TestQueue_CloseGivesUp(t *testing.T) {
+	next := &blockingMailer{release: make(chan struct{})}
+	defer close(next.release)
+	q := NewQueue(next, 1, log.New(&bytes.Buffer{}, "", 0))
+
+	assert.NoError(t, q.Send(Message{To: "a@example.com", Subject: "Hello"}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Queue delivers emails in the background, so callers never wait on the
+// mail server. Besides keeping requests fast, this keeps the response time
+// of endpoints such as password reset from revealing whether an email was
+// sent. Failed deliveries are logged.
+type Queue struct {
+	next     Mailer
+	logger   *log.Logger
+	messages chan Message
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewQueue starts a queue delivering through next. At most size messages
+// wait for delivery; further messages are dropped and logged rather than
+// blocking the caller.
+func NewQueue(next Mailer, size int, logger *log.Logger) *Queue {
+	q := &Queue{
+		next:     next,
+		logger:   logger,
+		messages: make(chan Message, size),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Send queues the message. Only malformed headers are reported; delivery
+// errors are logged.
+func (q *Queue) Send(msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.logger.Printf("mail to=%q subject=%q dropped: queue closed", msg.To, msg.Subject)
+		return nil
+	}
+	select {
+	case q.messages <- msg:
+	default:
+		q.logger.Printf("mail to=%q subject=%q dropped: queue full", msg.To, msg.Subject)
+	}
+	return nil
+}
+
+// Close stops accepting messages and waits until the queued ones are
+// delivered, or until ctx is done. Messages still queued then are lost.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for msg := range q.messages {
+		if err := q.next.Send(msg); err != nil {
+			q.logger.Printf("mail to=%q subject=%q failed: %v", msg.To, msg.Subject, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ISO 2709 delimiters
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+const (
+	leaderLength         = 24
+	directoryEntryLength = 12
+)
+
+// FormatError reports a malformed record. The reader skips past the record,
+// so reading can continue with the next one.
+type FormatError struct {
+	Reason string
+}
+
+func (e *FormatError) Error() string {
+	return "malformed MARC record: " + e.Reason
+}
+
+// Reader reads binary ISO 2709 MARC 21 records
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader creates a reader of binary MARC 21 records
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one. Malformed
+// records are returned as a *FormatError.
+func (r *Reader) Read() (*Record, error) {
+	// Records are sometimes separated by line breaks
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != '\n' && b != '\r' && b != ' ' {
+			if err := r.r.UnreadByte(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	raw, err := r.r.ReadBytes(recordTerminator)
+	if err == io.EOF {
+		return nil, &FormatError{Reason: "missing record terminator"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRecord(raw)
+}
+
+// parseRecord parses a record including its record terminator
+func parseRecord(raw []byte) (*Record, error) {
+	if len(raw) < leaderLength+1 {
+		return nil, &FormatError{Reason: "record shorter than its leader"}
+	}
+	leader := string(raw[:leaderLength])
+	baseAddress, ok := parseDigits(leader[12:17])
+	if !ok || baseAddress <= leaderLength || baseAddress > len(raw) {
+		return nil, &FormatError{Reason: fmt.Sprintf("invalid base address %q", leader[12:17])}
+	}
+
+	// Only UTF-8 records (leader position 9 "a") and MARC-8 records limited
+	// to ASCII can be decoded
+	data := raw[baseAddress:]
+	if leader[9] != 'a' && !isASCII(data) {
+		return nil, &FormatError{Reason: "MARC-8 encoded characters are not supported, convert the records to UTF-8"}
+	}
+
+	directory := raw[leaderLength : baseAddress-1]
+	if len(directory)%directoryEntryLength != 0 {
+		return nil, &FormatError{Reason: "invalid directory length"}
+	}
+
+	record := &Record{Leader: leader}
+	for i := 0; i < len(directory); i += directoryEntryLength {
+		entry := directory[i : i+directoryEntryLength]
+		tag := string(entry[:3])
+		length, lengthOK := parseDigits(string(entry[3:7]))
+		start, startOK := parseDigits(string(entry[7:12]))
+		if !lengthOK || !startOK || length < 1 || start+length > len(data) {
+			return nil, &FormatError{Reason: "invalid directory entry for field " + tag}
+		}
+
+		// Drop the field terminator
+		value := data[start : start+length-1]
+		if !utf8.Valid(value) {
+			return nil, &FormatError{Reason: "field " + tag + " is not valid UTF-8"}
+		}
+		if isControlTag(tag) {
+			record.AddControl(tag, string(value))
+			continue
+		}
+		field, err := parseDataField(tag, value)
+		if err != nil {
+			return nil, err
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+	return record, nil
+}
+
+// parseDigits parses a fixed width number of the leader or directory. Unlike
+// strconv.Atoi it rejects signs, which would otherwise let negative offsets
+// through.
+func parseDigits(s string) (int, bool) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+		n = n*10 + int(s[i]-'0')
+	}
+	return n, len(s) > 0
+}
+
+func parseDataField(tag string, value []byte) (DataField, error) {
+	if len(value) < 2 {
+		return DataField{}, &FormatError{Reason: "field " + tag + " has no indicators"}
+	}
+
+	field := DataField{Tag: tag, Ind1: value[0], Ind2: value[1]}
+	for _, part := range bytes.Split(value[2:], []byte{subfieldDelimiter}) {
+		if len(part) == 0 {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: part[0], Value: string(part[1:])})
+	}
+	return field, nil
+}
+
+// Marshal encodes a record as ISO 2709 with UTF-8 character coding
+func Marshal(record *Record) ([]byte, error) {
+	var directory, data bytes.Buffer
+	addField := func(tag string, value []byte) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, len(value)+1, data.Len())
+		data.Write(value)
+		data.WriteByte(fieldTerminator)
+		return nil
+	}
+
+	for _, field := range record.ControlFields {
+		if err := addField(field.Tag, []byte(field.Value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range record.DataFields {
+		value := []byte{blankIndicator(field.Ind1), blankIndicator(field.Ind2)}
+		for _, subfield := range field.Subfields {
+			value = append(value, subfieldDelimiter, subfield.Code)
+			value = append(value, subfield.Value...)
+		}
+		if err := addField(field.Tag, value); err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+
+	baseAddress := leaderLength + directory.Len()
+	length := baseAddress + data.Len() + 1
+	if length > 99999 {
+		return nil, fmt.Errorf("record of %d bytes exceeds the ISO 2709 limit", length)
+	}
+
+	leader := []byte(record.Leader)
+	if len(leader) != leaderLength {
+		leader = []byte("00000nam a2200000 i 4500")
+	}
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	leader[9] = 'a'
+	copy(leader[12:17], fmt.Sprintf("%05d", baseAddress))
+
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	out = append(out, data.Bytes()...)
+	return append(out, recordTerminator), nil
+}
+
+// isControlTag reports whether a tag is a control field (001-009)
+func isControlTag(tag string) bool {
+	return tag >= "001" && tag <= "009"
+}
+
+func blankIndicator(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+	return b
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+// Package marc models MARC 21 bibliographic records and reads and writes
+// them as binary ISO 2709 and MARCXML.
+package marc
+
+// Namespace is the XML namespace of MARCXML documents
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// Record is a MARC 21 record: a 24 character leader followed by control
+// fields (tags 001-009) and data fields
+type Record struct {
+	Leader        string
+	ControlFields []ControlField
+	DataFields    []DataField
+}
+
+// ControlField is a control field holding a single value
+type ControlField struct {
+	Tag   string
+	Value string
+}
+
+// DataField is a data field with two indicators and coded subfields
+type DataField struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+// Subfield is a coded value of a data field
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// AddControl appends a control field
+func (r *Record) AddControl(tag, value string) {
+	r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+}
+
+// AddData appends a data field. Subfields with empty values are dropped and
+// a field left without subfields is not added.
+func (r *Record) AddData(tag string, ind1, ind2 byte, subfields ...Subfield) {
+	kept := make([]Subfield, 0, len(subfields))
+	for _, subfield := range subfields {
+		if subfield.Value != "" {
+			kept = append(kept, subfield)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	r.DataFields = append(r.DataFields, DataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+}
+
+// Control returns the value of the first control field with a tag
+func (r *Record) Control(tag string) string {
+	for _, field := range r.ControlFields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Fields returns the data fields with a tag in record order
+func (r *Record) Fields(tag string) []DataField {
+	var fields []DataField
+	for _, field := range r.DataFields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Subfield returns the value of the first subfield with a code
+func (f DataField) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
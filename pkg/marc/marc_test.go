@@ -0,0 +1,150 @@
+package marc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord_AddDataDropsEmptySubfields(t *testing.T) {
+	var record Record
+	record.AddData("520", ' ', ' ', Subfield{Code: 'a', Value: ""})
+	record.AddData("245", '0', '0', Subfield{Code: 'a', Value: "Dune"}, Subfield{Code: 'c', Value: ""})
+
+	assert.Len(t, record.DataFields, 1)
+	assert.Equal(t, "Dune", record.Fields("245")[0].Subfield('a'))
+	assert.Empty(t, record.Fields("520"))
+}
+
+func TestXMLWriter(t *testing.T) {
+	record := &Record{Leader: "00000nam a2200000 i 4500"}
+	record.AddControl("001", "42")
+	record.AddData("245", '1', 0, Subfield{Code: 'a', Value: "Rock & Roll <Live>"})
+
+	var buf bytes.Buffer
+	writer := NewXMLWriter(&buf)
+	assert.NoError(t, writer.Write(record))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+<record><leader>00000nam a2200000 i 4500</leader><controlfield tag="001">42</controlfield>`+
+		`<datafield tag="245" ind1="1" ind2=" "><subfield code="a">Rock &amp; Roll &lt;Live&gt;</subfield></datafield></record>
+</collection>
+`, buf.String())
+}
+
+func TestXMLWriter_EmptyCollection(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, NewXMLWriter(&buf).Close())
+	assert.Contains(t, buf.String(), `<collection xmlns="http://www.loc.gov/MARC21/slim">`+"\n</collection>")
+}
+
+func sampleRecord() *Record {
+	record := &Record{Leader: "00000nam a2200000 i 4500"}
+	record.AddControl("001", "7")
+	record.AddData("020", ' ', ' ', Subfield{Code: 'a', Value: "9780441172719"})
+	record.AddData("245", '1', '0', Subfield{Code: 'a', Value: "Dune /"}, Subfield{Code: 'c', Value: "Frank Herbert."})
+	record.AddData("650", ' ', '0', Subfield{Code: 'a', Value: "Science fiction"}, Subfield{Code: 'x', Value: "Ökologie"})
+	return record
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	first, err := Marshal(sampleRecord())
+	assert.NoError(t, err)
+	second, err := Marshal(&Record{Leader: "00000nam a2200000 i 4500", ControlFields: []ControlField{{Tag: "001", Value: "8"}}})
+	assert.NoError(t, err)
+
+	reader := NewReader(bytes.NewReader(append(append(first, '\n'), second...)))
+	record, err := reader.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "7", record.Control("001"))
+		assert.Equal(t, "Frank Herbert.", record.Fields("245")[0].Subfield('c'))
+		assert.Equal(t, byte('0'), record.Fields("650")[0].Ind2)
+		assert.Equal(t, "Ökologie", record.Fields("650")[0].Subfield('x'))
+		assert.Equal(t, byte('a'), record.Leader[9])
+	}
+	record, err = reader.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "8", record.Control("001"))
+	}
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader_SkipsMalformedRecords(t *testing.T) {
+	valid, _ := Marshal(sampleRecord())
+	broken := append([]byte("00050nam a22abcde i 4500"), recordTerminator)
+
+	// MARC-8 records may only hold ASCII
+	marc8 := append([]byte(nil), valid...)
+	marc8[9] = ' '
+
+	reader := NewReader(bytes.NewReader(bytes.Join([][]byte{broken, marc8, valid}, nil)))
+	var formatErr *FormatError
+	_, err := reader.Read()
+	assert.ErrorAs(t, err, &formatErr)
+	_, err = reader.Read()
+	if assert.ErrorAs(t, err, &formatErr) {
+		assert.Contains(t, formatErr.Reason, "MARC-8")
+	}
+	record, err := reader.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "7", record.Control("001"))
+	}
+
+	_, err = NewReader(bytes.NewReader(valid[:len(valid)-1])).Read()
+	assert.ErrorAs(t, err, &formatErr)
+}
+
+func TestReader_RejectsSignedDirectoryNumbers(t *testing.T) {
+	valid, err := Marshal(&Record{Leader: "00000nam a2200000 i 4500", ControlFields: []ControlField{{Tag: "001", Value: "8"}}})
+	assert.NoError(t, err)
+
+	// The directory entry of field 001 follows the leader: tag, length, start
+	for _, patch := range []struct {
+		offset int
+		value  string
+	}{{31, "-0001"}, {27, "+002"}, {12, "+0037"}} {
+		record := append([]byte(nil), valid...)
+		copy(record[patch.offset:], patch.value)
+
+		var formatErr *FormatError
+		_, err := NewReader(bytes.NewReader(record)).Read()
+		assert.ErrorAs(t, err, &formatErr, patch.value)
+	}
+}
+
+func TestXMLReader(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewXMLWriter(&buf)
+	assert.NoError(t, writer.Write(sampleRecord()))
+	assert.NoError(t, writer.Close())
+
+	reader := NewXMLReader(&buf)
+	record, err := reader.Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sampleRecord(), record)
+	}
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestXMLReader_PrefixedNamespace(t *testing.T) {
+	doc := `<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:leader>00000nam a2200000 i 4500</marc:leader>
+  <marc:datafield tag="100" ind1="1" ind2=" "><marc:subfield code="a">Herbert, Frank.</marc:subfield></marc:datafield>
+</marc:record>`
+
+	record, err := NewXMLReader(bytes.NewReader([]byte(doc))).Read()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Herbert, Frank.", record.Fields("100")[0].Subfield('a'))
+		assert.Equal(t, byte(' '), record.Fields("100")[0].Ind2)
+	}
+
+	var formatErr *FormatError
+	_, err = NewXMLReader(bytes.NewReader([]byte(`<record><leader>short</leader></record>`))).Read()
+	assert.ErrorAs(t, err, &formatErr)
+}
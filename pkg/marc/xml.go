@@ -0,0 +1,154 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLWriter streams records as a MARCXML collection
+type XMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+// NewXMLWriter creates a writer of a MARCXML collection. Close must be
+// called to end the document.
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w, encoder: xml.NewEncoder(w)}
+}
+
+// Write appends a record to the collection
+func (x *XMLWriter) Write(r *Record) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	record := xmlRecord{Leader: r.Leader}
+	for _, field := range r.ControlFields {
+		record.ControlFields = append(record.ControlFields, xmlControlField{Tag: field.Tag, Value: field.Value})
+	}
+	for _, field := range r.DataFields {
+		data := xmlDataField{Tag: field.Tag, Ind1: string(blankIndicator(field.Ind1)), Ind2: string(blankIndicator(field.Ind2))}
+		for _, subfield := range field.Subfields {
+			data.Subfields = append(data.Subfields, xmlSubfield{Code: string(subfield.Code), Value: subfield.Value})
+		}
+		record.DataFields = append(record.DataFields, data)
+	}
+
+	if err := x.encoder.Encode(record); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
+
+// Close ends the collection
+func (x *XMLWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "</collection>\n")
+	return err
+}
+
+func (x *XMLWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	_, err := io.WriteString(x.w, xml.Header+`<collection xmlns="`+Namespace+`">`+"\n")
+	return err
+}
+
+// XMLReader reads the records of a MARCXML document, either a collection or
+// a single record, with or without the MARCXML namespace
+type XMLReader struct {
+	decoder *xml.Decoder
+}
+
+// NewXMLReader creates a reader of MARCXML records
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{decoder: xml.NewDecoder(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one. Records
+// missing a leader or with invalid tags are returned as a *FormatError.
+func (x *XMLReader) Read() (*Record, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var raw xmlRecord
+		if err := x.decoder.DecodeElement(&raw, &start); err != nil {
+			return nil, err
+		}
+		return raw.record()
+	}
+}
+
+func (raw *xmlRecord) record() (*Record, error) {
+	if len(raw.Leader) != leaderLength {
+		return nil, &FormatError{Reason: "missing or invalid leader"}
+	}
+
+	record := &Record{Leader: raw.Leader}
+	for _, field := range raw.ControlFields {
+		if len(field.Tag) != 3 {
+			return nil, &FormatError{Reason: fmt.Sprintf("invalid tag %q", field.Tag)}
+		}
+		record.AddControl(field.Tag, field.Value)
+	}
+	for _, field := range raw.DataFields {
+		if len(field.Tag) != 3 {
+			return nil, &FormatError{Reason: fmt.Sprintf("invalid tag %q", field.Tag)}
+		}
+		data := DataField{Tag: field.Tag, Ind1: xmlIndicator(field.Ind1), Ind2: xmlIndicator(field.Ind2)}
+		for _, subfield := range field.Subfields {
+			if len(subfield.Code) != 1 {
+				return nil, &FormatError{Reason: fmt.Sprintf("invalid subfield code %q in field %s", subfield.Code, field.Tag)}
+			}
+			data.Subfields = append(data.Subfields, Subfield{Code: subfield.Code[0], Value: subfield.Value})
+		}
+		record.DataFields = append(record.DataFields, data)
+	}
+	return record, nil
+}
+
+func xmlIndicator(s string) byte {
+	if len(s) != 1 {
+		return ' '
+	}
+	return s[0]
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+)
+
+// dialect holds the driver specific SQL used by the migrator
+type dialect struct {
+	createTable      string
+	insert           string
+	delete           string
+	transactionalDDL bool
+	lock             func(ctx context.Context, conn *sql.Conn) error
+	unlock           func(ctx context.Context, conn *sql.Conn)
+}
+
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "postgres":
+		return postgresDialect(), nil
+	case "mysql":
+		return mysqlDialect(), nil
+	default:
+		return dialect{}, fmt.Errorf("unsupported migration driver %q", driver)
+	}
+}
+
+func postgresDialect() dialect {
+	h := fnv.New64a()
+	h.Write([]byte(lockName))
+	key := int64(h.Sum64())
+
+	return dialect{
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)`,
+		insert:           "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)",
+		delete:           "DELETE FROM schema_migrations WHERE version = $1",
+		transactionalDDL: true,
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key)
+			return err
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) {
+			_, _ = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		},
+	}
+}
+
+func mysqlDialect() dialect {
+	return dialect{
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at DATETIME(6) NOT NULL
+		)`,
+		insert: "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		delete: "DELETE FROM schema_migrations WHERE version = ?",
+		// MySQL commits implicitly after every DDL statement
+		transactionalDDL: false,
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			var acquired sql.NullInt64
+			err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&acquired)
+			if err != nil {
+				return err
+			}
+			if !acquired.Valid || acquired.Int64 != 1 {
+				return ErrLockNotAcquired
+			}
+			return nil
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) {
+			_, _ = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/000002_add_books.up.sql":     {Data: []byte("CREATE TABLE books (id INT);")},
+		"db/000002_add_books.down.sql":   {Data: []byte("DROP TABLE books;")},
+		"db/000001_add_users.up.sql":     {Data: []byte("CREATE TABLE users (id INT);")},
+		"db/000001_add_users.down.sql":   {Data: []byte("DROP TABLE users;")},
+		"db/000003_backfill_only.up.sql": {Data: []byte("UPDATE books SET id = id;")},
+	}
+
+	list, err := Load(fsys, "db")
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	assert.Equal(t, int64(1), list[0].Version)
+	assert.Equal(t, "add_users", list[0].Name)
+	assert.Equal(t, "DROP TABLE users;", list[0].Down)
+	assert.Len(t, list[0].Checksum, 64)
+	assert.Equal(t, "", list[2].Down)
+}
+
+func TestLoad_RejectsBadNames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/add_users.sql": {Data: []byte("CREATE TABLE users (id INT);")},
+	}
+
+	_, err := Load(fsys, "db")
+	assert.Error(t, err)
+}
+
+func TestLoad_RequiresUpScript(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/000001_add_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	_, err := Load(fsys, "db")
+	assert.Error(t, err)
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+    id INT
+);
+
+CREATE INDEX idx_a ON a (id);
+SELECT 1`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n    id INT\n);",
+		"CREATE INDEX idx_a ON a (id);",
+		"SELECT 1",
+	}, splitStatements(script))
+}
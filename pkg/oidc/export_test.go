@@ -0,0 +1,11 @@
+package oidc
+
+import "time"
+
+// SetJWKSRefreshInterval changes how often key sets may be refetched and
+// returns a function restoring the previous interval
+func SetJWKSRefreshInterval(d time.Duration) func() {
+	previous := jwksRefreshInterval
+	jwksRefreshInterval = d
+	return func() { jwksRefreshInterval = previous }
+}
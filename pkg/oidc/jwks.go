@@ -0,0 +1,191 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// supportedAlgorithms are the ID token signature algorithms accepted
+var supportedAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// jwksRefreshInterval limits how often an unknown kid triggers a refetch
+var jwksRefreshInterval = time.Minute
+
+// jwk is a JSON Web Key as published by a provider
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// publicKey returns the key as a crypto public key and the algorithm it is
+// used with
+func (k *jwk) publicKey() (interface{}, string, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, "", err
+		}
+		if n.BitLen() < 2048 {
+			return nil, "", errors.New("RSA key is shorter than 2048 bits")
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, "", errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, "RS256", nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, "", err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, "", errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, "ES256", nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, "", errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), "EdDSA", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// verificationKey is a parsed signing key of the provider
+type verificationKey struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+// keySet caches the signing keys of a provider and refetches them when a
+// token names a key it does not know, which is how providers rotate keys
+type keySet struct {
+	client *http.Client
+	uri    string
+
+	mu        sync.Mutex
+	keys      []verificationKey
+	fetchedAt time.Time
+}
+
+func newKeySet(client *http.Client, uri string) *keySet {
+	return &keySet{client: client, uri: uri}
+}
+
+// key returns the key for a kid and algorithm. Tokens without a kid are
+// accepted only when the provider publishes a single key for the algorithm.
+func (s *keySet) key(ctx context.Context, kid, alg string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+	if err := s.fetch(ctx); err != nil {
+		return nil, err
+	}
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+func (s *keySet) find(kid, alg string) interface{} {
+	var match interface{}
+	matches := 0
+	for _, key := range s.keys {
+		if key.algorithm != alg || (kid != "" && key.id != kid) {
+			continue
+		}
+		match = key.key
+		matches++
+	}
+	if matches != 1 {
+		return nil
+	}
+	return match
+}
+
+// fetch replaces the cached keys with the provider's current JWKS. Keys that
+// cannot be used for signatures are skipped.
+func (s *keySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: fetching JWKS returned %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return fmt.Errorf("oidc: decoding JWKS: %w", err)
+	}
+
+	keys := make([]verificationKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, alg, err := k.publicKey()
+		if err != nil || (k.Algorithm != "" && k.Algorithm != alg) {
+			continue
+		}
+		keys = append(keys, verificationKey{id: k.KeyID, algorithm: alg, key: key})
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,150 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"dot-be-go/pkg/oidc"
+	"dot-be-go/pkg/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "http://app.example.com/oidc/callback"
+
+func newProvider(issuer *oidctest.Server) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Name:         "fake",
+		Issuer:       issuer.Issuer(),
+		ClientID:     issuer.ClientID,
+		ClientSecret: issuer.ClientSecret,
+		RedirectURL:  redirectURL,
+	}, nil)
+}
+
+// signIn runs the authorization step and returns the code
+func signIn(t *testing.T, issuer *oidctest.Server, provider *oidc.Provider, verifier, nonce string) string {
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	assert.NoError(t, err)
+
+	parsed, _ := url.Parse(authURL)
+	assert.Equal(t, redirectURL, parsed.Query().Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, oidc.CodeChallenge(verifier), parsed.Query().Get("code_challenge"))
+
+	code, state, err := issuer.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+	return code
+}
+
+func TestProvider_CodeFlow(t *testing.T) {
+	issuer := oidctest.NewServer("client", "s3cret")
+	defer issuer.Close()
+	issuer.SetUser(oidctest.User{Subject: "42", Email: "ada@example.com", EmailVerified: true, Name: "Ada"})
+	provider := newProvider(issuer)
+
+	verifier, err := oidc.NewCodeVerifier()
+	assert.NoError(t, err)
+	code := signIn(t, issuer, provider, verifier, "nonce-1")
+
+	claims, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "42", claims.Subject)
+		assert.Equal(t, "ada@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+	}
+
+	// Codes are single use
+	_, err = provider.Exchange(context.Background(), code, verifier, "nonce-1")
+	assert.Error(t, err)
+}
+
+func TestProvider_RejectsWrongVerifierAndNonce(t *testing.T) {
+	issuer := oidctest.NewServer("client", "s3cret")
+	defer issuer.Close()
+	issuer.SetUser(oidctest.User{Subject: "42"})
+	provider := newProvider(issuer)
+
+	code := signIn(t, issuer, provider, "verifier-one-verifier-one-verifier-one-1234", "nonce-1")
+	_, err := provider.Exchange(context.Background(), code, "another-verifier-another-verifier-another-12", "nonce-1")
+	assert.Error(t, err)
+
+	code = signIn(t, issuer, provider, "verifier-one-verifier-one-verifier-one-1234", "nonce-1")
+	_, err = provider.Exchange(context.Background(), code, "verifier-one-verifier-one-verifier-one-1234", "nonce-2")
+	assert.ErrorIs(t, err, oidc.ErrNonceMismatch)
+}
+
+func TestProvider_Verify(t *testing.T) {
+	issuer := oidctest.NewServer("client", "s3cret")
+	defer issuer.Close()
+	provider := newProvider(issuer)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   issuer.Issuer(),
+			"sub":   "42",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": "n",
+		}
+	}
+
+	_, err := provider.Verify(context.Background(), issuer.SignIDToken(valid()), "n")
+	assert.NoError(t, err)
+
+	tests := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+		"foreign azp": func(c jwt.MapClaims) {
+			c["aud"] = []string{"client", "other-client"}
+			c["azp"] = "other-client"
+		},
+	}
+	for name, mutate := range tests {
+		claims := valid()
+		mutate(claims)
+		_, err := provider.Verify(context.Background(), issuer.SignIDToken(claims), "n")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestProvider_RefetchesRotatedKeys(t *testing.T) {
+	issuer := oidctest.NewServer("client", "s3cret")
+	defer issuer.Close()
+	provider := newProvider(issuer)
+
+	claims := jwt.MapClaims{
+		"iss": issuer.Issuer(),
+		"sub": "42",
+		"aud": "client",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+	_, err := provider.Verify(context.Background(), issuer.SignIDToken(claims), "")
+	assert.NoError(t, err)
+
+	// A new kid is only looked up once the key set may be fetched again
+	issuer.RotateKey()
+	_, err = provider.Verify(context.Background(), issuer.SignIDToken(claims), "")
+	assert.Error(t, err)
+
+	defer oidc.SetJWKSRefreshInterval(0)()
+	_, err = provider.Verify(context.Background(), issuer.SignIDToken(claims), "")
+	assert.NoError(t, err)
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	issuer := oidctest.NewServer("client", "s3cret")
+	defer issuer.Close()
+
+	provider := oidc.NewProvider(oidc.Config{Issuer: issuer.Issuer() + "/", ClientID: "client"}, nil)
+	_, err := provider.AuthCodeURL(context.Background(), "s", "n", "v")
+	assert.Error(t, err)
+}
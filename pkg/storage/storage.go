@@ -0,0 +1,108 @@
+// Package storage stores blobs such as uploaded images under slash
+// separated keys, on the local filesystem or in an S3 compatible object
+// store, and builds the public URLs they are served from.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or leave the
+// storage root
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage stores blobs by key
+type Storage interface {
+	// Put stores a blob, replacing any blob with the same key
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes a blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of a blob
+	URL(key string) string
+}
+
+// checkKey rejects keys that could escape the storage root
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// joinURL joins a base URL and a key
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + key
+}
+
+// Local stores blobs as files under a directory. The directory is expected
+// to be served at the base URL, e.g. by the API server itself.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal creates a storage writing under dir and serving from baseURL
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: baseURL}
+}
+
+// Put writes the blob to a temporary file first, so readers never see a
+// partially written blob
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	name := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Delete removes the file of a blob and the directories it leaves empty
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	name := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	root := filepath.Clean(l.dir)
+	for dir := filepath.Dir(name); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// URL returns the base URL followed by the key
+func (l *Local) URL(key string) string {
+	return joinURL(l.baseURL, key)
+}
@@ -0,0 +1,174 @@
+// Package throttle tracks repeated attempts per key and computes an
+// exponentially growing backoff once a key exceeds its allowance.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Entry is the attempt history of a key
+type Entry struct {
+	Attempts    int
+	LastAttempt time.Time
+}
+
+// Store persists attempt entries. Implementations must make Increment
+// atomic so concurrent attempts are all counted.
+type Store interface {
+	// Get returns the entry of a key, or a zero Entry when there is none
+	Get(key string) (Entry, error)
+	// Increment records an attempt at now and returns the updated entry.
+	// Attempts from before now minus window are forgotten first.
+	Increment(key string, now time.Time, window time.Duration) (Entry, error)
+	// Reset forgets every attempt of a key
+	Reset(key string) error
+}
+
+// Policy configures how many attempts are free and how long keys are
+// blocked afterwards
+type Policy struct {
+	// MaxAttempts is the number of attempts allowed before backoff starts
+	MaxAttempts int
+	// BaseDelay is the block after the first attempt over MaxAttempts. It
+	// doubles with every further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the block
+	MaxDelay time.Duration
+	// Window is how long attempts are remembered after the last one
+	Window time.Duration
+}
+
+// Delay returns how long a key with the given number of attempts is blocked
+// after its last attempt
+func (p Policy) Delay(attempts int) time.Duration {
+	if attempts < p.MaxAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.MaxAttempts; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Limiter applies a policy to the keys of a store
+type Limiter struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+// NewLimiter creates a limiter
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{store: store, policy: policy, now: time.Now}
+}
+
+// Check returns how long the key is still blocked, or zero when it may
+// make another attempt
+func (l *Limiter) Check(key string) (time.Duration, error) {
+	entry, err := l.store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return l.remaining(entry), nil
+}
+
+// Hit records an attempt and returns how long the key is blocked afterwards
+func (l *Limiter) Hit(key string) (time.Duration, error) {
+	entry, err := l.store.Increment(key, l.now(), l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	return l.remaining(entry), nil
+}
+
+// Reset forgets the attempts of a key
+func (l *Limiter) Reset(key string) error {
+	return l.store.Reset(key)
+}
+
+func (l *Limiter) remaining(entry Entry) time.Duration {
+	if entry.Attempts == 0 {
+		return 0
+	}
+
+	now := l.now()
+	if now.Sub(entry.LastAttempt) > l.policy.Window {
+		return 0
+	}
+	if remaining := entry.LastAttempt.Add(l.policy.Delay(entry.Attempts)).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// memorySweepInterval is how often MemoryStore drops expired entries. Each
+// sweep walks every entry, so sweeping on every attempt would make a client
+// trying many keys pay for all of them.
+const memorySweepInterval = time.Minute
+
+// MemoryStore keeps entries in process memory. It is only suitable for a
+// single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	nextSweep time.Time
+}
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+// Get returns the entry of a key
+func (s *MemoryStore) Get(key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.entries[key].Entry, nil
+}
+
+// Increment records an attempt. Expired entries are dropped at most once
+// per memorySweepInterval.
+func (s *MemoryStore) Increment(key string, now time.Time, window time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !now.Before(s.nextSweep) {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(memorySweepInterval)
+	}
+
+	entry := s.entries[key]
+	if now.After(entry.expiresAt) {
+		entry = memoryEntry{}
+	}
+	entry.Attempts++
+	entry.LastAttempt = now
+	entry.expiresAt = now.Add(window)
+	s.entries[key] = entry
+	return entry.Entry, nil
+}
+
+// Reset forgets a key
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,95 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    10 * time.Second,
+	Window:      time.Hour,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 8 * time.Second},
+		{7, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, testPolicy.Delay(tt.attempts), tt.attempts)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), testPolicy)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		wait, err := limiter.Hit("user")
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+
+	wait, _ := limiter.Hit("user")
+	assert.Equal(t, time.Second, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	wait, _ = limiter.Check("user")
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	wait, _ = limiter.Check("other")
+	assert.Zero(t, wait)
+
+	now = now.Add(time.Second)
+	wait, _ = limiter.Check("user")
+	assert.Zero(t, wait)
+
+	wait, _ = limiter.Hit("user")
+	assert.Equal(t, 2*time.Second, wait)
+
+	assert.NoError(t, limiter.Reset("user"))
+	wait, _ = limiter.Check("user")
+	assert.Zero(t, wait)
+}
+
+func TestMemoryStore_ForgetsAfterWindow(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Now()
+
+	entry, _ := store.Increment("key", start, time.Minute)
+	assert.Equal(t, 1, entry.Attempts)
+	entry, _ = store.Increment("key", start.Add(30*time.Second), time.Minute)
+	assert.Equal(t, 2, entry.Attempts)
+	entry, _ = store.Increment("key", start.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, 1, entry.Attempts)
+}
+
+func TestMemoryStore_SweepsPeriodically(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Now()
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.Increment(key, start, time.Second)
+	}
+	// Expired entries stay until the next sweep is due
+	store.Increment("d", start.Add(2*time.Second), time.Second)
+	assert.Len(t, store.entries, 4)
+
+	store.Increment("d", start.Add(memorySweepInterval), time.Second)
+	assert.Len(t, store.entries, 1)
+}
@@ -0,0 +1,138 @@
+// Package thumbnail checks uploaded images and scales them down into
+// thumbnails. JPEG, PNG and WebP images are accepted; thumbnails are JPEG.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	// Register the accepted formats with image.Decode
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Quality is the JPEG quality of thumbnails
+const Quality = 85
+
+var (
+	// ErrUnsupportedFormat is returned for data that is not a JPEG, PNG or
+	// WebP image
+	ErrUnsupportedFormat = errors.New("thumbnail: unsupported image format")
+	// ErrTooLarge is returned for images with more pixels than allowed
+	ErrTooLarge = errors.New("thumbnail: image dimensions too large")
+)
+
+// contentTypes lists the accepted content types by image.Decode format name
+var contentTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+}
+
+// Size is a named box a thumbnail is fitted into
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// DefaultSizes are the thumbnail sizes of book covers, in the 2:3 ratio of
+// most covers
+var DefaultSizes = []Size{
+	{Name: "small", Width: 120, Height: 180},
+	{Name: "medium", Width: 240, Height: 360},
+	{Name: "large", Width: 480, Height: 720},
+}
+
+// Thumbnail is an encoded thumbnail
+type Thumbnail struct {
+	Size   Size
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Detect sniffs the content type of an image. The content is trusted, not
+// the file name or the type claimed by the client.
+func Detect(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	for _, accepted := range contentTypes {
+		if contentType == accepted {
+			return contentType, nil
+		}
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Generate decodes an image and scales it into each size, keeping its
+// aspect ratio. Images are never scaled up. Images with more than
+// maxPixels pixels are rejected before decoding, so a small file declaring
+// huge dimensions cannot exhaust memory; zero means no limit.
+func Generate(data []byte, sizes []Size, maxPixels int) ([]Thumbnail, error) {
+	contentType, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || contentTypes[format] != contentType {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrUnsupportedFormat)
+	}
+	if maxPixels > 0 && config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	thumbnails := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		thumbnail, err := scale(src, size)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	return thumbnails, nil
+}
+
+// scale fits an image into a size and encodes it as JPEG. Transparent
+// areas are flattened onto white, as JPEG has no alpha channel.
+func scale(src image.Image, size Size) (Thumbnail, error) {
+	bounds := src.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), size.Width, size.Height)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: Quality}); err != nil {
+		return Thumbnail{}, err
+	}
+	return Thumbnail{Size: size, Width: width, Height: height, Data: buf.Bytes()}, nil
+}
+
+// fit returns the largest dimensions with the ratio of width and height
+// that fit into the box, without exceeding the original ones
+func fit(width, height, boxWidth, boxHeight int) (int, int) {
+	if width <= boxWidth && height <= boxHeight {
+		return width, height
+	}
+	if width*boxHeight > height*boxWidth {
+		return boxWidth, max(1, height*boxWidth/width)
+	}
+	return max(1, width*boxHeight/height), boxHeight
+}
@@ -0,0 +1,101 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gopherWebP is a 75x100 lossless WebP image
+const gopherWebP = `UklGRrIBAABXRUJQVlA4TKUBAAAvSsAYAA8w//M///MfeJAkbXvaSG7m8Q3GfYSBJekwQztm/IcZ
+lgwnmWImn2BK7aFmBtnVir6q//8VOkFE/xm4baTIu8c48ArEo6+B3zFKYln3pqClSCKX0begFTAX
+FOLXHSyF8cCNcZEG4OywuA4KVVfJCiArU7GAgJI8+lJP/OKMT/fBAjevg1cYB7YVkFuWga2lyPi5
+I0HFy5YTpWIHg0RZpkniRVW9odHAKOwosWuOGdxIyn2OvaCDvhg/we6TwadPBPbqBV58MsLmMJ8y
+ZnOWk8SRz4N+QoyPL+MnamzMvcE1rHNEr91F9GKZPVUcS9w7PhhH36suB9qPeYb/oLk6cuTiJ0wO
+K3m5h1cKjW6EVZCYMK7dxcKCBdgP9HkKr9gkAO2P8GKZGWVdIAatQa+1IDpt6qyorVwdy01xdW8J
+kfk6xjEXmVQQ+HQdFr6OKhIN34dXWq0+0qr6EJSCeeVLH9+gvGTLyqM65PQ44ihzlTXxQKjKbAvs
+hXgir7Lil9w4L2bvMycmjQcqXaMCO6BlY28i+FOLzbfI1vEqxAhotocAAA==`
+
+func encodePNG(t *testing.T, width, height int, fill color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil))
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	webp, err := base64.StdEncoding.DecodeString(gopherWebP)
+	assert.NoError(t, err)
+
+	for want, data := range map[string][]byte{
+		"image/jpeg": encodeJPEG(t, 2, 2),
+		"image/png":  encodePNG(t, 2, 2, color.Black),
+		"image/webp": webp,
+	} {
+		contentType, err := Detect(data)
+		assert.NoError(t, err)
+		assert.Equal(t, want, contentType)
+	}
+
+	for _, data := range [][]byte{nil, []byte("GIF89a\x01\x00\x01\x00"), []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), []byte("%PDF-1.7")} {
+		_, err := Detect(data)
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	thumbnails, err := Generate(encodeJPEG(t, 1000, 1500), DefaultSizes, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, thumbnails, 3) {
+		assert.Equal(t, "small", thumbnails[0].Size.Name)
+		assert.Equal(t, [2]int{120, 180}, [2]int{thumbnails[0].Width, thumbnails[0].Height})
+		assert.Equal(t, [2]int{480, 720}, [2]int{thumbnails[2].Width, thumbnails[2].Height})
+
+		config, format, err := image.DecodeConfig(bytes.NewReader(thumbnails[1].Data))
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, 240, config.Width)
+		assert.Equal(t, 360, config.Height)
+	}
+
+	// Wide images are fitted by width, small ones are not scaled up
+	webp, _ := base64.StdEncoding.DecodeString(gopherWebP)
+	thumbnails, err = Generate(webp, DefaultSizes, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [2]int{75, 100}, [2]int{thumbnails[2].Width, thumbnails[2].Height})
+	thumbnails, err = Generate(encodePNG(t, 600, 300, color.Black), DefaultSizes[:1], 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [2]int{120, 60}, [2]int{thumbnails[0].Width, thumbnails[0].Height})
+
+	// Transparency is flattened onto white
+	thumbnails, err = Generate(encodePNG(t, 10, 10, color.Transparent), DefaultSizes[:1], 0)
+	assert.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(thumbnails[0].Data))
+	assert.NoError(t, err)
+	r, g, b, _ := img.At(5, 5).RGBA()
+	assert.True(t, r > 0xf000 && g > 0xf000 && b > 0xf000)
+
+	_, err = Generate(encodePNG(t, 200, 200, color.Black), DefaultSizes, 100*100)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// A PNG signature followed by garbage
+	_, err = Generate([]byte("\x89PNG\r\n\x1a\nnot really a png"), DefaultSizes, 0)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,25 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	signed := Sign("verify:1:1700000000:user@example.com", "secret")
+
+	payload, err := Verify(signed, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "verify:1:1700000000:user@example.com", payload)
+
+	_, err = Verify(signed, "other-secret")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	tampered := Sign("verify:2:1700000000:user@example.com", "secret")
+	_, err = Verify(tampered[:len(tampered)-2]+signed[len(signed)-2:], "secret")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = Verify("no-signature", "secret")
+	assert.Equal(t, ErrInvalidSignature, err)
+}
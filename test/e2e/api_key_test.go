@@ -0,0 +1,139 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKeyResponse struct {
+	ID         uint       `json:"id"`
+	Key        string     `json:"key"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// sendWithAPIKey sends a request authenticated by an API key in the given header
+func sendWithAPIKey(e *echo.Echo, method, path, header, value string, body interface{}) *httptest.ResponseRecorder {
+	var jsonBody []byte
+	if body != nil {
+		jsonBody, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(header, value)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func createAPIKey(t *testing.T, e *echo.Echo, accessToken string, scopes ...string) apiKeyResponse {
+	rec := sendJSON(e, http.MethodPost, "/api/profile/api-keys", accessToken, map[string]interface{}{
+		"name":       "import script",
+		"scopes":     scopes,
+		"expires_at": time.Now().Add(24 * time.Hour),
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Failed to create API key: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var key apiKeyResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &key)
+	return key
+}
+
+func TestAPIKey_AuthenticatesWithScopes(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "importer@example.com")
+	key := createAPIKey(t, e, login.Token, "book:read")
+	assert.True(t, strings.HasPrefix(key.Key, "dbk_"))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+
+	rec := sendWithAPIKey(e, http.MethodGet, "/api/books", "Authorization", "ApiKey "+key.Key, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = sendWithAPIKey(e, http.MethodGet, "/api/profile", "X-API-Key", key.Key, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "importer@example.com")
+
+	// The key is limited to its scopes even though the user may write books
+	rec = sendWithAPIKey(e, http.MethodPost, "/api/books", "X-API-Key", key.Key, map[string]interface{}{
+		"title": "Dune", "author": "Frank Herbert", "isbn": "9780441172719", "publish_year": 1965,
+	})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Keys are listed without their secret and record their last use
+	rec = sendJSON(e, http.MethodGet, "/api/profile/api-keys", login.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), key.Key)
+	var keys []apiKeyResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &keys)
+	if assert.Len(t, keys, 1) {
+		assert.NotNil(t, keys[0].LastUsedAt)
+	}
+
+	// Keys cannot manage keys or other account security settings
+	rec = sendWithAPIKey(e, http.MethodGet, "/api/profile/api-keys", "X-API-Key", key.Key, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "session_required", decodeProblem(t, rec).Code)
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "importer@example.com")
+	key := createAPIKey(t, e, login.Token, "book:read")
+
+	rec := sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/profile/api-keys/%d", key.ID), login.Token, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = sendWithAPIKey(e, http.MethodGet, "/api/books", "X-API-Key", key.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "invalid_api_key", decodeProblem(t, rec).Code)
+
+	rec = sendWithAPIKey(e, http.MethodGet, "/api/books", "X-API-Key", "dbk_unknown", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKey_Validation(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "importer@example.com")
+
+	// Scopes must be held by the user
+	rec := sendJSON(e, http.MethodPost, "/api/profile/api-keys", login.Token, map[string]interface{}{
+		"name":       "too broad",
+		"scopes":     []string{"user:manage"},
+		"expires_at": time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/api-keys", login.Token, map[string]interface{}{
+		"name":       "expired",
+		"scopes":     []string{"book:read"},
+		"expires_at": time.Now().Add(-time.Hour),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
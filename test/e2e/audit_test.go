@@ -0,0 +1,183 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditLogEntry struct {
+	ID         uint   `json:"id"`
+	ActorID    *uint  `json:"actor_id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   *uint  `json:"target_id"`
+	Changes    map[string]struct {
+		Before interface{} `json:"before"`
+		After  interface{} `json:"after"`
+	} `json:"changes"`
+	Details map[string]string `json:"details"`
+}
+
+type auditLogListResponse struct {
+	Data []auditLogEntry `json:"data"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+}
+
+func listAuditLogs(t *testing.T, e *echo.Echo, accessToken, query string) auditLogListResponse {
+	rec := sendJSON(e, http.MethodGet, "/api/admin/audit-logs?"+query, accessToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to list audit logs: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var response auditLogListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode audit log list: %v", err)
+	}
+	return response
+}
+
+func TestAuditLog_AuthEvents(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "admin@example.com", Password: "wrong-password"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "nobody@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	admin := loginAsAdmin(t, e)
+
+	failed := listAuditLogs(t, e, admin.Token, "action=auth.login_failed&sort=id")
+	if assert.Len(t, failed.Data, 2) {
+		assert.Equal(t, "admin@example.com", failed.Data[0].Details["email"])
+		assert.Equal(t, "invalid_credentials", failed.Data[0].Details["reason"])
+		assert.NotNil(t, failed.Data[0].TargetID)
+		assert.Nil(t, failed.Data[0].ActorID)
+		assert.Equal(t, "nobody@example.com", failed.Data[1].Details["email"])
+		assert.Nil(t, failed.Data[1].TargetID)
+	}
+
+	logins := listAuditLogs(t, e, admin.Token, "action=auth.login")
+	if assert.Len(t, logins.Data, 1) {
+		assert.Equal(t, "password", logins.Data[0].Details["method"])
+		assert.NotNil(t, logins.Data[0].ActorID)
+		assert.NotEmpty(t, logins.Data[0].IP)
+	}
+
+	login, user := registerUser(t, e, "vera@example.com")
+	rec = sendJSON(e, http.MethodPost, "/api/profile/password", login.Token, map[string]string{
+		"current_password": "secret123",
+		"new_password":     "newsecret123",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// Entries of one user, across auth actions
+	own := listAuditLogs(t, e, admin.Token, fmt.Sprintf("actor_id=%d&action=auth.*&sort=id", user.ID))
+	actions := []string{}
+	for _, entry := range own.Data {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{"auth.register", "auth.email_verified", "auth.login", "auth.password_changed"}, actions)
+}
+
+func TestAuditLog_CategoryChanges(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	rec := sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": "Fiction", "description": "Made up"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var category struct {
+		ID uint `json:"id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &category)
+
+	rec = sendJSON(e, http.MethodPut, fmt.Sprintf("/api/admin/categories/%d", category.ID), admin.Token, map[string]string{"name": "Fiction", "description": "Novels"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/admin/categories/%d", category.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	entries := listAuditLogs(t, e, admin.Token, fmt.Sprintf("target_type=category&target_id=%d&sort=id", category.ID))
+	if assert.Len(t, entries.Data, 3) {
+		created, updated, deleted := entries.Data[0], entries.Data[1], entries.Data[2]
+		assert.Equal(t, "category.created", created.Action)
+		assert.Equal(t, "Fiction", created.Changes["name"].After)
+		assert.Nil(t, created.Changes["name"].Before)
+
+		// Only changed fields are recorded
+		assert.Equal(t, "category.updated", updated.Action)
+		assert.Len(t, updated.Changes, 1)
+		assert.Equal(t, "Made up", updated.Changes["description"].Before)
+		assert.Equal(t, "Novels", updated.Changes["description"].After)
+
+		assert.Equal(t, "category.deleted", deleted.Action)
+		assert.Equal(t, "Novels", deleted.Changes["description"].Before)
+		assert.Nil(t, deleted.Changes["description"].After)
+	}
+
+	// Entries cannot be changed once written
+	assert.Error(t, db.Exec("UPDATE audit_logs SET action = 'tampered'").Error)
+	assert.Error(t, db.Exec("DELETE FROM audit_logs").Error)
+
+	assert.Equal(t, int64(0), listAuditLogs(t, e, admin.Token, "from=2000-01-01&to=2000-12-31").Meta.Total)
+	rec = sendJSON(e, http.MethodGet, "/api/admin/audit-logs?actor_id=me", admin.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAuditLog_Export(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	for _, name := range []string{"Poetry", "Drama"} {
+		sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": name})
+	}
+
+	rec := sendJSON(e, http.MethodGet, "/api/admin/audit-logs/export?action=category.*", admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+	names := []interface{}{}
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var entry auditLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to decode export line %q: %v", scanner.Text(), err)
+		}
+		assert.Equal(t, "category.created", entry.Action)
+		names = append(names, entry.Changes["name"].After)
+	}
+	assert.Equal(t, []interface{}{"Poetry", "Drama"}, names)
+}
+
+func TestAuditLog_RequiresPermission(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "wendy@example.com")
+	for _, path := range []string{"/api/admin/audit-logs", "/api/admin/audit-logs/export"} {
+		rec := sendJSON(e, http.MethodGet, path, login.Token, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code, path)
+	}
+}
@@ -2,20 +2,38 @@ package e2e
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"dot-be-go/config"
 	"dot-be-go/internal/app/api/handlers"
 	"dot-be-go/internal/app/api/routes"
+	"dot-be-go/internal/app/api/validation"
 	"dot-be-go/internal/domain/entity"
 	"dot-be-go/internal/domain/repository"
 	"dot-be-go/internal/service"
+	"dot-be-go/migrations"
+	"dot-be-go/pkg/booklookup"
+	"dot-be-go/pkg/breach"
 	"dot-be-go/pkg/hash"
+	"dot-be-go/pkg/jwt"
+	"dot-be-go/pkg/migrate"
+	"dot-be-go/pkg/oidc"
+	"dot-be-go/pkg/oidc/oidctest"
+	"dot-be-go/pkg/storage"
+	"dot-be-go/pkg/storage/s3test"
+	"dot-be-go/pkg/throttle"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -26,7 +44,12 @@ type loginRequest struct {
 }
 
 type loginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 func setupTestEnvironment(t *testing.T) (*echo.Echo, *gorm.DB, *handlers.Handler) {
@@ -37,25 +60,43 @@ func setupTestEnvironment(t *testing.T) (*echo.Echo, *gorm.DB, *handlers.Handler
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
+	sqlDB, _ := db.DB()
+	migrationList, err := migrate.Load(migrations.FS, "postgres")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator, err := migrate.New(sqlDB, "postgres", migrationList)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
 	db.Exec("TRUNCATE TABLE users CASCADE")
 	db.Exec("TRUNCATE TABLE categories CASCADE")
 	db.Exec("TRUNCATE TABLE books CASCADE")
+	db.Exec("TRUNCATE TABLE throttle_attempts")
+	db.Exec("TRUNCATE TABLE oidc_auth_requests")
+	db.Exec("TRUNCATE TABLE audit_logs")
+	db.Exec("TRUNCATE TABLE book_imports CASCADE")
+	db.Exec("DELETE FROM roles WHERE name NOT IN ('admin', 'user')")
 
-	err = db.AutoMigrate(
-		&entity.User{},
-		&entity.Category{},
-		&entity.Book{},
-	)
-	if err != nil {
-		t.Fatalf("Failed to migrate test database: %v", err)
+	var adminRole entity.Role
+	if err := db.Where("name = ?", entity.RoleAdmin).First(&adminRole).Error; err != nil {
+		t.Fatalf("Failed to load admin role: %v", err)
 	}
 
-	hashedPassword, _ := hash.GenerateHash("admin123")
+	// The admin keeps a bcrypt hash as written before Argon2id was introduced
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+	hashedPassword := string(legacyHash)
+	verifiedAt := time.Now()
 	adminUser := entity.User{
-		Name:     "Admin",
-		Email:    "admin@example.com",
-		Password: hashedPassword,
-		Role:     "admin",
+		Name:            "Admin",
+		Email:           "admin@example.com",
+		Password:        hashedPassword,
+		Roles:           []entity.Role{adminRole},
+		EmailVerifiedAt: &verifiedAt,
 	}
 	result := db.Create(&adminUser)
 	if result.Error != nil {
@@ -65,16 +106,127 @@ func setupTestEnvironment(t *testing.T) (*echo.Echo, *gorm.DB, *handlers.Handler
 	userRepo := repository.NewUserRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
 	bookRepo := repository.NewBookRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	bookSearchRepo := repository.NewBookSearchRepository(db, cfg.DBDriver)
+	roleRepo := repository.NewRoleRepository(db)
+	passwordResetRepo := repository.NewPasswordResetTokenRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	bookImportRepo := repository.NewBookImportRepository(db)
 
-	authService := service.NewAuthService(userRepo, cfg.JWTSecretKey, cfg.JWTExpiry)
-	categoryService := service.NewCategoryService(categoryRepo)
-	bookService := service.NewBookService(bookRepo, categoryRepo)
+	sentMail = &recordingMailer{}
+	throttleStore := repository.NewThrottleStore(db)
+	throttlePolicy := throttle.Policy{
+		MaxAttempts: cfg.LoginMaxAttempts,
+		BaseDelay:   cfg.LockoutBaseDelay,
+		MaxDelay:    cfg.LockoutMaxDelay,
+		Window:      cfg.ThrottleWindow,
+	}
+	ipPolicy := throttlePolicy
+	ipPolicy.MaxAttempts = cfg.LoginIPMaxAttempts
+	authThrottle := &service.AuthThrottle{
+		Account: throttle.NewLimiter(throttleStore, throttlePolicy),
+		IP:      throttle.NewLimiter(throttleStore, ipPolicy),
+	}
+	breachedList := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(breachedList, []byte(breachedPasswordHash+":42\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write breached password list: %v", err)
+	}
+	breached, err := breach.Open(breachedList)
+	if err != nil {
+		t.Fatalf("Failed to open breached password list: %v", err)
+	}
+	t.Cleanup(func() { breached.Close() })
+	passwords := &service.Passwords{
+		Hasher:    hash.NewHasher(&hash.Argon2id{Memory: 8 * 1024, Time: 1, Parallelism: 1}),
+		MinLength: cfg.PasswordMinLength,
+		Breached:  breached,
+	}
+	auditor := service.NewAuditor(auditLogRepo)
+	oidcIssuer = oidctest.NewServer("dot-be-go", "client-secret")
+	t.Cleanup(oidcIssuer.Close)
 
-	handler := handlers.NewHandler(authService, bookService, categoryService)
+	// Sign with a fresh Ed25519 key so the asymmetric path and JWKS are covered
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %v", err)
+	}
+	signingKey, err := jwt.NewSigningKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create signing key: %v", err)
+	}
+	signingKeys, err = jwt.NewKeyRing(signingKey)
+	if err != nil {
+		t.Fatalf("Failed to create key ring: %v", err)
+	}
+
+	categoryService := service.NewCategoryService(categoryRepo, auditor)
+	bookLookup = &countingLookup{provider: lookupFixtures}
+	coverStore = s3test.NewServer("covers", "minio", "minio-secret")
+	t.Cleanup(coverStore.Close)
+	covers, err := storage.NewS3(storage.S3Config{
+		Endpoint:  coverStore.URL,
+		Bucket:    coverStore.Bucket,
+		AccessKey: coverStore.AccessKey,
+		SecretKey: coverStore.SecretKey,
+		PathStyle: true,
+		PublicURL: "https://cdn.example.com",
+	}, coverStore.Client())
+	if err != nil {
+		t.Fatalf("Failed to configure cover storage: %v", err)
+	}
+	bookService := service.NewBookService(bookRepo, categoryRepo, bookSearchRepo, auditor, booklookup.NewCache(bookLookup, time.Hour, 100), covers, service.BookCoverConfig{
+		MaxSize:   coverMaxSize,
+		MaxPixels: coverMaxPixels,
+	})
+	authService := service.NewAuthService(service.AuthServiceDeps{
+		UserRepo:      userRepo,
+		TokenRepo:     refreshTokenRepo,
+		RoleRepo:      roleRepo,
+		ResetRepo:     passwordResetRepo,
+		RecoveryRepo:  recoveryCodeRepo,
+		APIKeyRepo:    apiKeyRepo,
+		IdentityRepo:  identityRepo,
+		Books:         bookService,
+		Mailer:        sentMail,
+		Passwords:     passwords,
+		Throttle:      authThrottle,
+		Audit:         auditor,
+		Issuer:        cfg.AppName,
+		Keys:          signingKeys,
+		JWTSecret:     cfg.JWTSecretKey,
+		JWTExpiry:     cfg.JWTExpiry,
+		RefreshExpiry: cfg.JWTRefreshExpiry,
+		Verification: service.EmailVerificationConfig{
+			Required:              cfg.EmailVerificationRequired,
+			UnverifiedPermissions: cfg.UnverifiedPermissions,
+			Expiry:                cfg.EmailVerificationExpiry,
+			URL:                   cfg.EmailVerificationURL,
+		},
+		OIDCProviders: []*oidc.Provider{oidc.NewProvider(oidc.Config{
+			Name:         "fake",
+			Issuer:       oidcIssuer.Issuer(),
+			ClientID:     oidcIssuer.ClientID,
+			ClientSecret: oidcIssuer.ClientSecret,
+			RedirectURL:  "http://app.example.com/auth/callback/fake",
+		}, nil)},
+	})
+	roleService := service.NewRoleService(roleRepo, userRepo, refreshTokenRepo, auditor)
+	userService := service.NewUserService(userRepo, roleRepo, refreshTokenRepo, authThrottle, passwords, auditor)
+	auditService := service.NewAuditService(auditLogRepo)
+	// Imports of more than five rows run in the background
+	bookImportService := service.NewBookImportService(bookRepo, categoryRepo, bookImportRepo, validation.New(), auditor, service.BookImportConfig{
+		MaxRows:  cfg.BookImportMaxRows,
+		SyncRows: 5,
+	})
+
+	handler := handlers.NewHandler(authService, bookService, categoryService, roleService, userService, auditService, bookImportService)
 
 	e := echo.New()
 
-	routes.SetupRoutes(e, handler, cfg.JWTSecretKey)
+	routes.SetupRoutes(e, handler, signingKeys)
 
 	return e, db, handler
 }
@@ -155,3 +307,133 @@ func TestLoginFailure_InvalidJSON(t *testing.T) {
 	e.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func loginAsAdmin(t *testing.T, e *echo.Echo) loginResponse {
+	return loginAs(t, e, "admin@example.com", "admin123")
+}
+
+func loginAs(t *testing.T, e *echo.Echo, email, password string) loginResponse {
+	jsonBody, _ := json.Marshal(loginRequest{Email: email, Password: password})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to login: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var response loginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode login response: %v", err)
+	}
+	return response
+}
+
+func postRefreshToken(e *echo.Echo, path, refreshToken string) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(refreshRequest{RefreshToken: refreshToken})
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func getProfile(e *echo.Echo, accessToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/profile", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRefreshToken_Rotates(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	assert.NotEmpty(t, login.RefreshToken)
+
+	rec := postRefreshToken(e, "/api/auth/refresh", login.RefreshToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response loginResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEqual(t, login.RefreshToken, response.RefreshToken)
+	assert.Equal(t, http.StatusOK, getProfile(e, response.Token).Code)
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+
+	rec := postRefreshToken(e, "/api/auth/refresh", login.RefreshToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var rotated loginResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &rotated)
+
+	// Replaying the first refresh token revokes the whole family
+	rec = postRefreshToken(e, "/api/auth/refresh", login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = postRefreshToken(e, "/api/auth/refresh", rotated.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, rotated.Token).Code)
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	assert.Equal(t, http.StatusOK, getProfile(e, login.Token).Code)
+
+	rec := postRefreshToken(e, "/api/auth/logout", login.RefreshToken)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, login.Token).Code)
+	rec = postRefreshToken(e, "/api/auth/refresh", login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRegisterFailure_ValidationErrors(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	invalidBody := []byte(`{"email": "not-an-email", "password": "123"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewReader(invalidBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var response struct {
+		Errors []struct {
+			Field string `json:"field"`
+			Rule  string `json:"rule"`
+		} `json:"errors"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	rules := map[string]string{}
+	for _, fieldErr := range response.Errors {
+		rules[fieldErr.Field] = fieldErr.Rule
+	}
+	assert.Equal(t, map[string]string{"email": "email", "password": "min", "name": "required"}, rules)
+}
@@ -0,0 +1,285 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/storage/s3test"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	coverMaxSize   = 512 << 10
+	coverMaxPixels = 4000000
+)
+
+// coverStore is the object store of the current test environment
+var coverStore *s3test.Server
+
+// gopherWebP is a 75x100 lossless WebP image
+const gopherWebP = `UklGRrIBAABXRUJQVlA4TKUBAAAvSsAYAA8w//M///MfeJAkbXvaSG7m8Q3GfYSBJekwQztm/IcZ
+lgwnmWImn2BK7aFmBtnVir6q//8VOkFE/xm4baTIu8c48ArEo6+B3zFKYln3pqClSCKX0begFTAX
+FOLXHSyF8cCNcZEG4OywuA4KVVfJCiArU7GAgJI8+lJP/OKMT/fBAjevg1cYB7YVkFuWga2lyPi5
+I0HFy5YTpWIHg0RZpkniRVW9odHAKOwosWuOGdxIyn2OvaCDvhg/we6TwadPBPbqBV58MsLmMJ8y
+ZnOWk8SRz4N+QoyPL+MnamzMvcE1rHNEr91F9GKZPVUcS9w7PhhH36suB9qPeYb/oLk6cuTiJ0wO
+K3m5h1cKjW6EVZCYMK7dxcKCBdgP9HkKr9gkAO2P8GKZGWVdIAatQa+1IDpt6qyorVwdy01xdW8J
+kfk6xjEXmVQQ+HQdFr6OKhIN34dXWq0+0qr6EJSCeeVLH9+gvGTLyqM65PQ44ihzlTXxQKjKbAvs
+hXgir7Lil9w4L2bvMycmjQcqXaMCO6BlY28i+FOLzbfI1vEqxAhotocAAA==`
+
+type bookCoverResponse struct {
+	ID    uint              `json:"id"`
+	Cover map[string]string `json:"cover"`
+}
+
+func uploadCover(e *echo.Echo, bookID uint, accessToken, filename string, content []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if filename != "" {
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write(content)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/books/%d/cover", bookID), body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeBookCover(t *testing.T, rec *httptest.ResponseRecorder) bookCoverResponse {
+	var book bookCoverResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &book); err != nil {
+		t.Fatalf("Failed to decode book: %v", err)
+	}
+	return book
+}
+
+func createCoverBook(t *testing.T, e *echo.Echo, accessToken, isbn string) uint {
+	rec := createBook(t, e, accessToken, map[string]interface{}{
+		"title": "Cover Book " + isbn, "author": "Author", "isbn": isbn, "publish_year": 2020,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Failed to create book: %d %s", rec.Code, rec.Body.String())
+	}
+	return decodeBookCover(t, rec).ID
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func jpegImage(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// storedKey returns the storage key of a cover URL of the test environment
+func storedKey(url string) string {
+	return strings.TrimPrefix(url, "https://cdn.example.com/")
+}
+
+func TestBookCover_Upload(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "uma@example.com")
+	bookID := createCoverBook(t, e, login.Token, "9780132350884")
+
+	rec := uploadCover(e, bookID, login.Token, "cover.png", pngImage(t, 1000, 1500))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	book := decodeBookCover(t, rec)
+	assert.Len(t, book.Cover, 4)
+	prefix := fmt.Sprintf("https://cdn.example.com/covers/%d/", bookID)
+	for _, name := range []string{"original", "small", "medium", "large"} {
+		assert.True(t, strings.HasPrefix(book.Cover[name], prefix), book.Cover[name])
+	}
+	assert.True(t, strings.HasSuffix(book.Cover["original"], "/original.png"))
+	assert.True(t, strings.HasSuffix(book.Cover["small"], "/small.jpg"))
+	assert.Len(t, coverStore.Keys(), 4)
+
+	original, ok := coverStore.Object(storedKey(book.Cover["original"]))
+	assert.True(t, ok)
+	assert.Equal(t, "image/png", original.ContentType)
+	small, ok := coverStore.Object(storedKey(book.Cover["small"]))
+	assert.True(t, ok)
+	assert.Equal(t, "image/jpeg", small.ContentType)
+	config, format, err := image.DecodeConfig(bytes.NewReader(small.Data))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, [2]int{120, 180}, [2]int{config.Width, config.Height})
+
+	// The cover is part of the book JSON
+	rec = sendJSON(e, http.MethodGet, fmt.Sprintf("/api/books/%d", bookID), login.Token, nil)
+	assert.Equal(t, book.Cover, decodeBookCover(t, rec).Cover)
+
+	// Replacing the cover removes the blobs of the previous one, whatever
+	// the file name claims
+	rec = uploadCover(e, bookID, login.Token, "cover.png", jpegImage(t, 300, 450))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	replaced := decodeBookCover(t, rec)
+	assert.True(t, strings.HasSuffix(replaced.Cover["original"], "/original.jpg"))
+	assert.NotEqual(t, book.Cover["small"], replaced.Cover["small"])
+	assert.Len(t, coverStore.Keys(), 4)
+	_, ok = coverStore.Object(storedKey(book.Cover["original"]))
+	assert.False(t, ok)
+
+	webp, _ := base64.StdEncoding.DecodeString(gopherWebP)
+	rec = uploadCover(e, bookID, login.Token, "gopher.webp", webp)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	book = decodeBookCover(t, rec)
+	original, _ = coverStore.Object(storedKey(book.Cover["original"]))
+	assert.Equal(t, "image/webp", original.ContentType)
+	large, _ := coverStore.Object(storedKey(book.Cover["large"]))
+	config, _, err = image.DecodeConfig(bytes.NewReader(large.Data))
+	assert.NoError(t, err)
+	assert.Equal(t, [2]int{75, 100}, [2]int{config.Width, config.Height})
+
+	admin := loginAsAdmin(t, e)
+	entries := listAuditLogs(t, e, admin.Token, fmt.Sprintf("target_type=book&target_id=%d&action=book.updated&sort=id", bookID))
+	if assert.Len(t, entries.Data, 3) {
+		assert.Nil(t, entries.Data[0].Changes["cover"].Before)
+		assert.Equal(t, replaced.Cover["original"], entries.Data[1].Changes["cover"].After)
+	}
+}
+
+func TestBookCover_Rejected(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "vic@example.com")
+	bookID := createCoverBook(t, e, login.Token, "9780441172719")
+
+	rec := uploadCover(e, bookID, login.Token, "cover.jpg", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "image", passwordRule(t, decodeProblem(t, rec), "file"))
+
+	// A GIF is an image, but not an accepted one
+	rec = uploadCover(e, bookID, login.Token, "cover.gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "image", passwordRule(t, decodeProblem(t, rec), "file"))
+
+	oversized := append(jpegImage(t, 10, 10), make([]byte, coverMaxSize)...)
+	rec = uploadCover(e, bookID, login.Token, "cover.jpg", oversized)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "max", passwordRule(t, decodeProblem(t, rec), "file"))
+
+	rec = uploadCover(e, bookID, login.Token, "cover.png", pngImage(t, 2500, 2000))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "dimensions", passwordRule(t, decodeProblem(t, rec), "file"))
+
+	rec = uploadCover(e, bookID, login.Token, "", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "required", passwordRule(t, decodeProblem(t, rec), "file"))
+
+	// Other users' books are not found, unless the user has book:write:any
+	other, _ := registerUser(t, e, "wes@example.com")
+	rec = uploadCover(e, bookID, other.Token, "cover.jpg", jpegImage(t, 10, 10))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, coverStore.Keys())
+
+	admin := loginAsAdmin(t, e)
+	rec = uploadCover(e, bookID, admin.Token, "cover.jpg", jpegImage(t, 10, 10))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, coverStore.Keys(), 4)
+}
+
+func TestBookCover_Delete(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "xena@example.com")
+	bookID := createCoverBook(t, e, login.Token, "9780201633610")
+
+	rec := uploadCover(e, bookID, login.Token, "cover.jpg", jpegImage(t, 600, 900))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, coverStore.Keys(), 4)
+
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/books/%d/cover", bookID), login.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, decodeBookCover(t, rec).Cover)
+	assert.Empty(t, coverStore.Keys())
+	assert.NotContains(t, sendJSON(e, http.MethodGet, fmt.Sprintf("/api/books/%d", bookID), login.Token, nil).Body.String(), `"cover"`)
+
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/books/%d/cover", bookID), login.Token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "book_cover_not_found", decodeProblem(t, rec).Code)
+
+	// Deleting the book removes the blobs of its cover
+	uploadCover(e, bookID, login.Token, "cover.png", pngImage(t, 200, 300))
+	assert.Len(t, coverStore.Keys(), 4)
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/books/%d", bookID), login.Token, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, coverStore.Keys())
+
+	// A storage outage keeps the book and its cover in place
+	otherID := createCoverBook(t, e, login.Token, "9780134757599")
+	uploadCover(e, otherID, login.Token, "cover.png", pngImage(t, 200, 300))
+	coverStore.Close()
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/books/%d", otherID), login.Token, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "cover_storage_unavailable", decodeProblem(t, rec).Code)
+	rec = sendJSON(e, http.MethodGet, fmt.Sprintf("/api/books/%d", otherID), login.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, decodeBookCover(t, rec).Cover, 4)
+}
+
+func TestBookCover_DeletedWithAccount(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, user := registerUser(t, e, "yusuf@example.com")
+	for _, isbn := range []string{"9780201633610", "9780134757599"} {
+		rec := uploadCover(e, createCoverBook(t, e, login.Token, isbn), login.Token, "cover.png", pngImage(t, 200, 300))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Len(t, coverStore.Keys(), 8)
+
+	rec := sendJSON(e, http.MethodDelete, "/api/profile", login.Token, map[string]string{
+		"password": "secret123",
+		"books":    "delete",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, coverStore.Keys())
+
+	var books int64
+	db.Model(&entity.Book{}).Where("user_id = ?", user.ID).Count(&books)
+	assert.Equal(t, int64(0), books)
+}
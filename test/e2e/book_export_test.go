@@ -0,0 +1,176 @@
+package e2e
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func createExportFixtures(t *testing.T, e *echo.Echo) (loginResponse, loginResponse) {
+	admin := loginAsAdmin(t, e)
+	rec := sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": "Science Fiction"})
+	var category struct {
+		ID uint `json:"id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &category)
+
+	login, _ := registerUser(t, e, "paula@example.com")
+	rec = createBook(t, e, login.Token, map[string]interface{}{
+		"title": "Dune", "author": "Frank Herbert", "isbn": "9780441172719", "publish_year": 1965,
+		"description": "Spice & sand", "category_ids": []uint{category.ID},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = createBook(t, e, login.Token, map[string]interface{}{
+		"title": "Clean Code", "author": "Robert C. Martin", "isbn": "9780132350884", "publish_year": 2008,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	other, _ := registerUser(t, e, "quinn@example.com")
+	rec = createBook(t, e, other.Token, map[string]interface{}{
+		"title": "Refactoring", "author": "Martin Fowler", "isbn": "9780134757599", "publish_year": 2018,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	return login, admin
+}
+
+func exportBooks(t *testing.T, e *echo.Echo, accessToken, path string) string {
+	rec := sendJSON(e, http.MethodGet, path, accessToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to export books: %d %s", rec.Code, rec.Body.String())
+	}
+	return rec.Body.String()
+}
+
+func TestBookExport_CSVRoundTrip(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := createExportFixtures(t, e)
+	rec := sendJSON(e, http.MethodGet, "/api/books/export", login.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="books.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+
+	content := rec.Body.String()
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, []string{"Dune", "Frank Herbert", "9780441172719", "1965", "Spice & sand", "Science Fiction"}, records[1][1:7])
+		assert.Equal(t, "Clean Code", records[2][1])
+	}
+
+	// Deleted books come back with their categories when the export is imported
+	for _, book := range listBooks(t, e, login.Token, "").Data {
+		sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/books/%d", book.ID), login.Token, nil)
+	}
+	db.Exec("DELETE FROM book_categories")
+	db.Exec("DELETE FROM books WHERE deleted_at IS NOT NULL")
+	rec = importBooks(e, login.Token, "books.csv", content, nil)
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 0, job.Failed)
+	assert.Equal(t, int64(1), listBooks(t, e, login.Token, "?category_ids="+categoryIDOf(t, e, "Science Fiction")).Meta.Total)
+}
+
+func categoryIDOf(t *testing.T, e *echo.Echo, name string) string {
+	rec := sendJSON(e, http.MethodGet, "/api/categories?name="+strings.ReplaceAll(name, " ", "+"), "", nil)
+	var response struct {
+		Data []struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	for _, category := range response.Data {
+		if category.Name == name {
+			return fmt.Sprint(category.ID)
+		}
+	}
+	t.Fatalf("Category %q not found", name)
+	return ""
+}
+
+func TestBookExport_NDJSONRoundTrip(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := createExportFixtures(t, e)
+	content := exportBooks(t, e, login.Token, "/api/books/export?format=ndjson")
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if assert.Len(t, lines, 2) {
+		var book struct {
+			Title      string   `json:"title"`
+			Categories []string `json:"categories"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &book))
+		assert.Equal(t, "Dune", book.Title)
+		assert.Equal(t, []string{"Science Fiction"}, book.Categories)
+	}
+
+	rec := importBooks(e, login.Token, "books.ndjson", content, map[string]string{"upsert": "true", "dry_run": "true"})
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, 2, job.Updated)
+	assert.Equal(t, 0, job.Failed)
+}
+
+func TestBookExport_BibliographicFormats(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := createExportFixtures(t, e)
+
+	marcxml := exportBooks(t, e, login.Token, "/api/books/export?format=marcxml")
+	assert.Contains(t, marcxml, `<collection xmlns="http://www.loc.gov/MARC21/slim">`)
+	assert.Contains(t, marcxml, `<datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780441172719</subfield></datafield>`)
+	assert.Contains(t, marcxml, `<datafield tag="520" ind1=" " ind2=" "><subfield code="a">Spice &amp; sand</subfield></datafield>`)
+	assert.Contains(t, marcxml, `<datafield tag="650" ind1=" " ind2="4"><subfield code="a">Science Fiction</subfield></datafield>`)
+	assert.Equal(t, 2, strings.Count(marcxml, "<record>"))
+
+	bibtex := exportBooks(t, e, login.Token, "/api/books/export?format=bibtex")
+	assert.Contains(t, bibtex, "@book{9780441172719,\n  title = {Dune},\n  author = {Frank Herbert},\n  year = {1965},\n")
+	assert.Contains(t, bibtex, `abstract = {Spice \& sand}`)
+	assert.Contains(t, bibtex, "keywords = {Science Fiction}")
+
+	ris := exportBooks(t, e, login.Token, "/api/books/export?format=ris")
+	assert.Contains(t, ris, "TY  - BOOK\r\n")
+	assert.Contains(t, ris, "TI  - Dune\r\nAU  - Frank Herbert\r\nPY  - 1965\r\nSN  - 9780441172719\r\n")
+	assert.Contains(t, ris, "KW  - Science Fiction\r\nER  - \r\n")
+	assert.Equal(t, 2, strings.Count(ris, "ER  - "))
+
+	rec := sendJSON(e, http.MethodGet, "/api/books/export?format=xlsx", login.Token, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "oneof", passwordRule(t, decodeProblem(t, rec), "format"))
+}
+
+func TestBookExport_WholeCatalog(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, admin := createExportFixtures(t, e)
+
+	content := exportBooks(t, e, admin.Token, "/api/admin/books/export?format=ndjson")
+	assert.Equal(t, 3, strings.Count(content, "\n"))
+	assert.Contains(t, content, `"title":"Refactoring"`)
+
+	rec := sendJSON(e, http.MethodGet, "/api/admin/books/export", login.Token, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,207 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"dot-be-go/pkg/marc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const marcImportPath = "/api/admin/books/import/marc"
+
+func marshalMARC(t *testing.T, records ...*marc.Record) string {
+	var out []byte
+	for _, record := range records {
+		data, err := marc.Marshal(record)
+		if err != nil {
+			t.Fatalf("Failed to marshal MARC record: %v", err)
+		}
+		out = append(out, data...)
+	}
+	return string(out)
+}
+
+const cookbookMARCXML = `<?xml version="1.0" encoding="UTF-8"?>
+<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:record>
+    <marc:leader>00000nam a2200000 i 4500</marc:leader>
+    <marc:controlfield tag="008">200101s2020    xx            000 0 eng d</marc:controlfield>
+    <marc:datafield tag="020" ind1=" " ind2=" "><marc:subfield code="a">9780134757599</marc:subfield></marc:datafield>
+    <marc:datafield tag="100" ind1="1" ind2=" "><marc:subfield code="a">Child, Julia,</marc:subfield></marc:datafield>
+    <marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Mastering the art of French cooking.</marc:subfield></marc:datafield>
+    <marc:datafield tag="650" ind1=" " ind2="0"><marc:subfield code="a">Cooking, French.</marc:subfield></marc:datafield>
+  </marc:record>
+</marc:collection>`
+
+func TestBookImportMARC_BinaryWithSubjectRules(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": "Programming"})
+
+	book := &marc.Record{}
+	book.AddControl("008", "090101s2009    nju           001 0 eng d")
+	book.AddData("020", ' ', ' ', marc.Subfield{Code: 'a', Value: "9780132350884 (pbk.)"})
+	book.AddData("100", '1', ' ', marc.Subfield{Code: 'a', Value: "Martin, Robert C.,"})
+	book.AddData("245", '1', '0', marc.Subfield{Code: 'a', Value: "Clean code :"}, marc.Subfield{Code: 'b', Value: "a handbook of agile software craftsmanship /"})
+	book.AddData("264", ' ', '1', marc.Subfield{Code: 'c', Value: "[2009]"})
+	book.AddData("520", ' ', ' ', marc.Subfield{Code: 'a', Value: "Even bad code can function."})
+	book.AddData("650", ' ', '0', marc.Subfield{Code: 'a', Value: "Computer programming."})
+	book.AddData("650", ' ', '0', marc.Subfield{Code: 'a', Value: "Agile software development."})
+	book.AddData("650", ' ', '0', marc.Subfield{Code: 'a', Value: "Software engineering."})
+
+	untitled := &marc.Record{}
+	untitled.AddControl("008", "650101s1965    nyu           000 1 eng d")
+	untitled.AddData("020", ' ', ' ', marc.Subfield{Code: 'a', Value: "9780441172719"})
+	untitled.AddData("100", '1', ' ', marc.Subfield{Code: 'a', Value: "Herbert, Frank."})
+
+	content := marshalMARC(t, book, untitled) + "00042nam a2200000 a 4500garbage\x1d"
+	rules := `[{"match": "computer programming*", "category": "Programming"}, {"match": "Agile*", "ignore": true}]`
+	rec := uploadImport(e, marcImportPath, admin.Token, "catalog.mrc", content, map[string]string{
+		"subject_rules": rules, "unknown_subjects": "create",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, "marc", job.Format)
+	assert.Equal(t, 3, job.TotalRows)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 2, job.Failed)
+	assert.Equal(t, 1, job.CategoriesCreated)
+
+	errors := bookImportErrors(t, e, admin.Token, job.ID)
+	if assert.Len(t, errors, 2) {
+		assert.Equal(t, []string{"2", "9780441172719", "title", "required"}, errors[0][:4])
+		assert.Equal(t, "3", errors[1][0])
+		assert.Equal(t, "marc", errors[1][3])
+	}
+
+	var exported struct {
+		Title       string   `json:"title"`
+		Author      string   `json:"author"`
+		ISBN        string   `json:"isbn"`
+		PublishYear int      `json:"publish_year"`
+		Description string   `json:"description"`
+		Categories  []string `json:"categories"`
+	}
+	content = exportBooks(t, e, admin.Token, "/api/books/export?format=ndjson")
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(content)), &exported))
+	assert.Equal(t, "Clean code: a handbook of agile software craftsmanship", exported.Title)
+	assert.Equal(t, "Martin, Robert C.", exported.Author)
+	assert.Equal(t, "9780132350884", exported.ISBN)
+	assert.Equal(t, 2009, exported.PublishYear)
+	assert.Equal(t, "Even bad code can function.", exported.Description)
+	assert.ElementsMatch(t, []string{"Programming", "Software engineering"}, exported.Categories)
+
+	logs := listAuditLogs(t, e, admin.Token, "action=category.created&target_type=category")
+	var created []auditLogEntry
+	for _, entry := range logs.Data {
+		if entry.Details["import_id"] == fmt.Sprint(job.ID) {
+			created = append(created, entry)
+		}
+	}
+	assert.Len(t, created, 1)
+}
+
+func TestBookImportMARC_XMLUnknownSubjects(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+
+	rec := uploadImport(e, marcImportPath, admin.Token, "cookbook.xml", cookbookMARCXML, map[string]string{"unknown_subjects": "reject"})
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, "marcxml", job.Format)
+	assert.Equal(t, 1, job.Failed)
+	errors := bookImportErrors(t, e, admin.Token, job.ID)
+	if assert.Len(t, errors, 1) {
+		assert.Equal(t, []string{"1", "9780134757599", "subjects", "exists"}, errors[0][:4])
+	}
+
+	rec = uploadImport(e, marcImportPath, admin.Token, "cookbook.xml", cookbookMARCXML, map[string]string{"dry_run": "true", "unknown_subjects": "create"})
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.CategoriesCreated)
+
+	rec = uploadImport(e, marcImportPath, admin.Token, "cookbook.xml", cookbookMARCXML, nil)
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 0, job.CategoriesCreated)
+	books := listBooks(t, e, admin.Token, "")
+	if assert.Len(t, books.Data, 1) {
+		assert.Equal(t, "Mastering the art of French cooking", books.Data[0].Title)
+		assert.Equal(t, 2020, books.Data[0].PublishYear)
+	}
+}
+
+func TestBookImportMARC_Permissions(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	login, user := registerUser(t, e, "rosa@example.com")
+
+	rec := uploadImport(e, marcImportPath, login.Token, "cookbook.xml", cookbookMARCXML, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// MARC files go through the admin endpoint only
+	rec = importBooks(e, login.Token, "cookbook.xml", cookbookMARCXML, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "oneof", passwordRule(t, decodeProblem(t, rec), "format"))
+
+	rec = sendJSON(e, http.MethodPost, "/api/admin/roles", admin.Token, map[string]interface{}{
+		"name":        "cataloguer",
+		"permissions": []string{"book:read", "book:write", "book:write:any"},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = sendJSON(e, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/roles", user.ID), admin.Token, map[string]interface{}{"roles": []string{"cataloguer"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "rosa@example.com", Password: "secret123"})
+	var cataloguer loginResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &cataloguer)
+
+	// Creating categories needs category:write as well
+	rec = uploadImport(e, marcImportPath, cataloguer.Token, "cookbook.xml", cookbookMARCXML, map[string]string{"unknown_subjects": "create"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = uploadImport(e, marcImportPath, cataloguer.Token, "cookbook.xml", cookbookMARCXML, nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, decodeBookImport(t, rec).Created)
+
+	rec = uploadImport(e, marcImportPath, cataloguer.Token, "books.csv", "title\nDune\n", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "oneof", passwordRule(t, decodeProblem(t, rec), "format"))
+}
+
+func TestBookImportMARC_RejectsInvalidSubjectRules(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+
+	rules := fmt.Sprintf(`[{"match": "Cooking*", "ignore": true}, {"match": %q}]`, strings.Repeat("a*", 101))
+	rec := uploadImport(e, marcImportPath, admin.Token, "cookbook.xml", cookbookMARCXML, map[string]string{"subject_rules": rules})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	problem := decodeProblem(t, rec)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "subject_rules[1].match", problem.Errors[0].Field)
+		assert.Equal(t, "max", problem.Errors[0].Rule)
+	}
+}
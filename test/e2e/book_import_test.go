@@ -0,0 +1,269 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type bookImportResponse struct {
+	ID                uint   `json:"id"`
+	Format            string `json:"format"`
+	Mode              string `json:"mode"`
+	DryRun            bool   `json:"dry_run"`
+	Status            string `json:"status"`
+	TotalRows         int    `json:"total_rows"`
+	Processed         int    `json:"processed_rows"`
+	Created           int    `json:"created"`
+	Updated           int    `json:"updated"`
+	Failed            int    `json:"failed"`
+	CategoriesCreated int    `json:"categories_created"`
+	Error             string `json:"error"`
+}
+
+func importBooks(e *echo.Echo, accessToken, filename, content string, fields map[string]string) *httptest.ResponseRecorder {
+	return uploadImport(e, "/api/books/import", accessToken, filename, content, fields)
+}
+
+func uploadImport(e *echo.Echo, path, accessToken, filename, content string, fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write([]byte(content))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeBookImport(t *testing.T, rec *httptest.ResponseRecorder) bookImportResponse {
+	var job bookImportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Failed to decode book import: %v", err)
+	}
+	return job
+}
+
+func bookImportErrors(t *testing.T, e *echo.Echo, accessToken string, id uint) [][]string {
+	rec := sendJSON(e, http.MethodGet, fmt.Sprintf("/api/books/imports/%d/errors", id), accessToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to download import errors: %d %s", rec.Code, rec.Body.String())
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse import errors: %v", err)
+	}
+	return records[1:]
+}
+
+func TestBookImport_CSVWithMapping(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": "Programming"})
+	login, _ := registerUser(t, e, "ivan@example.com")
+
+	content := "Judul,Penulis,ISBN,Tahun,Kategori\n" +
+		"Clean Code,Robert C. Martin,978-0-13-235088-4,2008,Programming\n" +
+		"Refactoring,Martin Fowler,978-0-13-468599-1,2018,\n" +
+		",Nobody,9780201633610,1994,\n" +
+		"Unknown,Somebody,9780306406157,soon,\n" +
+		"Missing,Somebody,9780441172719,1965,Cooking\n" +
+		"Again,Robert C. Martin,9780132350884,2008,\n"
+	mapping := `{"Judul":"title","Penulis":"author","Tahun":"publish_year","Kategori":"categories"}`
+	rec := importBooks(e, login.Token, "books.csv", content, map[string]string{"mapping": mapping})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, fmt.Sprintf("/api/books/imports/%d", job.ID), rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, "csv", job.Format)
+	assert.Equal(t, "best_effort", job.Mode)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, 6, job.TotalRows)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 4, job.Failed)
+
+	books := listBooks(t, e, login.Token, "?sort=title")
+	if assert.Len(t, books.Data, 2) {
+		assert.Equal(t, "Clean Code", books.Data[0].Title)
+		assert.Equal(t, "Refactoring", books.Data[1].Title)
+	}
+
+	// The report lists every rejected row by its line in the file
+	report := bookImportErrors(t, e, login.Token, job.ID)
+	assert.Equal(t, [][]string{
+		{"4", "9780201633610", "title", "required", "title is required"},
+		{"5", "9780306406157", "publish_year", "numeric", "publish_year must be a number"},
+		{"6", "9780441172719", "categories", "exists", `category "Cooking" does not exist`},
+		{"7", "9780132350884", "isbn", "duplicate", "ISBN already appears on line 2"},
+	}, report)
+
+	// Imports are only visible to the user who started them
+	other, _ := registerUser(t, e, "judy@example.com")
+	rec = sendJSON(e, http.MethodGet, fmt.Sprintf("/api/books/imports/%d", job.ID), other.Token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestBookImport_NDJSONDryRunAndUpsert(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "kate@example.com")
+	rec := createBook(t, e, login.Token, map[string]interface{}{
+		"title": "Dune", "author": "Frank Herbert", "isbn": "9780441172719", "publish_year": 1965,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	content := `{"title":"Dune","author":"Frank Herbert","isbn":"0441172717","publish_year":1966,"description":"Second printing"}` + "\n" +
+		"\n" +
+		`{"title":"Clean Code","author":"Robert C. Martin","isbn":"9780132350884","publish_year":2008}` + "\n" +
+		`{"title": 42}` + "\n"
+
+	// A dry run reports what would happen without writing anything
+	rec = importBooks(e, login.Token, "books.ndjson", content, map[string]string{"dry_run": "true", "upsert": "true"})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	job := decodeBookImport(t, rec)
+	assert.True(t, job.DryRun)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	assert.Equal(t, 1, job.Failed)
+	assert.Equal(t, int64(1), listBooks(t, e, login.Token, "").Meta.Total)
+
+	report := bookImportErrors(t, e, login.Token, job.ID)
+	if assert.Len(t, report, 1) {
+		assert.Equal(t, "4", report[0][0])
+		assert.Equal(t, "json", report[0][3])
+	}
+
+	// Without upsert existing ISBNs are rejected
+	rec = importBooks(e, login.Token, "books.ndjson", content, nil)
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 0, job.Updated)
+	assert.Equal(t, 2, job.Failed)
+
+	rec = importBooks(e, login.Token, "books.ndjson", content, map[string]string{"upsert": "true"})
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, 0, job.Created)
+	assert.Equal(t, 2, job.Updated)
+	books := listBooks(t, e, login.Token, "?sort=title")
+	if assert.Len(t, books.Data, 2) {
+		assert.Equal(t, "Dune", books.Data[1].Title)
+		assert.Equal(t, 1966, books.Data[1].PublishYear)
+	}
+
+	// Books of other users cannot be overwritten
+	other, _ := registerUser(t, e, "liam@example.com")
+	rec = importBooks(e, other.Token, "books.ndjson", content, map[string]string{"upsert": "true"})
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, 0, job.Updated)
+	assert.Equal(t, 3, job.Failed)
+}
+
+func TestBookImport_AtomicRollsBack(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "mona@example.com")
+	content := "title,author,isbn,publish_year\n" +
+		"Clean Code,Robert C. Martin,9780132350884,2008\n" +
+		"Broken,Somebody,978-0-13-468599-2,2018\n"
+
+	rec := importBooks(e, login.Token, "books.csv", content, map[string]string{"mode": "atomic"})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, "failed", job.Status)
+	assert.Equal(t, 0, job.Created)
+	assert.Equal(t, 1, job.Failed)
+	assert.Contains(t, job.Error, "no books were imported")
+	assert.Equal(t, int64(0), listBooks(t, e, login.Token, "").Meta.Total)
+
+	rec = importBooks(e, login.Token, "books.csv", strings.Split(content, "Broken")[0], map[string]string{"mode": "atomic"})
+	job = decodeBookImport(t, rec)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, int64(1), listBooks(t, e, login.Token, "").Meta.Total)
+}
+
+func TestBookImport_BackgroundJob(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "nina@example.com")
+	isbns := []string{"9780134685991", "9780201633610", "9780132350884", "9781491950357", "9780596007126", "9780134190440", "9780306406157"}
+	content := "title,author,isbn,publish_year\n"
+	for i, isbn := range isbns {
+		content += fmt.Sprintf("Book %d,Author,%s,2000\n", i, isbn)
+	}
+
+	rec := importBooks(e, login.Token, "books.csv", content, nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	job := decodeBookImport(t, rec)
+	assert.Equal(t, "pending", job.Status)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status != "completed" && job.Status != "failed" && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		rec = sendJSON(e, http.MethodGet, rec.Header().Get(echo.HeaderLocation), login.Token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Failed to poll import: %d %s", rec.Code, rec.Body.String())
+		}
+		job = decodeBookImport(t, rec)
+	}
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, len(isbns), job.Processed)
+	assert.Equal(t, len(isbns), job.Created)
+	assert.Equal(t, int64(len(isbns)), listBooks(t, e, login.Token, "").Meta.Total)
+}
+
+func TestBookImport_RejectsInvalidFiles(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "omar@example.com")
+
+	rec := importBooks(e, login.Token, "books.txt", "title\n", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "required", passwordRule(t, decodeProblem(t, rec), "format"))
+
+	rec = importBooks(e, login.Token, "books.csv", "title,author\nDune,Frank Herbert\n", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "required", passwordRule(t, decodeProblem(t, rec), "mapping"))
+
+	rec = importBooks(e, login.Token, "books.csv", "title,author,isbn,publish_year\n", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "empty_import_file", decodeProblem(t, rec).Code)
+}
@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"dot-be-go/pkg/booklookup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLookup counts the lookups reaching the metadata provider and can
+// simulate an outage
+type countingLookup struct {
+	provider booklookup.Provider
+	calls    int
+	down     bool
+}
+
+func (l *countingLookup) Name() string {
+	return l.provider.Name()
+}
+
+func (l *countingLookup) Lookup(ctx context.Context, isbn string) (*booklookup.Metadata, error) {
+	l.calls++
+	if l.down {
+		return nil, errors.New("connection refused")
+	}
+	return l.provider.Lookup(ctx, isbn)
+}
+
+// bookLookup is the metadata provider of the current test environment
+var bookLookup *countingLookup
+
+var lookupFixtures = booklookup.Fixtures{
+	"9780132350884": {
+		Title:       "Clean Code: A Handbook of Agile Software Craftsmanship",
+		Authors:     []string{"Robert C. Martin"},
+		PublishYear: 2008,
+		Description: "Even bad code can function.",
+		Subjects:    []string{"Programming", "Software engineering"},
+	},
+	"9780441172719": {
+		Title:       "Dune",
+		Authors:     []string{"Frank Herbert"},
+		PublishYear: 1965,
+		Subjects:    []string{"Science Fiction"},
+	},
+}
+
+type bookLookupResponse struct {
+	ISBN        string   `json:"isbn"`
+	Title       string   `json:"title"`
+	Author      string   `json:"author"`
+	PublishYear int      `json:"publish_year"`
+	Description string   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	CategoryIDs []uint   `json:"category_ids"`
+	Source      string   `json:"source"`
+}
+
+func TestBookLookup(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	sendJSON(e, http.MethodPost, "/api/admin/categories", admin.Token, map[string]string{"name": "Programming"})
+	login, _ := registerUser(t, e, "sam@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/books/lookup", login.Token, map[string]string{"isbn": "0-13-235088-2"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var result bookLookupResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, "9780132350884", result.ISBN)
+	assert.Equal(t, "Clean Code: A Handbook of Agile Software Craftsmanship", result.Title)
+	assert.Equal(t, "Robert C. Martin", result.Author)
+	assert.Equal(t, 2008, result.PublishYear)
+	assert.Equal(t, []string{"Programming", "Software engineering"}, result.Subjects)
+	assert.Equal(t, "fixture", result.Source)
+	if assert.Len(t, result.CategoryIDs, 1) {
+		assert.Equal(t, categoryIDOf(t, e, "Programming"), fmt.Sprint(result.CategoryIDs[0]))
+	}
+
+	// Answers, including unknown ISBNs, are cached
+	sendJSON(e, http.MethodPost, "/api/books/lookup", login.Token, map[string]string{"isbn": "9780132350884"})
+	assert.Equal(t, 1, bookLookup.calls)
+	for i := 0; i < 2; i++ {
+		rec = sendJSON(e, http.MethodPost, "/api/books/lookup", login.Token, map[string]string{"isbn": "9780134757599"})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "book_metadata_not_found", decodeProblem(t, rec).Code)
+	}
+	assert.Equal(t, 2, bookLookup.calls)
+
+	rec = sendJSON(e, http.MethodPost, "/api/books/lookup", login.Token, map[string]string{"isbn": "1234567890"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "isbn", passwordRule(t, decodeProblem(t, rec), "isbn"))
+
+	bookLookup.down = true
+	rec = sendJSON(e, http.MethodPost, "/api/books/lookup", login.Token, map[string]string{"isbn": "9780201633610"})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "book_lookup_unavailable", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/books/lookup", "", map[string]string{"isbn": "9780132350884"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestBookCreate_Autofill(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "tara@example.com")
+
+	// Fields given in the request win over the looked up ones
+	rec := createBook(t, e, login.Token, map[string]interface{}{
+		"isbn": "9780441172719", "title": "Dune (40th Anniversary Edition)", "autofill": true,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var book struct {
+		ID          uint   `json:"id"`
+		Title       string `json:"title"`
+		Author      string `json:"author"`
+		PublishYear int    `json:"publish_year"`
+		Description string `json:"description"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &book))
+	assert.Equal(t, "Dune (40th Anniversary Edition)", book.Title)
+	assert.Equal(t, "Frank Herbert", book.Author)
+	assert.Equal(t, 1965, book.PublishYear)
+
+	rec = sendJSON(e, http.MethodPut, fmt.Sprintf("/api/books/%d", book.ID), login.Token, map[string]interface{}{
+		"isbn": "9780132350884", "publish_year": 2009, "autofill": true,
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &book))
+	assert.Equal(t, "Clean Code: A Handbook of Agile Software Craftsmanship", book.Title)
+	assert.Equal(t, 2009, book.PublishYear)
+	assert.Equal(t, "Even bad code can function.", book.Description)
+
+	// Without autofill, or for unknown ISBNs, the fields stay required
+	rec = createBook(t, e, login.Token, map[string]interface{}{"isbn": "9780441172719"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = createBook(t, e, login.Token, map[string]interface{}{"isbn": "9780134757599", "autofill": true})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "required", passwordRule(t, decodeProblem(t, rec), "title"))
+}
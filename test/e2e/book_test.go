@@ -0,0 +1,199 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type bookListResponse struct {
+	Data []struct {
+		ID          uint   `json:"id"`
+		Title       string `json:"title"`
+		PublishYear int    `json:"publish_year"`
+	} `json:"data"`
+	Meta struct {
+		Total      int64  `json:"total"`
+		Page       int    `json:"page"`
+		Limit      int    `json:"limit"`
+		NextCursor string `json:"next_cursor"`
+	} `json:"meta"`
+	Links struct {
+		Next string `json:"next"`
+		Prev string `json:"prev"`
+	} `json:"links"`
+}
+
+func createBook(t *testing.T, e *echo.Echo, accessToken string, body map[string]interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/books", bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func listBooks(t *testing.T, e *echo.Echo, accessToken, query string) bookListResponse {
+	req := httptest.NewRequest(http.MethodGet, "/api/books"+query, nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to list books: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var response bookListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode book list: %v", err)
+	}
+	return response
+}
+
+func TestListBooks_PaginationAndFilters(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	isbns := []string{"9780134685991", "9780201633610", "9780132350884", "9781491950357", "9780596007126"}
+	for i, isbn := range isbns {
+		rec := createBook(t, e, login.Token, map[string]interface{}{
+			"title":        fmt.Sprintf("Book %d", i),
+			"author":       "Author",
+			"isbn":         isbn,
+			"publish_year": 2000 + i,
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	first := listBooks(t, e, login.Token, "?limit=2&sort=-publish_year")
+	assert.Equal(t, int64(5), first.Meta.Total)
+	assert.Len(t, first.Data, 2)
+	assert.Equal(t, 2004, first.Data[0].PublishYear)
+	assert.NotEmpty(t, first.Links.Next)
+	assert.Empty(t, first.Links.Prev)
+
+	second := listBooks(t, e, login.Token, "?limit=2&sort=-publish_year&cursor="+first.Meta.NextCursor)
+	assert.Len(t, second.Data, 2)
+	assert.Equal(t, 2002, second.Data[0].PublishYear)
+
+	filtered := listBooks(t, e, login.Token, "?publish_year_from=2001&publish_year_to=2003")
+	assert.Equal(t, int64(3), filtered.Meta.Total)
+
+	// LIKE wildcards in filters match themselves only
+	assert.Equal(t, int64(5), listBooks(t, e, login.Token, "?author=uth").Meta.Total)
+	assert.Equal(t, int64(0), listBooks(t, e, login.Token, "?author=_").Meta.Total)
+	assert.Equal(t, int64(0), listBooks(t, e, login.Token, "?author=%25").Meta.Total)
+}
+
+func TestListBooks_InvalidSort(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	req := httptest.NewRequest(http.MethodGet, "/api/books?sort=password", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+login.Token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSearchBooks_RanksAndHighlights(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "The Go Programming Language",
+		"author":       "Donovan",
+		"isbn":         "9780134190440",
+		"publish_year": 2015,
+	})
+	createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "Clean Code",
+		"author":       "Martin",
+		"isbn":         "9780132350884",
+		"publish_year": 2008,
+		"description":  "Examples are in Java, not Go",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/books/search?q=go", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+login.Token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data []struct {
+			Title      string            `json:"title"`
+			Highlights map[string]string `json:"highlights"`
+		} `json:"data"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), response.Meta.Total)
+	assert.Equal(t, "The Go Programming Language", response.Data[0].Title)
+	assert.Equal(t, "The <mark>Go</mark> Programming Language", response.Data[0].Highlights["title"])
+}
+
+func TestCreateBook_NormalizesISBN(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	rec := createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "Effective Java",
+		"author":       "Bloch",
+		"isbn":         "0-13-468599-7",
+		"publish_year": 2018,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var book struct {
+		ISBN        string `json:"isbn"`
+		ISBNDisplay string `json:"isbn_display"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &book)
+	assert.NoError(t, err)
+	assert.Equal(t, "9780134685991", book.ISBN)
+	assert.Equal(t, "978-0-13-468599-1", book.ISBNDisplay)
+
+	// The same book in another notation collides with the canonical ISBN
+	rec = createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "Effective Java",
+		"author":       "Bloch",
+		"isbn":         "978-0-13-468599-1",
+		"publish_year": 2018,
+	})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "isbn_taken", decodeProblem(t, rec).Code)
+
+	rec = createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "Broken",
+		"author":       "Nobody",
+		"isbn":         "978-0-13-468599-2",
+		"publish_year": 2018,
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
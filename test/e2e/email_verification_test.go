@@ -0,0 +1,146 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// verificationToken returns the token of the last verification link mailed
+// to an address
+func verificationToken(t *testing.T, email string) string {
+	link, err := url.Parse(mailToken(t, email))
+	if err != nil {
+		t.Fatalf("Failed to parse verification link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestEmailVerification_UnverifiedUserIsLimited(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "niaj@example.com",
+		"password": "secret123",
+		"name":     "Niaj",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"email_verified_at":null`)
+
+	var session loginResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+
+	book := map[string]interface{}{
+		"title":        "Unverified Book",
+		"author":       "Author",
+		"isbn":         "9780306406157",
+		"publish_year": 2020,
+	}
+	rec = sendJSON(e, http.MethodGet, "/api/books", session.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = sendJSON(e, http.MethodPost, "/api/books", session.Token, book)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": verificationToken(t, "niaj@example.com")})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"email_verified_at":null`)
+
+	// Full permissions apply from the next token
+	rec = postRefreshToken(e, "/api/auth/refresh", session.RefreshToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+
+	rec = sendJSON(e, http.MethodPost, "/api/books", session.Token, book)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestEmailVerification_Audited(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "quinn@example.com",
+		"password": "secret123",
+		"name":     "Quinn",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var registered struct {
+		User userResponse `json:"user"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &registered))
+
+	// Verifying again is accepted but changes nothing
+	verifyToken := verificationToken(t, "quinn@example.com")
+	for i := 0; i < 2; i++ {
+		rec = sendJSON(e, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": verifyToken})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	admin := loginAsAdmin(t, e)
+	entries := listAuditLogs(t, e, admin.Token, fmt.Sprintf("action=auth.email_verified&target_type=user&target_id=%d", registered.User.ID))
+	if assert.Len(t, entries.Data, 1) {
+		entry := entries.Data[0]
+		if assert.NotNil(t, entry.ActorID) {
+			assert.Equal(t, registered.User.ID, *entry.ActorID)
+		}
+		assert.NotEmpty(t, entry.IP)
+		assert.Nil(t, entry.Changes["email_verified_at"].Before)
+		assert.NotNil(t, entry.Changes["email_verified_at"].After)
+	}
+}
+
+func TestEmailVerification_InvalidToken(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "olivia@example.com",
+		"password": "secret123",
+		"name":     "Olivia",
+	})
+	verifyToken := verificationToken(t, "olivia@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": verifyToken + "x"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_verification_token", decodeProblem(t, rec).Code)
+}
+
+func TestEmailVerification_Resend(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "peggy@example.com",
+		"password": "secret123",
+		"name":     "Peggy",
+	})
+	sent := len(sentMail.messages)
+
+	unverified := sendJSON(e, http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": "peggy@example.com"})
+	verified := sendJSON(e, http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": "admin@example.com"})
+	unknown := sendJSON(e, http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, unverified.Code)
+	assert.Equal(t, unverified.Body.String(), verified.Body.String())
+	assert.Equal(t, unverified.Body.String(), unknown.Body.String())
+	assert.Len(t, sentMail.messages, sent+1)
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": verificationToken(t, "peggy@example.com")})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,124 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type problemResponse struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id"`
+	Errors    []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"errors"`
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problemResponse {
+	assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+
+	var problem problemResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem response: %v", err)
+	}
+	return problem
+}
+
+func TestErrorResponse_NotFoundProblem(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	req := httptest.NewRequest(http.MethodGet, "/api/books/999999", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+login.Token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "book_not_found", problem.Code)
+	assert.Equal(t, "urn:dot-be-go:problem:book_not_found", problem.Type)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "/api/books/999999", problem.Instance)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), problem.RequestID)
+}
+
+func TestErrorResponse_ValidationProblem(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	rec := createBook(t, e, login.Token, map[string]interface{}{
+		"title":        "Broken",
+		"author":       "Nobody",
+		"isbn":         "978-0-13-468599-2",
+		"publish_year": 2018,
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "validation_failed", problem.Code)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "isbn", problem.Errors[0].Field)
+		assert.Equal(t, "isbn", problem.Errors[0].Rule)
+	}
+}
+
+func TestRegister_DuplicateEmailConflict(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	body := []byte(`{"email": "admin@example.com", "password": "secret123", "name": "Another Admin"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "email_taken", decodeProblem(t, rec).Code)
+}
+
+func TestCreateCategory_DuplicateNameConflict(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	createCategory := func() *httptest.ResponseRecorder {
+		body := []byte(`{"name": "Fiction", "description": "Novels"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/categories", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+login.Token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusCreated, createCategory().Code)
+
+	rec := createCategory()
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "category_name_taken", decodeProblem(t, rec).Code)
+}
@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dot-be-go/pkg/jwt"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// signingKeys is the key ring of the current test environment
+var signingKeys *jwt.KeyRing
+
+func TestJWKS_PublishesSigningKey(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var set jwt.JWKSet
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	if !assert.Len(t, set.Keys, 1) {
+		return
+	}
+	assert.Equal(t, "OKP", set.Keys[0].KeyType)
+	assert.Equal(t, "EdDSA", set.Keys[0].Algorithm)
+
+	// Access tokens name the published key in their kid header
+	login := loginAsAdmin(t, e)
+	parsed, _, err := gojwt.NewParser().ParseUnverified(login.Token, &jwt.JWTClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+	assert.Equal(t, set.Keys[0].KeyID, parsed.Header["kid"])
+}
+
+func TestJWKS_RejectsOtherAlgorithms(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login := loginAsAdmin(t, e)
+	parsed, _, err := gojwt.NewParser().ParseUnverified(login.Token, &jwt.JWTClaims{})
+	assert.NoError(t, err)
+	claims := parsed.Claims.(*jwt.JWTClaims)
+
+	// The same claims signed with the default HMAC secret or unsigned are refused
+	hmacToken, _ := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte("mySecretKey"))
+	unsigned, _ := gojwt.NewWithClaims(gojwt.SigningMethodNone, claims).SignedString(gojwt.UnsafeAllowNoneSignatureType)
+	for _, token := range []string{hmacToken, unsigned} {
+		rec := getProfile(e, token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "invalid_token", decodeProblem(t, rec).Code)
+	}
+
+	assert.Equal(t, http.StatusOK, getProfile(e, login.Token).Code)
+}
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"dot-be-go/migrations"
+	"dot-be-go/pkg/migrate"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testMigrator returns a migrator for the test database with the embedded
+// migrations, changed by edit
+func testMigrator(t *testing.T, edit func(list []migrate.Migration) []migrate.Migration) *migrate.Migrator {
+	_, db, _ := setupTestEnvironment(t)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	list, err := migrate.Load(migrations.FS, "postgres")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	migrator, err := migrate.New(sqlDB, "postgres", edit(list))
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	return migrator
+}
+
+func TestMigrate_UpIgnoresNewerAppliedMigrations(t *testing.T) {
+	// An older release lacks the latest migration the database already has
+	migrator := testMigrator(t, func(list []migrate.Migration) []migrate.Migration {
+		return list[:len(list)-1]
+	})
+
+	done, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+}
+
+func TestMigrate_DownRejectsEditedMigrations(t *testing.T) {
+	migrator := testMigrator(t, func(list []migrate.Migration) []migrate.Migration {
+		last := &list[len(list)-1]
+		last.Checksum = "edited"
+		last.Down = "SELECT 1;"
+		return list
+	})
+
+	done, err := migrator.Down(context.Background(), 1)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	}
+	assert.Empty(t, done)
+}
@@ -0,0 +1,161 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"dot-be-go/pkg/oidc/oidctest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// oidcIssuer is the fake OpenID Connect provider of the current test environment
+var oidcIssuer *oidctest.Server
+
+type identityResponse struct {
+	ID       uint   `json:"id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+// oidcCallback signs user in at the fake provider and returns the response of
+// the callback endpoint
+func oidcCallback(t *testing.T, e *echo.Echo, user oidctest.User) (int, []byte) {
+	rec := sendJSON(e, http.MethodGet, "/api/auth/oidc/fake/authorize", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to start OIDC login: %d %s", rec.Code, rec.Body.String())
+	}
+	var authorization struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &authorization)
+
+	oidcIssuer.SetUser(user)
+	code, state, err := oidcIssuer.Authorize(authorization.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("Failed to authorize at the provider: %v", err)
+	}
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/oidc/fake/callback", "", map[string]string{"code": code, "state": state})
+	return rec.Code, rec.Body.Bytes()
+}
+
+func TestOIDC_CreatesUserAndLinksIdentity(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	rec := sendJSON(e, http.MethodGet, "/api/auth/oidc/providers", "", nil)
+	assert.JSONEq(t, `{"providers":["fake"]}`, rec.Body.String())
+
+	user := oidctest.User{Subject: "ada-1", Email: "ada@example.com", EmailVerified: true, Name: "Ada Lovelace"}
+	status, body := oidcCallback(t, e, user)
+	assert.Equal(t, http.StatusOK, status)
+	var login loginResponse
+	_ = json.Unmarshal(body, &login)
+	assert.NotEmpty(t, login.Token)
+
+	rec = getProfile(e, login.Token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"email":"ada@example.com"`)
+
+	// The second login finds the same user through the linked identity
+	status, _ = oidcCallback(t, e, user)
+	assert.Equal(t, http.StatusOK, status)
+
+	rec = sendJSON(e, http.MethodGet, "/api/profile/identities", login.Token, nil)
+	var identities []identityResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &identities)
+	if assert.Len(t, identities, 1) {
+		assert.Equal(t, "fake", identities[0].Provider)
+		assert.Equal(t, "ada-1", identities[0].Subject)
+
+		// Users without a password cannot unlink their only identity
+		rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/profile/identities/%d", identities[0].ID), login.Token, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "last_login_method", decodeProblem(t, rec).Code)
+	}
+
+	// Password logins fail for users created through a provider
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "ada@example.com", Password: ""})
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+}
+
+func TestOIDC_LinksVerifiedAccountByEmail(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	status, body := oidcCallback(t, e, oidctest.User{Subject: "admin-1", Email: "admin@example.com", EmailVerified: true})
+	assert.Equal(t, http.StatusOK, status)
+	var login loginResponse
+	_ = json.Unmarshal(body, &login)
+
+	rec := getProfile(e, login.Token)
+	assert.Contains(t, rec.Body.String(), `"email":"admin@example.com"`)
+
+	rec = sendJSON(e, http.MethodGet, "/api/profile/identities", login.Token, nil)
+	var identities []identityResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &identities)
+	if assert.Len(t, identities, 1) {
+		rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/profile/identities/%d", identities[0].ID), login.Token, nil)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+}
+
+func TestOIDC_RefusesUnverifiedEmails(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	// The provider does not vouch for the address
+	status, body := oidcCallback(t, e, oidctest.User{Subject: "eve-1", Email: "admin@example.com"})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, string(body), "oidc_email_unverified")
+
+	// The local account never proved it owns the address
+	rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email": "pending@example.com", "password": "secret123", "name": "Pending User",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	status, body = oidcCallback(t, e, oidctest.User{Subject: "pending-1", Email: "pending@example.com", EmailVerified: true})
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, string(body), "account_link_unverified")
+}
+
+func TestOIDC_StateIsSingleUse(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	rec := sendJSON(e, http.MethodGet, "/api/auth/oidc/fake/authorize", "", nil)
+	var authorization struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &authorization)
+
+	oidcIssuer.SetUser(oidctest.User{Subject: "ada-1", Email: "ada@example.com", EmailVerified: true})
+	code, state, err := oidcIssuer.Authorize(authorization.AuthorizationURL)
+	assert.NoError(t, err)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/oidc/fake/callback", "", map[string]string{"code": code, "state": state})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/oidc/fake/callback", "", map[string]string{"code": code, "state": state})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_oidc_state", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodGet, "/api/auth/oidc/unknown/authorize", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
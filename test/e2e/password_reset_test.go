@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"dot-be-go/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordReset_DoesNotRevealEmail(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	registerUser(t, e, "ivan@example.com")
+	sent := len(sentMail.messages)
+
+	known := sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "ivan@example.com"})
+	unknown := sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, known.Code)
+	assert.Equal(t, known.Code, unknown.Code)
+	assert.Equal(t, known.Body.String(), unknown.Body.String())
+	assert.Len(t, sentMail.messages, sent+1)
+}
+
+func TestPasswordReset_Flow(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	session, _ := registerUser(t, e, "judy@example.com")
+
+	// Requesting a new token invalidates the previous one
+	sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "judy@example.com"})
+	first := mailToken(t, "judy@example.com")
+	sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "judy@example.com"})
+	second := mailToken(t, "judy@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": first, "password": "newsecret123"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_reset_token", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": second, "password": "newsecret123"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// Tokens are single use and the reset signs out every session
+	rec = sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": second, "password": "another123"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, session.Token).Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "judy@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "judy@example.com", Password: "newsecret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPasswordReset_ExpiredToken(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	registerUser(t, e, "mallory@example.com")
+	sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "mallory@example.com"})
+	resetToken := mailToken(t, "mallory@example.com")
+
+	db.Model(&entity.PasswordResetToken{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute))
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": resetToken, "password": "newsecret123"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"dot-be-go/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// breachedPasswordHash is the SHA-1 of "letmein12345", the only entry of the
+// breached password list used in the tests
+const breachedPasswordHash = "3533DC31B5B114D597E3AA2D198BC0965D17905F"
+
+// passwordRule returns the rule a validation problem reports for a field
+func passwordRule(t *testing.T, problem problemResponse, field string) string {
+	for _, fieldErr := range problem.Errors {
+		if fieldErr.Field == field {
+			return fieldErr.Rule
+		}
+	}
+	t.Fatalf("No validation error for %s: %+v", field, problem.Errors)
+	return ""
+}
+
+func TestPassword_LoginUpgradesLegacyHash(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	var admin entity.User
+	db.Where("email = ?", "admin@example.com").First(&admin)
+	assert.True(t, strings.HasPrefix(admin.Password, "$2a$"))
+
+	// A failed login leaves the hash alone
+	rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "admin@example.com", Password: "wrong-password"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	db.Where("email = ?", "admin@example.com").First(&admin)
+	assert.True(t, strings.HasPrefix(admin.Password, "$2a$"))
+
+	loginAsAdmin(t, e)
+	db.Where("email = ?", "admin@example.com").First(&admin)
+	assert.True(t, strings.HasPrefix(admin.Password, "$argon2id$v=19$m=8192,t=1,p=1$"), admin.Password)
+
+	// The upgraded hash keeps working and is not replaced again
+	upgraded := admin.Password
+	loginAsAdmin(t, e)
+	db.Where("email = ?", "admin@example.com").First(&admin)
+	assert.Equal(t, upgraded, admin.Password)
+}
+
+func TestPassword_RegisterPolicy(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	tests := map[string]string{
+		"short1":           "min",
+		"tamsin-rocks-123": "contains_email",
+		"letmein12345":     "breached",
+	}
+	for password, rule := range tests {
+		rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+			"email":    "tamsin@example.com",
+			"password": password,
+			"name":     "Tamsin",
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, password)
+		assert.Equal(t, rule, passwordRule(t, decodeProblem(t, rec), "password"), password)
+	}
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    "tamsin@example.com",
+		"password": "secret123",
+		"name":     "Tamsin",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestPassword_ChangeAndResetPolicy(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "ursula@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/profile/password", login.Token, map[string]string{
+		"current_password": "secret123",
+		"new_password":     "letmein12345",
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "breached", passwordRule(t, decodeProblem(t, rec), "new_password"))
+
+	// A rejected password does not use up the reset token
+	sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "ursula@example.com"})
+	resetToken := mailToken(t, "ursula@example.com")
+	rec = sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": resetToken, "password": "Ursula@Example.com!"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "contains_email", passwordRule(t, decodeProblem(t, rec), "password"))
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/reset-password", "", map[string]string{"token": resetToken, "password": "newsecret123"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	loginAs(t, e, "ursula@example.com", "newsecret123")
+}
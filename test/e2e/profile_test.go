@@ -0,0 +1,186 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"dot-be-go/internal/domain/entity"
+	"dot-be-go/pkg/mailer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMailer keeps sent messages in memory so tests can read tokens
+type recordingMailer struct {
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// sentMail is the mailer of the current test environment
+var sentMail *recordingMailer
+
+// mailToken returns the token of the last message sent to an address. Tokens
+// are on their own paragraph after the instructions.
+func mailToken(t *testing.T, to string) string {
+	for i := len(sentMail.messages) - 1; i >= 0; i-- {
+		if msg := sentMail.messages[i]; msg.To == to {
+			parts := strings.Split(msg.Body, "\n\n")
+			if len(parts) < 2 {
+				t.Fatalf("No token in mail to %s: %q", to, msg.Body)
+			}
+			return parts[1]
+		}
+	}
+	t.Fatalf("No mail sent to %s", to)
+	return ""
+}
+
+func TestProfile_UpdateName(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, user := registerUser(t, e, "erin@example.com")
+
+	rec := sendJSON(e, http.MethodPatch, "/api/profile", login.Token, map[string]string{"name": "Erin Smith"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"Erin Smith"`)
+
+	rec = sendJSON(e, http.MethodPatch, "/api/profile", login.Token, map[string]string{"name": "E"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	entries := listAuditLogs(t, e, loginAsAdmin(t, e).Token, fmt.Sprintf("action=auth.profile_updated&target_id=%d", user.ID))
+	if assert.Len(t, entries.Data, 1) {
+		assert.Equal(t, "Regular User", entries.Data[0].Changes["name"].Before)
+		assert.Equal(t, "Erin Smith", entries.Data[0].Changes["name"].After)
+	}
+}
+
+func TestProfile_ChangePasswordRevokesOtherSessions(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	current, _ := registerUser(t, e, "frank@example.com")
+	other := loginAs(t, e, "frank@example.com", "secret123")
+
+	rec := sendJSON(e, http.MethodPost, "/api/profile/password", current.Token, map[string]string{
+		"current_password": "wrong-password",
+		"new_password":     "newsecret123",
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/password", current.Token, map[string]string{
+		"current_password": "secret123",
+		"new_password":     "newsecret123",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusOK, getProfile(e, current.Token).Code)
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, other.Token).Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/refresh", "", refreshRequest{RefreshToken: current.RefreshToken})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "frank@example.com", Password: "newsecret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestProfile_ChangeEmail(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, user := registerUser(t, e, "grace@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/profile/email", login.Token, map[string]string{
+		"email":    "admin@example.com",
+		"password": "secret123",
+	})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/email", login.Token, map[string]string{
+		"email":    "grace.new@example.com",
+		"password": "secret123",
+	})
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	entries := listAuditLogs(t, e, loginAsAdmin(t, e).Token, fmt.Sprintf("action=auth.email_change_requested&target_id=%d", user.ID))
+	if assert.Len(t, entries.Data, 1) {
+		assert.Equal(t, "grace.new@example.com", entries.Data[0].Changes["pending_email"].After)
+	}
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/email/confirm", login.Token, map[string]string{"token": "bogus"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_email_token", decodeProblem(t, rec).Code)
+
+	token := mailToken(t, "grace.new@example.com")
+	rec = sendJSON(e, http.MethodPost, "/api/profile/email/confirm", login.Token, map[string]string{"token": token})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"email":"grace.new@example.com"`)
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/email/confirm", login.Token, map[string]string{"token": token})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "grace.new@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestProfile_DeleteAccount(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, user := registerUser(t, e, "heidi@example.com")
+	rec := sendJSON(e, http.MethodPost, "/api/books", login.Token, map[string]interface{}{
+		"title":        "Kept Book",
+		"author":       "Author",
+		"isbn":         "9780306406157",
+		"publish_year": 2020,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = sendJSON(e, http.MethodDelete, "/api/profile", login.Token, map[string]string{"password": "wrong-password"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = sendJSON(e, http.MethodDelete, "/api/profile", login.Token, map[string]string{
+		"password": "secret123",
+		"books":    "keep",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, login.Token).Code)
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "heidi@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	var books int64
+	db.Model(&entity.Book{}).Where("user_id = ?", user.ID).Count(&books)
+	assert.Equal(t, int64(1), books)
+}
+
+func TestProfile_LastAdminCannotDeleteAccount(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	rec := sendJSON(e, http.MethodDelete, "/api/profile", admin.Token, map[string]string{"password": "admin123"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "last_admin", decodeProblem(t, rec).Code)
+}
@@ -0,0 +1,198 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type userResponse struct {
+	ID    uint `json:"id"`
+	Roles []struct {
+		Name string `json:"name"`
+	} `json:"roles"`
+}
+
+func sendJSON(e *echo.Echo, method, path, accessToken string, body interface{}) *httptest.ResponseRecorder {
+	var jsonBody []byte
+	if body != nil {
+		jsonBody, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(jsonBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if accessToken != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// registerUser registers and verifies a user and returns a session with the
+// permissions of a verified account
+func registerUser(t *testing.T, e *echo.Echo, email string) (loginResponse, userResponse) {
+	rec := sendJSON(e, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":    email,
+		"password": "secret123",
+		"name":     "Regular User",
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Failed to register: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		loginResponse
+		User userResponse `json:"user"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode register response: %v", err)
+	}
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": verificationToken(t, email)})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to verify email: %d %s", rec.Code, rec.Body.String())
+	}
+	return loginAs(t, e, email, "secret123"), response.User
+}
+
+func TestRBAC_UserLacksCategoryWrite(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, user := registerUser(t, e, "reader@example.com")
+	if assert.Len(t, user.Roles, 1) {
+		assert.Equal(t, "user", user.Roles[0].Name)
+	}
+
+	rec := sendJSON(e, http.MethodPost, "/api/admin/categories", login.Token, map[string]string{"name": "Fiction"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "permission_denied", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/roles", login.Token, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRBAC_CustomRoleGrantsPermission(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	userLogin, user := registerUser(t, e, "librarian@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/admin/roles", admin.Token, map[string]interface{}{
+		"name":        "librarian",
+		"permissions": []string{"book:read", "book:write", "category:write"},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/admin/roles", admin.Token, map[string]interface{}{
+		"name":        "broken",
+		"permissions": []string{"does:not:exist"},
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	path := fmt.Sprintf("/api/admin/users/%d/roles", user.ID)
+	rec = sendJSON(e, http.MethodPut, path, admin.Token, map[string]interface{}{"roles": []string{"librarian"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Changing roles revokes existing sessions so new permissions apply at once
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, userLogin.Token).Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "librarian@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var relogin loginResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &relogin)
+
+	rec = sendJSON(e, http.MethodPost, "/api/admin/categories", relogin.Token, map[string]string{"name": "Fiction"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestRBAC_RemovingPermissionRevokesSessions(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	_, user := registerUser(t, e, "curator@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/admin/roles", admin.Token, map[string]interface{}{
+		"name":        "curator",
+		"permissions": []string{"book:read", "category:write"},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var role struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &role)
+
+	rec = sendJSON(e, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/roles", user.ID), admin.Token, map[string]interface{}{"roles": []string{"curator"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	login := loginAs(t, e, "curator@example.com", "secret123")
+
+	// Adding a permission keeps the sessions
+	path := fmt.Sprintf("/api/admin/roles/%d", role.ID)
+	rec = sendJSON(e, http.MethodPut, path, admin.Token, map[string]interface{}{
+		"name":        "curator",
+		"permissions": []string{"book:read", "book:write", "category:write"},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusOK, getProfile(e, login.Token).Code)
+
+	// Removing one revokes them so the old token cannot use it any longer
+	rec = sendJSON(e, http.MethodPut, path, admin.Token, map[string]interface{}{
+		"name":        "curator",
+		"permissions": []string{"book:read", "book:write"},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusUnauthorized, getProfile(e, login.Token).Code)
+	rec = sendJSON(e, http.MethodPost, "/api/admin/categories", login.Token, map[string]string{"name": "Poetry"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Other users keep their sessions
+	assert.Equal(t, http.StatusOK, getProfile(e, admin.Token).Code)
+}
+
+func TestRBAC_SystemRolesAndLastAdmin(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+
+	rec := getProfile(e, admin.Token)
+	var profile userResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &profile)
+
+	path := fmt.Sprintf("/api/admin/users/%d/roles", profile.ID)
+	rec = sendJSON(e, http.MethodPut, path, admin.Token, map[string]interface{}{"roles": []string{"user"}})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "last_admin", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/roles", admin.Token, nil)
+	var roles []struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &roles)
+	for _, role := range roles {
+		rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/admin/roles/%d", role.ID), admin.Token, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_AccountLockout(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	_, user := registerUser(t, e, "sybil@example.com")
+
+	wrong := loginRequest{Email: "sybil@example.com", Password: "wrong-password"}
+	for i := 0; i < 5; i++ {
+		rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", wrong)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	// Locked out accounts are refused even with the right password
+	rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "sybil@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "too_many_attempts", decodeProblem(t, rec).Code)
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.Greater(t, retryAfter, 0)
+
+	rec = sendJSON(e, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/unlock", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "sybil@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestThrottle_PerIP(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	// Spreading guesses over many accounts still trips the per-IP limit
+	for i := 0; i < 20; i++ {
+		rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{
+			Email:    fmt.Sprintf("guess%d@example.com", i),
+			Password: "wrong-password",
+		})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "admin@example.com", Password: "admin123"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestThrottle_PasswordReset(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	for i := 0; i < 5; i++ {
+		rec := sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "admin@example.com"})
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/forgot-password", "", map[string]string{"email": "admin@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestThrottle_ResendVerification(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	registerUser(t, e, "yara@example.com")
+	sent := len(sentMail.messages)
+
+	for i := 0; i < 5; i++ {
+		rec := sendJSON(e, http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": "yara@example.com"})
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+
+	rec := sendJSON(e, http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": "yara@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "too_many_attempts", decodeProblem(t, rec).Code)
+	assert.Len(t, sentMail.messages, sent+5)
+}
+
+func TestThrottle_CurrentPassword(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	login, _ := registerUser(t, e, "zane@example.com")
+
+	// Guesses count across every endpoint that asks for the current password
+	guesses := []struct {
+		method, path string
+		body         map[string]string
+	}{
+		{http.MethodPost, "/api/profile/password", map[string]string{"current_password": "guess1", "new_password": "newsecret123"}},
+		{http.MethodPost, "/api/profile/email", map[string]string{"email": "zane2@example.com", "password": "guess2"}},
+		{http.MethodDelete, "/api/profile", map[string]string{"password": "guess3"}},
+		{http.MethodPost, "/api/profile/password", map[string]string{"current_password": "guess4", "new_password": "newsecret123"}},
+		{http.MethodDelete, "/api/profile", map[string]string{"password": "guess5"}},
+	}
+	for _, guess := range guesses {
+		rec := sendJSON(e, guess.method, guess.path, login.Token, guess.body)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+
+	// Blocked even with the right password
+	rec := sendJSON(e, http.MethodPost, "/api/profile/password", login.Token, map[string]string{
+		"current_password": "secret123",
+		"new_password":     "newsecret123",
+	})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "too_many_attempts", decodeProblem(t, rec).Code)
+}
@@ -0,0 +1,163 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"dot-be-go/pkg/totp"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type twoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// totpCode returns the code of a secret for the time step offset from now
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := totp.Code(secret, totp.Counter(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Failed to generate TOTP code: %v", err)
+	}
+	return code
+}
+
+// enrolTwoFactor enables 2FA for the session's user and returns the secret
+// and recovery codes
+func enrolTwoFactor(t *testing.T, e *echo.Echo, accessToken string) (string, []string) {
+	rec := sendJSON(e, http.MethodPost, "/api/profile/2fa/setup", accessToken, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to set up 2FA: %d %s", rec.Code, rec.Body.String())
+	}
+	var setup twoFactorSetupResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &setup)
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/2fa/confirm", accessToken, map[string]string{"code": totpCode(t, setup.Secret, 0)})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to confirm 2FA: %d %s", rec.Code, rec.Body.String())
+	}
+	var codes recoveryCodesResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &codes)
+	return setup.Secret, codes.RecoveryCodes
+}
+
+func startMFALogin(t *testing.T, e *echo.Echo, email, password string) mfaChallengeResponse {
+	rec := sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: email, Password: password})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var challenge mfaChallengeResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &challenge)
+	assert.True(t, challenge.MFARequired)
+	assert.NotContains(t, rec.Body.String(), `"refresh_token"`)
+	return challenge
+}
+
+func TestTwoFactor_EnrolmentAndLogin(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	session, _ := registerUser(t, e, "quentin@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/profile/2fa/setup", session.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var setup twoFactorSetupResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &setup)
+	assert.True(t, strings.HasPrefix(setup.URI, "otpauth://totp/"))
+	assert.True(t, strings.HasPrefix(setup.QRCode, "data:image/png;base64,"))
+
+	rec = sendJSON(e, http.MethodPost, "/api/profile/2fa/confirm", session.Token, map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	code := totpCode(t, setup.Secret, 0)
+	rec = sendJSON(e, http.MethodPost, "/api/profile/2fa/confirm", session.Token, map[string]string{"code": code})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var codes recoveryCodesResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &codes)
+	assert.Len(t, codes.RecoveryCodes, 10)
+
+	// The code used for enrolment cannot be replayed
+	challenge := startMFALogin(t, e, "quentin@example.com", "secret123")
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": code})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": totpCode(t, setup.Secret, 1)})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"refresh_token"`)
+
+	// Recovery codes work once
+	challenge = startMFALogin(t, e, "quentin@example.com", "secret123")
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": codes.RecoveryCodes[0]})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": codes.RecoveryCodes[0]})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": "forged.token", "code": codes.RecoveryCodes[1]})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "invalid_mfa_token", decodeProblem(t, rec).Code)
+
+	rec = sendJSON(e, http.MethodDelete, "/api/profile/2fa", session.Token, map[string]string{"password": "secret123", "code": codes.RecoveryCodes[1]})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", loginRequest{Email: "quentin@example.com", Password: "secret123"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"refresh_token"`)
+}
+
+func TestTwoFactor_RequiredByRole(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	_, user := registerUser(t, e, "rupert@example.com")
+
+	rec := sendJSON(e, http.MethodPost, "/api/admin/roles", admin.Token, map[string]interface{}{
+		"name":               "curator",
+		"permissions":        []string{"category:write"},
+		"require_two_factor": true,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = sendJSON(e, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/roles", user.ID), admin.Token, map[string]interface{}{"roles": []string{"curator"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Without 2FA the role grants nothing, but the user can still enrol
+	session := loginAs(t, e, "rupert@example.com", "secret123")
+	rec = sendJSON(e, http.MethodPost, "/api/admin/categories", session.Token, map[string]string{"name": "Poetry"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	secret, _ := enrolTwoFactor(t, e, session.Token)
+
+	rec = sendJSON(e, http.MethodDelete, "/api/profile/2fa", session.Token, map[string]string{"password": "secret123", "code": totpCode(t, secret, 1)})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "two_factor_required", decodeProblem(t, rec).Code)
+
+	challenge := startMFALogin(t, e, "rupert@example.com", "secret123")
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{"mfa_token": challenge.MFAToken, "code": totpCode(t, secret, 1)})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var full loginResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &full)
+
+	rec = sendJSON(e, http.MethodPost, "/api/admin/categories", full.Token, map[string]string{"name": "Poetry"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
@@ -0,0 +1,142 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userListResponse struct {
+	Data []struct {
+		ID    uint   `json:"id"`
+		Email string `json:"email"`
+	} `json:"data"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+}
+
+func decodeUserList(t *testing.T, rec *httptest.ResponseRecorder) userListResponse {
+	var response userListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode user list: %v", err)
+	}
+	return response
+}
+
+func TestAdminUsers_ListAndSearch(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	registerUser(t, e, "alice@example.com")
+	registerUser(t, e, "bob@example.com")
+
+	rec := sendJSON(e, http.MethodGet, "/api/admin/users?limit=2", admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	all := decodeUserList(t, rec)
+	assert.Equal(t, int64(3), all.Meta.Total)
+	assert.Len(t, all.Data, 2)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?q=ALICE", admin.Token, nil)
+	found := decodeUserList(t, rec)
+	if assert.Len(t, found.Data, 1) {
+		assert.Equal(t, "alice@example.com", found.Data[0].Email)
+	}
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?q=_", admin.Token, nil)
+	assert.Equal(t, int64(0), decodeUserList(t, rec).Meta.Total)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?role=admin", admin.Token, nil)
+	assert.Equal(t, int64(1), decodeUserList(t, rec).Meta.Total)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?status=unknown", admin.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAdminUsers_SuspendBlocksAccess(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	userLogin, user := registerUser(t, e, "carol@example.com")
+	assert.Equal(t, http.StatusOK, getProfile(e, userLogin.Token).Code)
+
+	rec := sendJSON(e, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/suspend", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = getProfile(e, userLogin.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "account_suspended", decodeProblem(t, rec).Code)
+
+	credentials := loginRequest{Email: "carol@example.com", Password: "secret123"}
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", credentials)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?status=suspended", admin.Token, nil)
+	assert.Equal(t, int64(1), decodeUserList(t, rec).Meta.Total)
+
+	rec = sendJSON(e, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/unsuspend", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", credentials)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminUsers_DeleteAndRestore(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	_, user := registerUser(t, e, "dave@example.com")
+	credentials := loginRequest{Email: "dave@example.com", Password: "secret123"}
+
+	rec := sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/admin/users/%d", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", credentials)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendJSON(e, http.MethodGet, "/api/admin/users?status=deleted", admin.Token, nil)
+	assert.Equal(t, int64(1), decodeUserList(t, rec).Meta.Total)
+
+	rec = sendJSON(e, http.MethodGet, fmt.Sprintf("/api/admin/users/%d", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/restore", user.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendJSON(e, http.MethodPost, "/api/auth/login", "", credentials)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminUsers_CannotDisableSelf(t *testing.T) {
+	e, db, _ := setupTestEnvironment(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	admin := loginAsAdmin(t, e)
+	var profile userResponse
+	_ = json.Unmarshal(getProfile(e, admin.Token).Body.Bytes(), &profile)
+
+	rec := sendJSON(e, http.MethodPost, fmt.Sprintf("/api/admin/users/%d/suspend", profile.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = sendJSON(e, http.MethodDelete, fmt.Sprintf("/api/admin/users/%d", profile.ID), admin.Token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}